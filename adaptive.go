@@ -0,0 +1,98 @@
+package limiter
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// AdaptiveConfig turns on AIMD (additive increase, multiplicative decrease)
+// control of the token bucket refill rate. Every Interval the rate grows by
+// IncreaseStep if all observed responses were healthy, or is multiplied by
+// DecreaseFactor if any of them was a 5xx or slower than LatencyThreshold.
+type AdaptiveConfig struct {
+	MinRate          int
+	MaxRate          int
+	IncreaseStep     int
+	DecreaseFactor   float64
+	LatencyThreshold time.Duration
+	Interval         time.Duration
+}
+
+func (a *AdaptiveConfig) Validate() error {
+	if a.MinRate <= 0 {
+		return errors.New("Adaptive.MinRate must be greater than 0")
+	}
+	if a.MaxRate < a.MinRate {
+		return errors.New("Adaptive.MaxRate must not be less than MinRate")
+	}
+	if a.IncreaseStep <= 0 {
+		return errors.New("Adaptive.IncreaseStep must be greater than 0")
+	}
+	if a.DecreaseFactor <= 0 || a.DecreaseFactor >= 1 {
+		return errors.New("Adaptive.DecreaseFactor must be between 0 and 1")
+	}
+	if a.Interval < 0 {
+		return errors.New("Adaptive.Interval must not be negative")
+	}
+	return nil
+}
+
+type aimd struct {
+	config      AdaptiveConfig
+	interval    time.Duration
+	rate        int
+	windowStart time.Time
+	failures    int
+	mutex       sync.Mutex
+}
+
+func newAIMD(config AdaptiveConfig, rate int, interval time.Duration) *aimd {
+	a := &aimd{config: config}
+	a.rebase(rate, interval, time.Now())
+	return a
+}
+
+// rebase restarts the control from rate, e.g. after UpdateConfig changed
+// the refill rate. interval is used unless the config sets its own.
+func (a *aimd) rebase(rate int, interval time.Duration, now time.Time) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.interval = a.config.Interval
+	if a.interval == 0 {
+		a.interval = interval
+	}
+	a.rate = maxInt(minInt(rate, a.config.MaxRate), a.config.MinRate)
+	a.windowStart = now
+	a.failures = 0
+}
+
+func (a *aimd) current() int {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.rate
+}
+
+func (a *aimd) observe(status int, latency time.Duration, now time.Time) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if status >= http.StatusInternalServerError ||
+		(a.config.LatencyThreshold > 0 && latency > a.config.LatencyThreshold) {
+		a.failures++
+	}
+
+	if now.Sub(a.windowStart) < a.interval {
+		return
+	}
+
+	if a.failures > 0 {
+		a.rate = maxInt(int(float64(a.rate)*a.config.DecreaseFactor), a.config.MinRate)
+	} else {
+		a.rate = minInt(a.rate+a.config.IncreaseStep, a.config.MaxRate)
+	}
+	a.windowStart = now
+	a.failures = 0
+}
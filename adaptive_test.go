@@ -0,0 +1,57 @@
+package limiter
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAIMD_IncreaseAndDecrease(t *testing.T) {
+	config := AdaptiveConfig{
+		MinRate:          2,
+		MaxRate:          12,
+		IncreaseStep:     1,
+		DecreaseFactor:   0.5,
+		LatencyThreshold: time.Millisecond * 100,
+		Interval:         time.Second,
+	}
+	controller := newAIMD(config, 10, time.Second)
+	now := controller.windowStart
+
+	// 健康的响应使速率线性增长，但不超过 MaxRate
+	for i := 1; i <= 3; i++ {
+		controller.observe(http.StatusOK, time.Millisecond, now.Add(time.Second*time.Duration(i)))
+	}
+	assert.Equal(t, 12, controller.current())
+
+	// 出现 5xx 时速率成倍下降
+	controller.observe(http.StatusBadGateway, time.Millisecond, now.Add(time.Second*4))
+	assert.Equal(t, 6, controller.current())
+
+	// 延迟过高同样视为失败，且不低于 MinRate
+	controller.observe(http.StatusOK, time.Second, now.Add(time.Second*5))
+	controller.observe(http.StatusOK, time.Second, now.Add(time.Second*6))
+	assert.Equal(t, 2, controller.current())
+}
+
+func TestAdaptiveConfigValidate(t *testing.T) {
+	config := RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Second,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Adaptive: &AdaptiveConfig{
+			MinRate:        1,
+			MaxRate:        10,
+			IncreaseStep:   1,
+			DecreaseFactor: 1.5,
+		},
+	}
+	assert.Error(t, config.Validate())
+
+	config.Adaptive.DecreaseFactor = 0.5
+	assert.NoError(t, config.Validate())
+}
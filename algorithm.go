@@ -0,0 +1,87 @@
+package limiter
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	TokenBucket          = "token_bucket"
+	SlidingWindowCounter = "sliding_window_counter"
+	SlidingWindowLog     = "sliding_window_log"
+	LeakyBucket          = "leaky_bucket"
+	GCRA                 = "gcra"
+	FixedWindow          = "fixed_window"
+)
+
+// Algorithm decides whether n requests for key may proceed at now. The
+// returned duration is how long to wait: before retrying when denied, or
+// before proceeding when the request was admitted into a queue.
+type Algorithm interface {
+	Take(key string, n int, now time.Time) (allowed bool, retryAfter time.Duration)
+}
+
+// Cleaner is implemented by algorithms that keep per-key state, so that
+// CleanupExpiredBuckets can drop keys idle for longer than expiration.
+type Cleaner interface {
+	Cleanup(now time.Time, expiration time.Duration)
+}
+
+// AlgorithmFactory builds an Algorithm from a validated configuration.
+type AlgorithmFactory func(config RateLimitConfig) Algorithm
+
+var (
+	algorithmsMutex sync.RWMutex
+	algorithms      = make(map[string]AlgorithmFactory)
+)
+
+func init() {
+	RegisterAlgorithm(SlidingWindowCounter, func(config RateLimitConfig) Algorithm {
+		return newSlidingWindowCounter(config.MaxTokens, config.RefillInterval)
+	})
+	RegisterAlgorithm(SlidingWindowLog, func(config RateLimitConfig) Algorithm {
+		return newSlidingWindowLog(config.MaxTokens, config.RefillInterval)
+	})
+	RegisterAlgorithm(LeakyBucket, func(config RateLimitConfig) Algorithm {
+		return newLeakyBucket(config.MaxTokens, config.RefillRate, config.RefillInterval)
+	})
+	RegisterAlgorithm(GCRA, func(config RateLimitConfig) Algorithm {
+		return newGCRA(config)
+	})
+	RegisterAlgorithm(FixedWindow, func(config RateLimitConfig) Algorithm {
+		return newFixedWindow(config.MaxTokens, config.RefillInterval)
+	})
+}
+
+// RegisterAlgorithm makes an algorithm selectable by name through
+// RateLimitConfig.Algorithm. It panics if name is already registered or
+// factory is nil.
+func RegisterAlgorithm(name string, factory AlgorithmFactory) {
+	algorithmsMutex.Lock()
+	defer algorithmsMutex.Unlock()
+
+	if factory == nil {
+		panic("limiter: RegisterAlgorithm factory is nil")
+	}
+	if _, dup := algorithms[name]; dup || name == "" || name == TokenBucket {
+		panic("limiter: RegisterAlgorithm called twice for " + name)
+	}
+	algorithms[name] = factory
+}
+
+func lookupAlgorithm(name string) (AlgorithmFactory, bool) {
+	algorithmsMutex.RLock()
+	defer algorithmsMutex.RUnlock()
+
+	factory, ok := algorithms[name]
+	return factory, ok
+}
+
+// newAlgorithm returns nil for the built-in token bucket, which is handled
+// by the limiter itself so that it can work with a Store.
+func newAlgorithm(config RateLimitConfig) Algorithm {
+	if factory, ok := lookupAlgorithm(config.Algorithm); ok {
+		return factory(config)
+	}
+	return nil
+}
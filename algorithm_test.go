@@ -0,0 +1,62 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// denyAll 是一个总是拒绝请求的自定义算法
+type denyAll struct{}
+
+func (denyAll) Take(key string, n int, now time.Time) (bool, time.Duration) {
+	return false, time.Second
+}
+
+func TestRegisterAlgorithm(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	RegisterAlgorithm("deny_all", func(config RateLimitConfig) Algorithm {
+		return denyAll{}
+	})
+	// 注册表是全局的，测试结束后注销，以便 -count 多次运行
+	t.Cleanup(func() {
+		algorithmsMutex.Lock()
+		defer algorithmsMutex.Unlock()
+		delete(algorithms, "deny_all")
+	})
+
+	// 重复注册同名算法应当 panic
+	assert.Panics(t, func() {
+		RegisterAlgorithm("deny_all", func(config RateLimitConfig) Algorithm { return denyAll{} })
+	})
+
+	config := RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Second,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Algorithm:          "deny_all",
+	}
+
+	limiterMiddleware, err := NewRateLimiter(config)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
@@ -0,0 +1,31 @@
+package limiter
+
+import "context"
+
+// Allow reports whether one event for key may happen now. It applies the
+// same limits as the middleware, minus those derived from a gin.Context
+// such as Scopes, so it can guard background jobs or websocket loops.
+func (rl *RateLimiter) Allow(key string) bool {
+	return rl.AllowN(key, 1)
+}
+
+// AllowN reports whether n events for key may happen now. Like the
+// middleware it fails open if the Store is unreachable. It reports false
+// for an n that is not positive. AllowN never waits: events that
+// LeakyBucket would queue are admitted at once.
+func (rl *RateLimiter) AllowN(key string, n int) bool {
+	if n <= 0 {
+		return false
+	}
+	allowed, _, err := rl.take(context.Background(), rl.storeKey(key), n, false)
+	if err != nil {
+		return true
+	}
+
+	if allowed {
+		rl.allowed.Add(1)
+	} else {
+		rl.denied.Add(1)
+	}
+	return allowed
+}
@@ -0,0 +1,58 @@
+package limiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterAllowN(t *testing.T) {
+	limiter, err := New(RateLimitConfig{
+		MaxTokens:          5,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+	})
+	assert.NoError(t, err)
+
+	assert.True(t, limiter.Allow("job"))
+	assert.True(t, limiter.AllowN("job", 4))
+	assert.False(t, limiter.Allow("job"))
+
+	// 不同的键互不影响
+	assert.True(t, limiter.AllowN("other", 5))
+	// 一次请求超过剩余令牌时不消耗任何令牌
+	assert.False(t, limiter.AllowN("fresh", 6))
+	assert.True(t, limiter.AllowN("fresh", 5))
+
+	// 非正数的 n 不会反过来增加令牌
+	assert.False(t, limiter.AllowN("fresh", -5))
+	assert.False(t, limiter.AllowN("fresh", 0))
+	assert.False(t, limiter.Allow("fresh"))
+
+	stats := limiter.Stats()
+	assert.Equal(t, uint64(4), stats.Allowed)
+	assert.Equal(t, uint64(3), stats.Denied)
+}
+
+func TestRateLimiterAllowLeakyBucket(t *testing.T) {
+	limiter, err := New(RateLimitConfig{
+		MaxTokens:          3,
+		RefillRate:         1,
+		RefillInterval:     time.Second,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Algorithm:          LeakyBucket,
+	})
+	assert.NoError(t, err)
+
+	// 漏桶会排队的请求立即放行，不会阻塞调用方
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		assert.True(t, limiter.Allow("job"))
+	}
+	assert.False(t, limiter.Allow("job"))
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+}
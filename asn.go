@@ -0,0 +1,55 @@
+package limiter
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ASNResolver returns the number of the autonomous system announcing ip,
+// or 0 if it is unknown. The maxmind package provides one backed by a
+// MaxMind database.
+type ASNResolver interface {
+	ASN(ip net.IP) (uint, error)
+}
+
+// clientASN resolves the AS of c.ClientIP(), reporting lookup failures
+// on c and returning 0 for them.
+func clientASN(c *gin.Context, resolver ASNResolver) uint {
+	ip := net.ParseIP(c.ClientIP())
+	if ip == nil {
+		return 0
+	}
+	asn, err := resolver.ASN(ip)
+	if err != nil {
+		_ = c.Error(err)
+		return 0
+	}
+	return asn
+}
+
+// ByASN keys requests by the autonomous system of the client, e.g.
+// "AS16509", so one bucket covers a whole network. Clients whose AS is
+// unknown are keyed like ByClientIP instead.
+func ByASN(resolver ASNResolver) func(*gin.Context) string {
+	clientIP := ByClientIP()
+	return func(c *gin.Context) string {
+		if asn := clientASN(c, resolver); asn != 0 {
+			return "AS" + strconv.FormatUint(uint64(asn), 10)
+		}
+		return clientIP(c)
+	}
+}
+
+// ASNClass is a PolicySet selector that names the class of a request by
+// the AS of the client, e.g. mapping cloud providers to "hosting". Other
+// ASes give "".
+func ASNClass(resolver ASNResolver, classes map[uint]string) func(*gin.Context) string {
+	return func(c *gin.Context) string {
+		if asn := clientASN(c, resolver); asn != 0 {
+			return classes[asn]
+		}
+		return ""
+	}
+}
@@ -0,0 +1,47 @@
+package limiter
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type testASNResolver map[string]uint
+
+func (r testASNResolver) ASN(ip net.IP) (uint, error) {
+	if ip.String() == "198.51.100.1" {
+		return 0, errors.New("lookup failed")
+	}
+	return r[ip.String()], nil
+}
+
+func TestASNKeys(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	resolver := testASNResolver{"203.0.113.1": 16509, "192.0.2.1": 7922}
+	client := func(ip string) *gin.Context {
+		return newKeyContext(func(req *http.Request) { req.RemoteAddr = ip + ":1234" })
+	}
+
+	// 按自治系统生成键
+	assert.Equal(t, "AS16509", ByASN(resolver)(client("203.0.113.1")))
+
+	// 未知或查询失败时回退到客户端 IP
+	assert.Equal(t, "192.168.1.1", ByASN(resolver)(client("192.168.1.1")))
+	// 回退时与 ByClientIP 一样按 /64 聚合 IPv6 地址
+	assert.Equal(t, "2001:db8::/64", ByASN(resolver)(client("[2001:db8::1]")))
+	c := client("198.51.100.1")
+	assert.Equal(t, "198.51.100.1", ByASN(resolver)(c))
+	assert.Len(t, c.Errors, 1)
+
+	// 按自治系统划分类别
+	classes := ASNClass(resolver, map[uint]string{16509: "hosting"})
+	assert.Equal(t, "hosting", classes(client("203.0.113.1")))
+	assert.Equal(t, "", classes(client("192.0.2.1")))
+	assert.Equal(t, "", classes(client("192.168.1.1")))
+}
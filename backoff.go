@@ -0,0 +1,99 @@
+package limiter
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// BackoffRule is the Rule reported in LimitInfo when DryRun admits a request
+// that Backoff would have rejected.
+const BackoffRule = "backoff"
+
+// BackoffConfig escalates the penalty for keys that keep sending requests
+// while limited. Every denial blocks the key for Base, doubled for each
+// earlier violation and capped at Max; requests during the block count as
+// violations too. Each Decay without a violation forgives one of them.
+type BackoffConfig struct {
+	Base  time.Duration
+	Max   time.Duration
+	Decay time.Duration
+}
+
+func (b *BackoffConfig) Validate() error {
+	if b.Base <= 0 {
+		return errors.New("Backoff.Base must be greater than 0")
+	}
+	if b.Max < b.Base {
+		return errors.New("Backoff.Max must not be less than Backoff.Base")
+	}
+	if b.Decay <= 0 {
+		return errors.New("Backoff.Decay must be greater than 0")
+	}
+	return nil
+}
+
+type penalty struct {
+	level         int
+	lastViolation time.Time
+	until         time.Time
+}
+
+type backoff struct {
+	config BackoffConfig
+	keys   map[string]*penalty
+	mutex  sync.Mutex
+}
+
+func newBackoff(config BackoffConfig) *backoff {
+	return &backoff{
+		config: config,
+		keys:   make(map[string]*penalty),
+	}
+}
+
+// blocked returns how long key stays blocked, or 0.
+func (b *backoff) blocked(key string, now time.Time) time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if p, exists := b.keys[key]; exists && now.Before(p.until) {
+		return p.until.Sub(now)
+	}
+	return 0
+}
+
+// violate records a violation of key and returns how long it is blocked.
+func (b *backoff) violate(key string, now time.Time) time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	p, exists := b.keys[key]
+	if !exists {
+		p = &penalty{}
+		b.keys[key] = p
+	}
+	p.level = maxInt(p.level-int(now.Sub(p.lastViolation)/b.config.Decay), 0) + 1
+	p.lastViolation = now
+
+	block := b.config.Base
+	for i := 1; i < p.level && block < b.config.Max; i++ {
+		block *= 2
+	}
+	if block > b.config.Max {
+		block = b.config.Max
+	}
+	p.until = now.Add(block)
+	return block
+}
+
+func (b *backoff) cleanup(now time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for key, p := range b.keys {
+		if now.After(p.until) && now.Sub(p.lastViolation) >= time.Duration(p.level)*b.config.Decay {
+			delete(b.keys, key)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoffEscalation(t *testing.T) {
+	b := newBackoff(BackoffConfig{Base: time.Second, Max: time.Second * 10, Decay: time.Minute})
+	now := time.Now()
+
+	// 每次违规使封锁时间翻倍，直到上限
+	assert.Equal(t, time.Second, b.violate("key", now))
+	assert.Equal(t, time.Second*2, b.violate("key", now))
+	assert.Equal(t, time.Second*4, b.violate("key", now))
+	assert.Equal(t, time.Second*8, b.violate("key", now))
+	assert.Equal(t, time.Second*10, b.violate("key", now))
+	assert.Equal(t, time.Second*10, b.blocked("key", now))
+	assert.Equal(t, time.Duration(0), b.blocked("key", now.Add(time.Second*10)))
+
+	// 每个衰减周期免除一次违规
+	now = now.Add(time.Minute * 3)
+	assert.Equal(t, time.Second*4, b.violate("key", now))
+
+	// 完全衰减后记录被清理
+	b.cleanup(now.Add(time.Minute))
+	assert.Contains(t, b.keys, "key")
+	b.cleanup(now.Add(time.Minute * 3))
+	assert.NotContains(t, b.keys, "key")
+}
+
+func TestBackoffMiddleware(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Second,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Backoff:            &BackoffConfig{Base: time.Second * 10, Max: time.Minute, Decay: time.Hour},
+		LimitExceededFunc: func(c *gin.Context, info LimitInfo) {
+			c.String(http.StatusTooManyRequests, info.Rule)
+		},
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	// 持续请求使等待时间指数增长，封锁期间的拒绝同样交给处理函数
+	for i, want := range []string{"10", "20", "40", "60"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, want, w.Header().Get("Retry-After"))
+		if i > 0 {
+			assert.Equal(t, BackoffRule, w.Body.String())
+			assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+		}
+	}
+}
+
+func TestValidateBackoff(t *testing.T) {
+	config := RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Backoff:            &BackoffConfig{Base: time.Minute, Max: time.Second, Decay: time.Hour},
+	}
+	assert.EqualError(t, config.Validate(), "Backoff.Max must not be less than Backoff.Base")
+}
@@ -0,0 +1,77 @@
+package limiter
+
+import (
+	"sync"
+	"time"
+)
+
+// BanRule is the Rule reported in LimitInfo when DryRun admits a request
+// that a ban would have rejected.
+const BanRule = "ban"
+
+type violation struct {
+	denials  int
+	lastSeen time.Time
+	until    time.Time
+}
+
+// banList bans keys that keep getting denied. A key is banned for duration
+// once it has been denied threshold times in a row; an admitted request
+// clears its record.
+type banList struct {
+	threshold int
+	duration  time.Duration
+	keys      map[string]*violation
+	mutex     sync.Mutex
+}
+
+func newBanList(threshold int, duration time.Duration) *banList {
+	return &banList{
+		threshold: threshold,
+		duration:  duration,
+		keys:      make(map[string]*violation),
+	}
+}
+
+// banned returns how long key stays banned, or 0.
+func (b *banList) banned(key string, now time.Time) time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if v, exists := b.keys[key]; exists && now.Before(v.until) {
+		return v.until.Sub(now)
+	}
+	return 0
+}
+
+func (b *banList) record(key string, denied bool, now time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !denied {
+		delete(b.keys, key)
+		return
+	}
+	v, exists := b.keys[key]
+	if !exists {
+		v = &violation{}
+		b.keys[key] = v
+	}
+	v.denials++
+	v.lastSeen = now
+	if v.denials >= b.threshold {
+		v.denials = 0
+		v.until = now.Add(b.duration)
+	}
+}
+
+func (b *banList) cleanup(now time.Time, expiration time.Duration) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for key, v := range b.keys {
+		if now.After(v.until) && now.Sub(v.lastSeen) > expiration {
+			delete(b.keys, key)
+		}
+	}
+}
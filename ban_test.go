@@ -0,0 +1,91 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBanList(t *testing.T) {
+	bans := newBanList(3, time.Minute)
+	now := time.Now()
+
+	// 被允许的请求清除连续拒绝的记录
+	bans.record("key", true, now)
+	bans.record("key", true, now)
+	bans.record("key", false, now)
+	bans.record("key", true, now)
+	bans.record("key", true, now)
+	assert.Equal(t, time.Duration(0), bans.banned("key", now))
+
+	// 连续第三次被拒绝后封禁
+	bans.record("key", true, now)
+	assert.Equal(t, time.Minute, bans.banned("key", now))
+	assert.Equal(t, time.Duration(0), bans.banned("key", now.Add(time.Minute)))
+
+	// 封禁结束且长期不活跃的记录被清理
+	bans.cleanup(now.Add(time.Minute*2), time.Minute*5)
+	assert.Contains(t, bans.keys, "key")
+	bans.cleanup(now.Add(time.Minute*10), time.Minute*5)
+	assert.NotContains(t, bans.keys, "key")
+}
+
+func TestBanMiddleware(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	limiter, err := New(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Second,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		BanThreshold:       2,
+		BanDuration:        time.Hour,
+		LimitExceededFunc: func(c *gin.Context, info LimitInfo) {
+			c.String(http.StatusTooManyRequests, info.Rule)
+		},
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiter.RateLimitMiddleware())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+	for _, want := range []int{http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, want, w.Code)
+	}
+
+	// 封禁期间即使令牌恢复也直接拒绝，并像普通拒绝一样交给处理函数
+	limiter.getBucket("192.168.1.1").refund(1)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, BanRule, w.Body.String())
+	assert.Equal(t, "3600", w.Header().Get("Retry-After"))
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Remaining"))
+}
+
+func TestValidateBan(t *testing.T) {
+	config := RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		BanThreshold:       3,
+	}
+	assert.EqualError(t, config.Validate(), "BanDuration must be greater than 0")
+}
@@ -0,0 +1,54 @@
+package limiter
+
+import (
+	"encoding/json"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultDenialHTML is a minimal page for DenialHTML.
+const DefaultDenialHTML = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Slow down</title></head>
+<body>
+<h1>Slow down</h1>
+<p>You are sending requests too quickly. This page will reload in {retry_after} seconds.</p>
+</body>
+</html>
+`
+
+// renderDenialBody fills the placeholders of a DenialBody template. Text
+// values are escaped for use inside a JSON string; reset and retry_after
+// are in seconds.
+func renderDenialBody(template string, d denial) []byte {
+	return renderDenial(template, d, func(s string) string {
+		quoted, _ := json.Marshal(s)
+		return string(quoted[1 : len(quoted)-1])
+	})
+}
+
+// renderDenialHTML fills the same placeholders into a DenialHTML page.
+func renderDenialHTML(template string, d denial) []byte {
+	return renderDenial(template, d, html.EscapeString)
+}
+
+// acceptsHTML reports whether the client is a browser asking for a page.
+func acceptsHTML(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), "text/html")
+}
+
+func renderDenial(template string, d denial, escape func(string) string) []byte {
+	replacer := strings.NewReplacer(
+		"{key}", escape(d.info.Key),
+		"{message}", escape(d.message),
+		"{limit}", strconv.Itoa(d.info.Limit),
+		"{remaining}", strconv.Itoa(d.info.Remaining),
+		"{reset}", strconv.Itoa(ceilDiv(time.Until(d.info.Reset), time.Second)),
+		"{retry_after}", strconv.Itoa(maxInt(ceilDiv(d.info.RetryAfter, time.Second), 1)),
+	)
+	return []byte(replacer.Replace(template))
+}
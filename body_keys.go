@@ -0,0 +1,101 @@
+package limiter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ByBody keys requests by a value that extract finds in the first
+// maxBytes of the request body. The bytes read are put back in front of
+// the rest of the body, so handlers still see all of it. Larger bodies
+// are not parsed and give "", so the request falls back to
+// FallbackKeyFunc and huge uploads are not buffered by the limiter.
+func ByBody(maxBytes int64, extract func(body []byte) string) func(*gin.Context) string {
+	return func(c *gin.Context) string {
+		body, ok := peekBody(c, maxBytes)
+		if !ok {
+			return ""
+		}
+		return extract(body)
+	}
+}
+
+// peekBody returns the body of c if it is at most maxBytes long and puts
+// what it read back in front of the rest.
+func peekBody(c *gin.Context, maxBytes int64) ([]byte, bool) {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil, false
+	}
+	original := c.Request.Body
+	buf, err := io.ReadAll(io.LimitReader(original, maxBytes+1))
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), original), original}
+	if err != nil || int64(len(buf)) > maxBytes {
+		return nil, false
+	}
+	return buf, true
+}
+
+// ByJSONField keys requests by a field of a JSON body, given as a dotted
+// path such as "account.id". Strings and numbers are used as they are;
+// missing fields and other types give "".
+func ByJSONField(path string, maxBytes int64) func(*gin.Context) string {
+	fields := strings.Split(path, ".")
+	return ByBody(maxBytes, func(body []byte) string {
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		var value any
+		if decoder.Decode(&value) != nil {
+			return ""
+		}
+		for _, field := range fields {
+			object, ok := value.(map[string]any)
+			if !ok {
+				return ""
+			}
+			value = object[field]
+		}
+		switch value := value.(type) {
+		case string:
+			return value
+		case json.Number:
+			return value.String()
+		case bool:
+			return strconv.FormatBool(value)
+		}
+		return ""
+	})
+}
+
+var graphQLOperation = regexp.MustCompile(`^\s*(?:query|mutation|subscription)\s+([_A-Za-z][_0-9A-Za-z]*)`)
+
+// ByGraphQLOperation keys requests by the operation name of a GraphQL
+// request, taken from "operationName" or else from the query document.
+// Anonymous operations give "".
+func ByGraphQLOperation(maxBytes int64) func(*gin.Context) string {
+	return ByBody(maxBytes, func(body []byte) string {
+		var request struct {
+			OperationName string `json:"operationName"`
+			Query         string `json:"query"`
+		}
+		if json.Unmarshal(body, &request) != nil {
+			return ""
+		}
+		if request.OperationName != "" {
+			return request.OperationName
+		}
+		if match := graphQLOperation.FindStringSubmatch(request.Query); match != nil {
+			return match[1]
+		}
+		return ""
+	})
+}
@@ -0,0 +1,130 @@
+package limiter
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestByJSONField(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            ByJSONField("account.id", 1024),
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.POST("/", func(c *gin.Context) {
+		// 处理函数仍然可以读取完整的请求体
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Data(http.StatusOK, "application/json", body)
+	})
+
+	for _, tt := range []struct {
+		body string
+		want int
+	}{
+		{`{"account":{"id":7},"x":1}`, http.StatusOK},
+		{`{"account":{"id":7},"x":2}`, http.StatusTooManyRequests},
+		{`{"account":{"id":"8"}}`, http.StatusOK},
+	} {
+		req, _ := http.NewRequest("POST", "/", strings.NewReader(tt.body))
+		req.RemoteAddr = "192.168.1.1:1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tt.want, w.Code, tt.body)
+		if w.Code == http.StatusOK {
+			assert.Equal(t, tt.body, w.Body.String())
+		}
+	}
+}
+
+func TestByBodyLimit(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	keyFunc := ByJSONField("id", 16)
+	body := `{"id":"a","padding":"0123456789"}`
+	c := newKeyContext(func(req *http.Request) {})
+	c.Request, _ = http.NewRequest("POST", "/", strings.NewReader(body))
+
+	// 超过上限的请求体不解析，但处理函数仍能读取完整的请求体
+	assert.Equal(t, "", keyFunc(c))
+	restored, err := io.ReadAll(c.Request.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, body, string(restored))
+
+	c.Request, _ = http.NewRequest("GET", "/", nil)
+	assert.Equal(t, "", keyFunc(c))
+}
+
+func TestByBodyLargeBody(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            ByJSONField("id", 16),
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.POST("/", func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatus(http.StatusRequestEntityTooLarge)
+			return
+		}
+		c.Data(http.StatusOK, "application/json", body)
+	})
+
+	// 超过上限的请求体按回退键限流，处理函数读取到完整内容
+	body := `{"id":"a","padding":"` + strings.Repeat("0", 100) + `"}`
+	req, _ := http.NewRequest("POST", "/", strings.NewReader(body))
+	req.RemoteAddr = "192.168.1.1:1234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, body, w.Body.String())
+}
+
+func TestByGraphQLOperation(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	keyFunc := ByGraphQLOperation(1024)
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"operationName":"GetUser","query":"query GetUser { user { id } }"}`, "GetUser"},
+		{`{"query":"  mutation CreatePost($t: String) { createPost(title: $t) { id } }"}`, "CreatePost"},
+		// 匿名操作没有名称
+		{`{"query":"{ user { id } }"}`, ""},
+		{`not json`, ""},
+	}
+	for _, tt := range tests {
+		c := newKeyContext(func(req *http.Request) {})
+		c.Request, _ = http.NewRequest("POST", "/graphql", strings.NewReader(tt.body))
+		assert.Equal(t, tt.want, keyFunc(c), tt.body)
+	}
+}
@@ -0,0 +1,99 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderDenialBody(t *testing.T) {
+	body := renderDenialBody(`{"key":"{key}","limit":{limit},"remaining":{remaining},"reset":{reset},"retry":{retry_after}}`, denial{
+		info: LimitInfo{
+			Key:        `a"b`,
+			Limit:      10,
+			Reset:      time.Now().Add(time.Millisecond * 1500),
+			RetryAfter: time.Millisecond * 200,
+		},
+	})
+
+	// 键中的特殊字符会被转义，时间向上取整为秒
+	assert.Equal(t, `{"key":"a\"b","limit":10,"remaining":0,"reset":2,"retry":1}`, string(body))
+}
+
+func TestDenialBodyMiddleware(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		DenialBody:         `{"error":"slow down","client":"{key}","limit":{limit},"retry_after":{retry_after}}`,
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"error":"slow down","client":"192.168.1.1","limit":1,"retry_after":60}`, w.Body.String())
+}
+
+func TestDenialHTMLMiddleware(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Second * 30,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		DenialBody:         `{"error":"slow down"}`,
+		DenialHTML:         DefaultDenialHTML,
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	// 浏览器请求得到 HTML 页面，并在可以重试时自动刷新
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "30", w.Header().Get("Refresh"))
+	assert.Contains(t, w.Body.String(), "reload in 30 seconds")
+
+	// 其他客户端仍然得到 JSON
+	req.Header.Set("Accept", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, `{"error":"slow down"}`, w.Body.String())
+}
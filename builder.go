@@ -0,0 +1,121 @@
+package limiter
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConfigBuilder assembles a RateLimitConfig step by step:
+//
+//	middleware, err := limiter.Builder().PerIP().Limit(100, time.Minute).Burst(20).Build()
+//
+// Options that it does not cover can be set on the result of Config.
+type ConfigBuilder struct {
+	config RateLimitConfig
+	burst  int
+}
+
+// Builder starts a config that limits each client IP, but has no limit
+// yet; Limit must be called before Build.
+func Builder() *ConfigBuilder {
+	return &ConfigBuilder{config: RateLimitConfig{BurstMultiplier: 1}}
+}
+
+func (b *ConfigBuilder) PerIP() *ConfigBuilder {
+	b.config.KeyFunc = ByClientIP()
+	return b
+}
+
+func (b *ConfigBuilder) PerHeader(name string) *ConfigBuilder {
+	b.config.KeyFunc = ByHeader(name)
+	return b
+}
+
+func (b *ConfigBuilder) PerKey(keyFunc func(*gin.Context) string) *ConfigBuilder {
+	b.config.KeyFunc = keyFunc
+	return b
+}
+
+// Limit allows n requests per period, restored one at a time, and up to
+// n at once unless Burst says otherwise.
+func (b *ConfigBuilder) Limit(n int, per time.Duration) *ConfigBuilder {
+	b.config.MaxTokens = n
+	b.config.RefillRate = 1
+	b.config.RefillInterval = per
+	if n > 0 {
+		b.config.RefillInterval = per / time.Duration(n)
+	}
+	return b
+}
+
+// Burst sets how many requests a key may send at once after a quiet
+// spell, independently of its sustained rate.
+func (b *ConfigBuilder) Burst(n int) *ConfigBuilder {
+	b.burst = n
+	return b
+}
+
+// Expiration sets how long idle buckets are kept. By default they are
+// kept until they are full again, but at least 10 minutes.
+func (b *ConfigBuilder) Expiration(d time.Duration) *ConfigBuilder {
+	b.config.ExpirationDuration = d
+	return b
+}
+
+func (b *ConfigBuilder) Algorithm(name string) *ConfigBuilder {
+	b.config.Algorithm = name
+	return b
+}
+
+func (b *ConfigBuilder) Store(store Store) *ConfigBuilder {
+	b.config.Store = store
+	return b
+}
+
+func (b *ConfigBuilder) Namespace(namespace string) *ConfigBuilder {
+	b.config.Namespace = namespace
+	return b
+}
+
+func (b *ConfigBuilder) Exempt(networks ...string) *ConfigBuilder {
+	b.config.ExemptNetworks = append(b.config.ExemptNetworks, networks...)
+	return b
+}
+
+func (b *ConfigBuilder) Skip(paths ...string) *ConfigBuilder {
+	b.config.SkipPaths = append(b.config.SkipPaths, paths...)
+	return b
+}
+
+func (b *ConfigBuilder) DryRun() *ConfigBuilder {
+	b.config.DryRun = true
+	return b
+}
+
+func (b *ConfigBuilder) OnLimitExceeded(handler gin.HandlerFunc) *ConfigBuilder {
+	b.config.LimitExceededHandler = handler
+	return b
+}
+
+// Config returns the config built so far, without validating it.
+func (b *ConfigBuilder) Config() RateLimitConfig {
+	config := b.config
+	if b.burst > 0 {
+		config.MaxTokens = b.burst
+	}
+	if config.ExpirationDuration == 0 && config.MaxTokens > 0 && config.RefillRate > 0 {
+		config.ExpirationDuration = defaultExpiration(&config)
+	}
+	return config
+}
+
+// Build validates the config and returns its middleware.
+func (b *ConfigBuilder) Build() (gin.HandlerFunc, error) {
+	return NewRateLimiter(b.Config())
+}
+
+// New validates the config and returns its limiter.
+func (b *ConfigBuilder) New() (*RateLimiter, error) {
+	return New(b.Config())
+}
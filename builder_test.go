@@ -0,0 +1,59 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigBuilder(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	middleware, err := Builder().PerIP().Limit(100, time.Minute).Burst(2).Build()
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(middleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 突发上限为 2
+	assert.Equal(t, http.StatusOK, request().Code)
+	assert.Equal(t, http.StatusOK, request().Code)
+	assert.Equal(t, http.StatusTooManyRequests, request().Code)
+
+	// 每分钟 100 次意味着每 600 毫秒恢复一个令牌
+	config := Builder().PerHeader("X-API-Key").Limit(100, time.Minute).Config()
+	assert.Equal(t, 100, config.MaxTokens)
+	assert.Equal(t, 1, config.RefillRate)
+	assert.Equal(t, 600*time.Millisecond, config.RefillInterval)
+	assert.Equal(t, 10*time.Minute, config.ExpirationDuration)
+
+	// 空闲的桶至少保留到重新装满
+	config = Builder().Limit(1000, time.Hour).Config()
+	assert.Equal(t, 1001*3600*time.Millisecond, config.ExpirationDuration)
+	config = Builder().Limit(1, time.Second).Expiration(time.Minute).Exempt("10.0.0.0/8").Skip("/health").Config()
+	assert.Equal(t, time.Minute, config.ExpirationDuration)
+	assert.Equal(t, []string{"10.0.0.0/8"}, config.ExemptNetworks)
+	assert.Equal(t, []string{"/health"}, config.SkipPaths)
+
+	// 缺少或无效的限额会在构建时报错
+	_, err = Builder().PerIP().Build()
+	assert.Error(t, err)
+	_, err = Builder().Limit(10, time.Minute).Exempt("not-a-network").New()
+	assert.Error(t, err)
+}
@@ -0,0 +1,29 @@
+package limiter
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CanaryClass is a PolicySet selector that names the class of a request
+// by its canary or experiment marker: the value of header, or else of
+// cookie, e.g. "X-Canary: tight". Either may be "" to skip it. Requests
+// without a marker, or with one that has no policy, use the default
+// policy. Clients can send any marker, so canary policies should not be
+// much looser than the default unless the marker is set at the edge.
+func CanaryClass(header, cookie string) func(*gin.Context) string {
+	return func(c *gin.Context) string {
+		if header != "" {
+			if value := strings.TrimSpace(c.GetHeader(header)); value != "" {
+				return value
+			}
+		}
+		if cookie != "" {
+			if value, err := c.Cookie(cookie); err == nil {
+				return value
+			}
+		}
+		return ""
+	}
+}
@@ -0,0 +1,79 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanaryClass(t *testing.T) {
+	selector := CanaryClass("X-Canary", "canary")
+
+	tests := []struct {
+		name     string
+		setup    func(*http.Request)
+		expected string
+	}{
+		{"请求头", func(r *http.Request) { r.Header.Set("X-Canary", "tight") }, "tight"},
+		{"Cookie", func(r *http.Request) { r.AddCookie(&http.Cookie{Name: "canary", Value: "loose"}) }, "loose"},
+		{"请求头优先", func(r *http.Request) {
+			r.Header.Set("X-Canary", "tight")
+			r.AddCookie(&http.Cookie{Name: "canary", Value: "loose"})
+		}, "tight"},
+		{"没有标记", func(*http.Request) {}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, selector(newKeyContext(tt.setup)))
+		})
+	}
+}
+
+func TestPolicySetCanary(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	config := RateLimitConfig{
+		MaxTokens:          2,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+	}
+	tight := config
+	tight.MaxTokens = 1
+	policies, err := NewPolicySet(CanaryClass("X-Canary", ""), map[string]RateLimitConfig{
+		"":      config,
+		"tight": tight,
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(policies.RateLimitMiddleware())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func(canary, ip string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		req.Header.Set("X-Canary", canary)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 金丝雀流量使用试验中的限额
+	assert.Equal(t, http.StatusOK, request("tight", "192.168.1.1"))
+	assert.Equal(t, http.StatusTooManyRequests, request("tight", "192.168.1.1"))
+
+	// 其他流量和未知标记使用默认限额
+	assert.Equal(t, http.StatusOK, request("", "192.168.1.2"))
+	assert.Equal(t, http.StatusOK, request("unknown", "192.168.1.2"))
+	assert.Equal(t, http.StatusTooManyRequests, request("", "192.168.1.2"))
+}
@@ -0,0 +1,24 @@
+package limiter
+
+import "github.com/gin-gonic/gin"
+
+// ChallengeProvider lets clients over the limit prove they are human, e.g.
+// with a CAPTCHA or Turnstile widget. Challenge answers a denied request,
+// typically with a page that embeds the widget; Verify checks the solved
+// challenge token a client sends back. Tokens should be single use, since
+// every valid one gives its key a full bucket again.
+type ChallengeProvider interface {
+	Challenge(c *gin.Context, info LimitInfo)
+	Verify(c *gin.Context) (bool, error)
+}
+
+// solved reports whether a denied request carries a valid challenge token.
+// A provider error is recorded on c and treated as an unsolved challenge.
+func (rl *RateLimiter) solved(c *gin.Context) bool {
+	ok, err := rl.config.Challenge.Verify(c)
+	if err != nil {
+		_ = c.Error(err)
+		return false
+	}
+	return ok
+}
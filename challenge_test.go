@@ -0,0 +1,75 @@
+package limiter
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type testChallenge struct {
+	challenged []LimitInfo
+	err        error
+}
+
+func (p *testChallenge) Challenge(c *gin.Context, info LimitInfo) {
+	p.challenged = append(p.challenged, info)
+	c.String(http.StatusForbidden, "solve the challenge")
+}
+
+func (p *testChallenge) Verify(c *gin.Context) (bool, error) {
+	return c.GetHeader("X-Challenge-Token") == "solved", p.err
+}
+
+func TestChallenge(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	provider := &testChallenge{}
+	limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Challenge:          provider,
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	// 超限后由挑战提供者响应
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "solve the challenge", w.Body.String())
+	assert.Len(t, provider.challenged, 1)
+	assert.Equal(t, "192.168.1.1", provider.challenged[0].Key)
+
+	// 携带有效令牌的请求解除限流
+	req.Header.Set("X-Challenge-Token", "solved")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+
+	// 验证出错时视为未通过挑战
+	provider.err = errors.New("provider unavailable")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Len(t, provider.challenged, 2)
+}
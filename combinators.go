@@ -0,0 +1,106 @@
+package limiter
+
+import "github.com/gin-gonic/gin"
+
+// When applies limiter, a middleware such as the one NewRateLimiter
+// returns, only to requests for which predicate holds; the others pass
+// through untouched.
+func When(predicate func(c *gin.Context) bool, limiter gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !predicate(c) {
+			c.Next()
+			return
+		}
+		limiter(c)
+	}
+}
+
+// Chain runs the middleware of each limiter in turn, as if each had been
+// added with Use. Unlike All, every limiter charges the request on its own
+// and keeps its tokens when a later one denies:
+//
+//	router.Use(limiter.Chain(perIP, perUser)...)
+func Chain(limiters ...*RateLimiter) gin.HandlersChain {
+	chain := make(gin.HandlersChain, 0, len(limiters))
+	for _, rl := range limiters {
+		chain = append(chain, rl.RateLimitMiddleware())
+	}
+	return chain
+}
+
+// All admits a request only if every limiter admits it, e.g. a per-user
+// and a per-IP limiter together. Each limiter evaluates the request the
+// way its middleware would, with its own Cost, Scopes, bans and
+// concurrency limits, in order. When one denies, the tokens taken by the
+// earlier ones are given back, so a denied request consumes no capacity.
+// Like Reservation.Cancel this cannot return tokens taken by an
+// Algorithm. The limiter that denied answers the request.
+func All(limiters ...*RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		checks := make([]*check, 0, len(limiters))
+		defer func() {
+			for _, ch := range checks {
+				ch.release()
+			}
+		}()
+		rollback := func() {
+			for _, ch := range checks {
+				ch.cancel(c)
+			}
+		}
+
+		for _, rl := range limiters {
+			ch := rl.check(c)
+			if ch.denied() {
+				rollback()
+				ch.release()
+				ch.answer(c)
+				return
+			}
+			checks = append(checks, ch)
+		}
+
+		for _, ch := range checks {
+			if !ch.answer(c) {
+				rollback()
+				return
+			}
+		}
+		c.Next()
+		for _, ch := range checks {
+			ch.finish(c)
+		}
+	}
+}
+
+// Any admits a request if at least one limiter admits it, e.g. a generous
+// per-account limit or, failing that, a small anonymous allowance. The
+// limiters evaluate the request in order the way their middleware would,
+// and only the first one that admits is charged. If all deny, the one
+// that admits again soonest answers the request.
+func Any(limiters ...*RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var denied *check
+		for _, rl := range limiters {
+			ch := rl.check(c)
+			if !ch.denied() {
+				defer ch.release()
+				if ch.answer(c) {
+					c.Next()
+					ch.finish(c)
+				}
+				return
+			}
+			ch.release()
+			if denied == nil || ch.r.retryAfter < denied.r.retryAfter {
+				denied = ch
+			}
+		}
+
+		if denied == nil {
+			c.Next()
+			return
+		}
+		denied.answer(c)
+	}
+}
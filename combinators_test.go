@@ -0,0 +1,246 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWhen(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+	})
+	assert.NoError(t, err)
+
+	anonymous := func(c *gin.Context) bool {
+		return c.GetHeader("Authorization") == ""
+	}
+
+	router := gin.New()
+	router.Use(When(anonymous, limiterMiddleware))
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func(authorization string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		req.Header.Set("Authorization", authorization)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 只有匿名请求受到限流
+	assert.Equal(t, http.StatusOK, request(""))
+	assert.Equal(t, http.StatusTooManyRequests, request(""))
+	assert.Equal(t, http.StatusOK, request("Bearer token"))
+	assert.Equal(t, http.StatusOK, request("Bearer token"))
+}
+
+func TestAll(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	perIP, err := New(RateLimitConfig{
+		MaxTokens:          3,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+	})
+	assert.NoError(t, err)
+	perUser, err := New(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            ByHeader("X-User"),
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		LimitExceededFunc: func(c *gin.Context, info LimitInfo) {
+			c.String(http.StatusTooManyRequests, "slow down, "+info.Key)
+		},
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(All(perIP, perUser))
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func(user string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		req.Header.Set("X-User", user)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 所有限流器都放行才通过
+	assert.Equal(t, http.StatusOK, request("alice").Code)
+
+	// 后面的限流器拒绝时归还前面扣除的令牌
+	w := request("alice")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	// 拒绝的限流器按自己的中间件配置响应
+	assert.Equal(t, "slow down, alice", w.Body.String())
+	assert.Equal(t, http.StatusOK, request("bob").Code)
+	assert.Equal(t, http.StatusOK, request("carol").Code)
+
+	// 第一个限流器耗尽后拒绝
+	assert.Equal(t, http.StatusTooManyRequests, request("dave").Code)
+	assert.Equal(t, uint64(3), perIP.Stats().Allowed)
+}
+
+func TestAllCost(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	perIP, err := New(RateLimitConfig{
+		MaxTokens:          3,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Cost:               func(c *gin.Context) int { return 3 },
+	})
+	assert.NoError(t, err)
+	perUser, err := New(RateLimitConfig{
+		MaxTokens:          10,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            ByHeader("X-User"),
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Cost:               func(c *gin.Context) int { return 4 },
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(All(perUser, perIP))
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	header := http.Header{"X-User": {"alice"}}
+
+	// 每个限流器按自己的开销扣除令牌
+	assert.Equal(t, http.StatusOK, serve(router, "GET", "/", header).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(router, "GET", "/", header).Code)
+
+	// 被拒绝时归还的令牌数与扣除的相同，不多也不少
+	tokens := func(rl *RateLimiter, key string) int {
+		bucket := rl.getBucket(key)
+		bucket.mutex.Lock()
+		defer bucket.mutex.Unlock()
+		return bucket.tokens
+	}
+	assert.Equal(t, 6, tokens(perUser, "alice"))
+	assert.Equal(t, 0, tokens(perIP, "192.168.1.1"))
+}
+
+func TestAny(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	perUser, err := New(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            ByHeader("X-User"),
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+	})
+	assert.NoError(t, err)
+	perIP, err := New(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Second,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(Any(perUser, perIP))
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		req.Header.Set("X-User", "alice")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 任一限流器放行即可通过，且只扣除放行的限流器
+	assert.Equal(t, http.StatusOK, request().Code)
+	assert.Equal(t, uint64(0), perIP.Stats().Allowed)
+	assert.Equal(t, http.StatusOK, request().Code)
+	assert.Equal(t, uint64(1), perIP.Stats().Allowed)
+
+	// 全部拒绝时由最早恢复的限流器响应
+	w := request()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+}
+
+func TestChain(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	perIP, err := New(RateLimitConfig{
+		MaxTokens:          2,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+	})
+	assert.NoError(t, err)
+	perUser, err := New(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            ByHeader("X-User"),
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(Chain(perIP, perUser)...)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func(user string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		req.Header.Set("X-User", user)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 每个限流器各自扣除令牌，后面的拒绝不会归还前面的令牌
+	assert.Equal(t, http.StatusOK, request("alice"))
+	assert.Equal(t, http.StatusTooManyRequests, request("alice"))
+	assert.Equal(t, http.StatusTooManyRequests, request("bob"))
+}
@@ -0,0 +1,124 @@
+package limiter
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// ConcurrencyRule is the Rule reported in LimitInfo when DryRun admits a request
+// that ConcurrencyControl would have rejected.
+const ConcurrencyRule = "concurrency"
+
+// ConcurrencyControlConfig enables a latency-aware in-flight limit per route,
+// in the spirit of Netflix's concurrency-limits gradient algorithm. The limit
+// grows while request latency stays close to the lowest latency seen and
+// shrinks as soon as latency rises, i.e. when requests start to queue.
+// Requests beyond the current limit are rejected with 503.
+type ConcurrencyControlConfig struct {
+	InitialLimit int
+	MinLimit     int
+	MaxLimit     int
+	// Smoothing is the weight of each new estimate, between 0 and 1.
+	Smoothing float64
+	// ProbeInterval, if set, periodically forgets the lowest latency so the
+	// baseline can follow permanent changes in the service.
+	ProbeInterval time.Duration
+}
+
+func (cc *ConcurrencyControlConfig) Validate() error {
+	if cc.MinLimit <= 0 {
+		return errors.New("ConcurrencyControl.MinLimit must be greater than 0")
+	}
+	if cc.MaxLimit < cc.MinLimit {
+		return errors.New("ConcurrencyControl.MaxLimit must not be less than MinLimit")
+	}
+	if cc.InitialLimit < cc.MinLimit || cc.InitialLimit > cc.MaxLimit {
+		return errors.New("ConcurrencyControl.InitialLimit must be between MinLimit and MaxLimit")
+	}
+	if cc.Smoothing <= 0 || cc.Smoothing > 1 {
+		return errors.New("ConcurrencyControl.Smoothing must be between 0 and 1")
+	}
+	if cc.ProbeInterval < 0 {
+		return errors.New("ConcurrencyControl.ProbeInterval must not be negative")
+	}
+	return nil
+}
+
+type gradientLimiter struct {
+	config   ConcurrencyControlConfig
+	limit    float64
+	inflight int
+	minRTT   time.Duration
+	probedAt time.Time
+	mutex    sync.Mutex
+}
+
+func (g *gradientLimiter) acquire() bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.inflight >= int(g.limit) {
+		return false
+	}
+	g.inflight++
+	return true
+}
+
+func (g *gradientLimiter) release() {
+	g.mutex.Lock()
+	g.inflight--
+	g.mutex.Unlock()
+}
+
+func (g *gradientLimiter) sample(rtt time.Duration, now time.Time) {
+	if rtt <= 0 {
+		return
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.config.ProbeInterval > 0 && now.Sub(g.probedAt) >= g.config.ProbeInterval {
+		g.minRTT = 0
+		g.probedAt = now
+	}
+	if g.minRTT == 0 || rtt < g.minRTT {
+		g.minRTT = rtt
+	}
+
+	gradient := math.Max(0.5, math.Min(1, float64(g.minRTT)/float64(rtt)))
+	estimate := g.limit*gradient + math.Sqrt(g.limit)
+	g.limit = g.limit*(1-g.config.Smoothing) + estimate*g.config.Smoothing
+	g.limit = math.Max(float64(g.config.MinLimit), math.Min(float64(g.config.MaxLimit), g.limit))
+}
+
+type routeConcurrency struct {
+	config ConcurrencyControlConfig
+	routes map[string]*gradientLimiter
+	mutex  sync.Mutex
+}
+
+func newRouteConcurrency(config ConcurrencyControlConfig) *routeConcurrency {
+	return &routeConcurrency{
+		config: config,
+		routes: make(map[string]*gradientLimiter),
+	}
+}
+
+func (r *routeConcurrency) get(route string) *gradientLimiter {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	limiter, exists := r.routes[route]
+	if !exists {
+		limiter = &gradientLimiter{
+			config:   r.config,
+			limit:    float64(r.config.InitialLimit),
+			probedAt: time.Now(),
+		}
+		r.routes[route] = limiter
+	}
+	return limiter
+}
@@ -0,0 +1,40 @@
+package limiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGradientLimiter_AdaptsToLatency(t *testing.T) {
+	routes := newRouteConcurrency(ConcurrencyControlConfig{
+		InitialLimit: 4,
+		MinLimit:     1,
+		MaxLimit:     100,
+		Smoothing:    1,
+	})
+	limiter := routes.get("/search")
+	now := time.Now()
+
+	// 达到并发上限后拒绝新的请求
+	for i := 0; i < 4; i++ {
+		assert.True(t, limiter.acquire())
+	}
+	assert.False(t, limiter.acquire())
+	limiter.release()
+	assert.True(t, limiter.acquire())
+
+	// 延迟稳定时上限增长
+	limiter.sample(time.Millisecond*10, now)
+	limiter.sample(time.Millisecond*10, now)
+	assert.Greater(t, limiter.limit, 4.0)
+
+	// 延迟翻倍说明请求开始排队，上限下降
+	before := limiter.limit
+	limiter.sample(time.Millisecond*40, now)
+	assert.Less(t, limiter.limit, before)
+
+	// 不同路由使用独立的限制器
+	assert.NotSame(t, limiter, routes.get("/users/:id"))
+}
@@ -0,0 +1,253 @@
+package limiter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FileConfig holds the configs read by LoadConfig. Limiters are meant for
+// a Registry and Routes for a RouteLimiter.
+type FileConfig struct {
+	Limiters map[string]RateLimitConfig
+	Routes   map[string]RateLimitConfig
+}
+
+type fileLimit struct {
+	MaxTokens      int    `json:"max_tokens"`
+	RefillRate     int    `json:"refill_rate"`
+	RefillInterval string `json:"refill_interval"`
+}
+
+func (l fileLimit) limit() (Limit, error) {
+	interval, err := time.ParseDuration(l.RefillInterval)
+	if err != nil {
+		return Limit{}, errors.New("RefillInterval: " + err.Error())
+	}
+	return Limit{MaxTokens: l.MaxTokens, RefillRate: l.RefillRate, RefillInterval: interval}, nil
+}
+
+func fileOverrides(file map[string]fileLimit) (map[string]Limit, error) {
+	if len(file) == 0 {
+		return nil, nil
+	}
+	overrides := make(map[string]Limit, len(file))
+	for key, override := range file {
+		limit, err := override.limit()
+		if err != nil {
+			return nil, errors.New("Overrides[" + key + "]." + err.Error())
+		}
+		overrides[key] = limit
+	}
+	return overrides, nil
+}
+
+type fileRule struct {
+	Name       string `json:"name"`
+	StatusCode int    `json:"status_code"`
+	fileLimit
+}
+
+type fileLimiter struct {
+	fileLimit
+	Key             string               `json:"key"`
+	BurstMultiplier int                  `json:"burst_multiplier"`
+	Expiration      string               `json:"expiration"`
+	Algorithm       string               `json:"algorithm"`
+	Namespace       string               `json:"namespace"`
+	StatusCode      int                  `json:"status_code"`
+	ExemptNetworks  []string             `json:"exempt_networks"`
+	SkipPaths       []string             `json:"skip_paths"`
+	ExemptMethods   []string             `json:"exempt_methods"`
+	Overrides       map[string]fileLimit `json:"overrides"`
+	GlobalLimit     *fileLimit           `json:"global_limit"`
+	Rules           []fileRule           `json:"rules"`
+}
+
+type configFile struct {
+	Limiters map[string]fileLimiter `json:"limiters"`
+	Routes   map[string]fileLimiter `json:"routes"`
+}
+
+// ConfigFormat converts a config file in some format into the JSON that
+// ParseConfig reads.
+type ConfigFormat func(data []byte) ([]byte, error)
+
+var (
+	configFormatsMutex sync.RWMutex
+	configFormats      = make(map[string]ConfigFormat)
+)
+
+// RegisterConfigFormat makes LoadConfig read files whose extension is ext,
+// such as ".yaml", with format. Importing the yamlconfig package registers
+// YAML. It panics if ext is already registered or format is nil.
+func RegisterConfigFormat(ext string, format ConfigFormat) {
+	configFormatsMutex.Lock()
+	defer configFormatsMutex.Unlock()
+
+	if format == nil {
+		panic("limiter: RegisterConfigFormat format is nil")
+	}
+	if _, dup := configFormats[ext]; dup || ext == "" || ext == ".json" {
+		panic("limiter: RegisterConfigFormat called twice for " + ext)
+	}
+	configFormats[ext] = format
+}
+
+func lookupConfigFormat(ext string) (ConfigFormat, bool) {
+	configFormatsMutex.RLock()
+	defer configFormatsMutex.RUnlock()
+
+	format, ok := configFormats[ext]
+	return format, ok
+}
+
+// LoadConfig reads named limiters and per-route limits from a file and
+// validates them, like ParseConfig. Files ending in .json, or without an
+// extension, are read as JSON and others by the format registered for
+// their extension, e.g. .yaml and .yml once yamlconfig is imported.
+func LoadConfig(path string) (*FileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if ext := strings.ToLower(filepath.Ext(path)); ext != "" && ext != ".json" {
+		format, ok := lookupConfigFormat(ext)
+		if !ok {
+			return nil, errors.New("limiter: no config format registered for " + ext + " files")
+		}
+		if data, err = format(data); err != nil {
+			return nil, err
+		}
+	}
+	return ParseConfig(data)
+}
+
+// ParseConfig decodes named limiters and per-route limits from JSON and
+// validates them. Durations are strings such as "1m" and key names an
+// extractor: "ip", "route", "bearer", or "header:", "cookie:", "param:"
+// or "query:" followed by a name. Unknown fields are rejected.
+//
+//	{
+//	  "limiters": {
+//	    "search": {
+//	      "key": "header:X-API-Key",
+//	      "max_tokens": 100, "refill_rate": 100, "refill_interval": "1m",
+//	      "burst_multiplier": 1, "expiration": "10m",
+//	      "overrides": {"partner": {"max_tokens": 1000, "refill_rate": 1000, "refill_interval": "1m"}}
+//	    }
+//	  },
+//	  "routes": {
+//	    "POST /login": {"max_tokens": 5, "refill_rate": 5, "refill_interval": "1m", "burst_multiplier": 1, "expiration": "10m"}
+//	  }
+//	}
+func ParseConfig(data []byte) (*FileConfig, error) {
+	var file configFile
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, err
+	}
+
+	var err error
+	config := &FileConfig{
+		Limiters: make(map[string]RateLimitConfig, len(file.Limiters)),
+		Routes:   make(map[string]RateLimitConfig, len(file.Routes)),
+	}
+	for name, limiter := range file.Limiters {
+		if config.Limiters[name], err = limiter.config(); err != nil {
+			return nil, errors.New("Limiters[" + name + "]." + err.Error())
+		}
+	}
+	for route, limiter := range file.Routes {
+		if config.Routes[route], err = limiter.config(); err != nil {
+			return nil, errors.New("Routes[" + route + "]." + err.Error())
+		}
+	}
+	return config, nil
+}
+
+func (f fileLimiter) config() (RateLimitConfig, error) {
+	config := RateLimitConfig{
+		MaxTokens:       f.MaxTokens,
+		RefillRate:      f.RefillRate,
+		BurstMultiplier: f.BurstMultiplier,
+		Algorithm:       f.Algorithm,
+		Namespace:       f.Namespace,
+		StatusCode:      f.StatusCode,
+		ExemptNetworks:  f.ExemptNetworks,
+		SkipPaths:       f.SkipPaths,
+		ExemptMethods:   f.ExemptMethods,
+	}
+	var err error
+	if config.RefillInterval, err = parseDuration(f.RefillInterval); err != nil {
+		return config, errors.New("RefillInterval: " + err.Error())
+	}
+	if config.ExpirationDuration, err = parseDuration(f.Expiration); err != nil {
+		return config, errors.New("ExpirationDuration: " + err.Error())
+	}
+	if config.KeyFunc, err = keyFuncByName(f.Key); err != nil {
+		return config, err
+	}
+	if config.Overrides, err = fileOverrides(f.Overrides); err != nil {
+		return config, err
+	}
+	if f.GlobalLimit != nil {
+		limit, err := f.GlobalLimit.limit()
+		if err != nil {
+			return config, errors.New("GlobalLimit." + err.Error())
+		}
+		config.GlobalLimit = &limit
+	}
+	for i, rule := range f.Rules {
+		limit, err := rule.limit()
+		if err != nil {
+			return config, errors.New("Rules[" + strconv.Itoa(i) + "]." + err.Error())
+		}
+		config.Rules = append(config.Rules, Rule{Name: rule.Name, StatusCode: rule.StatusCode, Limit: limit})
+	}
+	return config, config.Validate()
+}
+
+// keyFuncByName returns the extractor a config file names; an empty name
+// keeps the default of the client IP.
+func keyFuncByName(name string) (func(*gin.Context) string, error) {
+	kind, arg, _ := strings.Cut(name, ":")
+	switch {
+	case name == "":
+		return nil, nil
+	case name == "ip":
+		return ByClientIP(), nil
+	case name == "route":
+		return ByRoute(), nil
+	case name == "bearer":
+		return ByAuthHeaderBearer(), nil
+	case arg == "":
+	case kind == "header":
+		return ByHeader(arg), nil
+	case kind == "cookie":
+		return ByCookie(arg), nil
+	case kind == "param":
+		return ByParam(arg), nil
+	case kind == "query":
+		return ByQuery(arg, false), nil
+	}
+	return nil, errors.New("KeyFunc: unknown key " + strconv.Quote(name))
+}
+
+// parseDuration leaves a missing duration zero, so that Validate fills in
+// its default.
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,98 @@
+package limiter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	// JSON 文件描述命名限流器和按路由的限额
+	path := filepath.Join(dir, "limits.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{
+  "limiters": {
+    "search": {
+      "key": "header:X-API-Key",
+      "max_tokens": 100, "refill_rate": 100, "refill_interval": "1m",
+      "burst_multiplier": 1, "expiration": "10m",
+      "exempt_networks": ["10.0.0.0/8"],
+      "overrides": {"partner": {"max_tokens": 1000, "refill_rate": 1000, "refill_interval": "1m"}},
+      "rules": [{"name": "hourly", "max_tokens": 1000, "refill_rate": 1000, "refill_interval": "1h"}]
+    }
+  },
+  "routes": {
+    "POST /login": {"max_tokens": 5, "refill_rate": 5, "refill_interval": "1m", "burst_multiplier": 1, "expiration": "10m"}
+  }
+}`), 0o600))
+
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	search := config.Limiters["search"]
+	assert.Equal(t, 100, search.MaxTokens)
+	assert.Equal(t, time.Minute, search.RefillInterval)
+	assert.Equal(t, 10*time.Minute, search.ExpirationDuration)
+	assert.Equal(t, []string{"10.0.0.0/8"}, search.ExemptNetworks)
+	assert.Equal(t, Limit{MaxTokens: 1000, RefillRate: 1000, RefillInterval: time.Minute}, search.Overrides["partner"])
+	assert.Equal(t, []Rule{{Name: "hourly", Limit: Limit{MaxTokens: 1000, RefillRate: 1000, RefillInterval: time.Hour}}}, search.Rules)
+	assert.NotNil(t, search.KeyFunc)
+	assert.Equal(t, 5, config.Routes["POST /login"].MaxTokens)
+
+	// 读取的配置可以直接注册
+	_, err = NewRegistry().Register("search", search)
+	assert.NoError(t, err)
+
+	// 全局限额
+	assert.NoError(t, os.WriteFile(path, []byte(`{"limiters": {"api": {"max_tokens": 10, "refill_rate": 1, "refill_interval": "1s", "burst_multiplier": 2, "expiration": "1m", "global_limit": {"max_tokens": 100, "refill_rate": 100, "refill_interval": "1s"}}}}`), 0o600))
+	config, err = LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, config.Limiters["api"].BurstMultiplier)
+	assert.Equal(t, &Limit{MaxTokens: 100, RefillRate: 100, RefillInterval: time.Second}, config.Limiters["api"].GlobalLimit)
+
+	// 省略的时长使用默认值
+	assert.NoError(t, os.WriteFile(path, []byte(`{"limiters": {"api": {"max_tokens": 10, "refill_rate": 1, "refill_interval": "1s"}}}`), 0o600))
+	config, err = LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, config.Limiters["api"].ExpirationDuration)
+
+	// 无效的配置、未知的字段和未知的键都会报错
+	for _, content := range []string{
+		`{"limiters": {"api": {"max_tokens": 0, "refill_rate": 1, "refill_interval": "1s", "burst_multiplier": 1, "expiration": "1m"}}}`,
+		`{"limiters": {"api": {"max_tokens": 1, "refill_rate": 1, "refill_interval": "soon", "burst_multiplier": 1, "expiration": "1m"}}}`,
+		`{"limiters": {"api": {"max_token": 1}}}`,
+		`{"routes": {"/": {"key": "header:", "max_tokens": 1, "refill_rate": 1, "refill_interval": "1s", "burst_multiplier": 1, "expiration": "1m"}}}`,
+	} {
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		_, err = LoadConfig(path)
+		assert.Error(t, err, content)
+	}
+
+	_, err = LoadConfig(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestLoadConfigFormat(t *testing.T) {
+	dir := t.TempDir()
+
+	// 没有注册格式的扩展名会报错
+	path := filepath.Join(dir, "limits.ini")
+	assert.NoError(t, os.WriteFile(path, []byte("max_tokens = 10"), 0o600))
+	_, err := LoadConfig(path)
+	assert.Error(t, err)
+
+	// 按扩展名选择注册的格式，转换成 JSON 后解析
+	RegisterConfigFormat(".ini", func(data []byte) ([]byte, error) {
+		return []byte(`{"limiters": {"api": {"max_tokens": 10, "refill_rate": 1, "refill_interval": "1s"}}}`), nil
+	})
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, config.Limiters["api"].MaxTokens)
+
+	// 扩展名不能重复注册
+	assert.Panics(t, func() { RegisterConfigFormat(".ini", func(data []byte) ([]byte, error) { return data, nil }) })
+	assert.Panics(t, func() { RegisterConfigFormat(".json", func(data []byte) ([]byte, error) { return data, nil }) })
+}
@@ -0,0 +1,80 @@
+// Package consulconfig relays limits published in Consul's KV store to
+// running limiters.
+package consulconfig
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// retryInterval is how long ConfigUpdates first waits before retrying a
+// failed query. Each further failure doubles the wait up to 32 times this.
+const retryInterval = time.Second
+
+// kvGetter is the part of *api.KV that ConfigUpdates uses.
+type kvGetter interface {
+	Get(key string, q *api.QueryOptions) (*api.KVPair, *api.QueryMeta, error)
+}
+
+// ConfigUpdates relays the current value of key in Consul's KV store, and
+// every later change to it, as updates for limiter.RateLimiter.WatchConfig
+// until ctx is done. It uses blocking queries, so changes arrive as soon
+// as Consul sees them:
+//
+//	go rl.WatchConfig(ctx, consulconfig.ConfigUpdates(ctx, client, "limits/api", nil), nil)
+//
+// Failed queries are passed to onError unless it is nil and retried after
+// a backoff of one to 32 seconds.
+func ConfigUpdates(ctx context.Context, client *api.Client, key string, onError func(error)) <-chan []byte {
+	return watch(ctx, client.KV(), key, onError, retryInterval)
+}
+
+func watch(ctx context.Context, kv kvGetter, key string, onError func(error), retry time.Duration) <-chan []byte {
+	updates := make(chan []byte)
+	go func() {
+		defer close(updates)
+		var index uint64
+		delay := retry
+		for {
+			pair, meta, err := kv.Get(key, (&api.QueryOptions{WaitIndex: index}).WithContext(ctx))
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				if onError != nil {
+					onError(err)
+				}
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+					return
+				}
+				if delay *= 2; delay > retry*32 {
+					delay = retry * 32
+				}
+				continue
+			}
+			delay = retry
+			// Consul may reset the index, e.g. after a restore; start over.
+			if meta.LastIndex < index {
+				index = 0
+				continue
+			}
+			if meta.LastIndex == index {
+				continue
+			}
+			index = meta.LastIndex
+			if pair == nil {
+				continue
+			}
+			select {
+			case updates <- pair.Value:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return updates
+}
@@ -0,0 +1,67 @@
+package consulconfig
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/assert"
+)
+
+type consulResult struct {
+	value string
+	index uint64
+	err   error
+}
+
+// fakeConsulKV 依次返回预设的查询结果，用完后阻塞到测试结束
+type fakeConsulKV struct {
+	results []consulResult
+	indexes []uint64
+	done    chan struct{}
+}
+
+func (f *fakeConsulKV) Get(key string, q *api.QueryOptions) (*api.KVPair, *api.QueryMeta, error) {
+	f.indexes = append(f.indexes, q.WaitIndex)
+	if len(f.results) == 0 {
+		<-f.done
+		return nil, nil, errors.New("closed")
+	}
+	result := f.results[0]
+	f.results = f.results[1:]
+	if result.err != nil {
+		return nil, nil, result.err
+	}
+	return &api.KVPair{Value: []byte(result.value)}, &api.QueryMeta{LastIndex: result.index}, nil
+}
+
+func TestConfigUpdates(t *testing.T) {
+	kv := &fakeConsulKV{
+		results: []consulResult{
+			{err: errors.New("consul unavailable")},
+			{value: "v1", index: 10},
+			{value: "v1", index: 10},
+			{value: "v2", index: 3},
+			{value: "v2", index: 4},
+		},
+		done: make(chan struct{}),
+	}
+	var errs []error
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := watch(ctx, kv, "limits/api", func(err error) { errs = append(errs, err) }, time.Millisecond)
+
+	// 查询失败后重试并报告错误
+	assert.Equal(t, "v1", string(<-updates))
+	assert.Equal(t, 1, len(errs))
+
+	// 索引未变化时不重复传递；索引回退后从 0 重新开始
+	assert.Equal(t, "v2", string(<-updates))
+
+	cancel()
+	close(kv.done)
+	_, ok := <-updates
+	assert.False(t, ok)
+	assert.Equal(t, []uint64{0, 0, 10, 10, 0, 4}, kv.indexes)
+}
@@ -0,0 +1,228 @@
+package limiter
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CronRule switches the main limit to Limit whenever Spec fires, until
+// another rule fires. Spec is a standard five-field cron expression,
+// "minute hour day-of-month month day-of-week", evaluated in Location, or
+// UTC if it is nil; fields take *, numbers, ranges, lists and steps such
+// as "*/15" or "1-5". A zero Limit switches back to the main config.
+type CronRule struct {
+	Spec     string
+	Location *time.Location
+	Limit    RateLimitParams
+}
+
+func (r *CronRule) Validate() error {
+	if _, err := parseCron(r.Spec); err != nil {
+		return errors.New("Spec " + err.Error())
+	}
+	if r.Limit == (RateLimitParams{}) {
+		return nil
+	}
+	if err := r.Limit.Validate(); err != nil {
+		return errors.New("Limit." + err.Error())
+	}
+	return nil
+}
+
+func validateCron(r *RateLimitConfig) error {
+	if len(r.Cron) > 0 && r.Algorithm != "" && r.Algorithm != TokenBucket {
+		return errors.New("Cron is only supported by the token bucket algorithm")
+	}
+	for i := range r.Cron {
+		if err := r.Cron[i].Validate(); err != nil {
+			return errors.New("Cron[" + strconv.Itoa(i) + "]." + err.Error())
+		}
+	}
+	return nil
+}
+
+// cronSpec holds the allowed values of each field as bit sets.
+type cronSpec struct {
+	minute, hour, dom, month, dow uint64
+	// Cron matches a day if either day field matches, unless one is "*".
+	domAny, dowAny bool
+}
+
+func parseCron(spec string) (*cronSpec, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, errors.New("must have 5 fields")
+	}
+	s := &cronSpec{domAny: fields[2] == "*", dowAny: fields[4] == "*"}
+	bounds := []struct {
+		field    *uint64
+		min, max int
+	}{{&s.minute, 0, 59}, {&s.hour, 0, 23}, {&s.dom, 1, 31}, {&s.month, 1, 12}, {&s.dow, 0, 7}}
+	for i, b := range bounds {
+		bits, err := parseCronField(fields[i], b.min, b.max)
+		if err != nil {
+			return nil, err
+		}
+		*b.field = bits
+	}
+	// Both 0 and 7 mean Sunday.
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		expr, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepText); err != nil || step <= 0 {
+				return 0, errors.New("has an invalid step in " + strconv.Quote(part))
+			}
+		}
+		lo, hi := min, max
+		if expr != "*" {
+			loText, hiText, isRange := strings.Cut(expr, "-")
+			var err error
+			if lo, err = strconv.Atoi(loText); err != nil {
+				return 0, errors.New("has an invalid value in " + strconv.Quote(part))
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(hiText); err != nil {
+					return 0, errors.New("has an invalid value in " + strconv.Quote(part))
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, errors.New("has a value out of range in " + strconv.Quote(part))
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func (s *cronSpec) day(t time.Time) bool {
+	dom := s.dom&(1<<t.Day()) != 0
+	dow := s.dow&(1<<t.Weekday()) != 0
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// maxCronSearch bounds the search for a firing time, so a spec such as
+// "0 0 31 2 *" that never fires does not search forever.
+const maxCronSearch = 5 * 366 * 24
+
+// next returns the first time after t at which s fires, or the zero time.
+func (s *cronSpec) next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	for i := 0; i < maxCronSearch; i++ {
+		switch {
+		case s.month&(1<<t.Month()) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.day(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// prev returns the last time at or before t at which s fired, or the zero
+// time.
+func (s *cronSpec) prev(t time.Time) time.Time {
+	t = t.Truncate(time.Minute)
+	for i := 0; i < maxCronSearch; i++ {
+		switch {
+		case s.month&(1<<t.Month()) == 0:
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()).Add(-time.Minute)
+		case !s.day(t):
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()).Add(-time.Minute)
+		case s.hour&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location()).Add(-time.Minute)
+		case s.minute&(1<<t.Minute()) == 0:
+			t = t.Add(-time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+type cronRule struct {
+	spec     *cronSpec
+	location *time.Location
+	limit    Limit
+}
+
+// cronScheduler tracks which CronRule fired last. It is advanced lazily by
+// the requests that need the limit, so it has no goroutine to stop.
+type cronScheduler struct {
+	rules  []cronRule
+	active int
+	next   time.Time
+	mutex  sync.Mutex
+}
+
+func newCronScheduler(rules []CronRule, now time.Time) *cronScheduler {
+	s := &cronScheduler{}
+	for _, rule := range rules {
+		spec, _ := parseCron(rule.Spec)
+		location := rule.Location
+		if location == nil {
+			location = time.UTC
+		}
+		s.rules = append(s.rules, cronRule{spec: spec, location: location, limit: rule.Limit})
+	}
+	s.advance(now)
+	return s
+}
+
+// advance picks the rule that fired last at or before now, so that a
+// limiter created in the middle of a window starts out in it, and the
+// time the next rule fires.
+func (s *cronScheduler) advance(now time.Time) {
+	s.active = -1
+	s.next = time.Time{}
+	var last time.Time
+	for i, rule := range s.rules {
+		local := now.In(rule.location)
+		if fired := rule.spec.prev(local); !fired.IsZero() && !fired.Before(last) {
+			s.active, last = i, fired
+		}
+		if next := rule.spec.next(local); !next.IsZero() && (s.next.IsZero() || next.Before(s.next)) {
+			s.next = next
+		}
+	}
+}
+
+// limit returns the limit of the active rule.
+func (s *cronScheduler) limit(now time.Time) (Limit, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.next.IsZero() && !now.Before(s.next) {
+		s.advance(now)
+	}
+	if s.active < 0 || s.rules[s.active].limit == (Limit{}) {
+		return Limit{}, false
+	}
+	return s.rules[s.active].limit, true
+}
@@ -0,0 +1,112 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		spec  string
+		valid bool
+	}{
+		{"* * * * *", true},
+		{"*/15 1-5 * * 1-5", true},
+		{"0 22 * * 0,6,7", true},
+		{"30 2 1 */3 *", true},
+		{"* * * *", false},
+		{"60 * * * *", false},
+		{"5-1 * * * *", false},
+		{"*/0 * * * *", false},
+		{"a * * * *", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := parseCron(tt.spec)
+			assert.Equal(t, tt.valid, err == nil)
+		})
+	}
+}
+
+func TestCronSpecNextPrev(t *testing.T) {
+	// 工作日凌晨 1 点
+	spec, err := parseCron("0 1 * * 1-5")
+	assert.NoError(t, err)
+
+	friday := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 10, 19, 1, 0, 0, 0, time.UTC), spec.next(friday))
+	assert.Equal(t, time.Date(2026, 10, 16, 1, 0, 0, 0, time.UTC), spec.prev(friday))
+	// 触发时刻本身算作上一次触发
+	assert.Equal(t, time.Date(2026, 10, 16, 1, 0, 0, 0, time.UTC), spec.prev(time.Date(2026, 10, 16, 1, 0, 0, 0, time.UTC)))
+
+	// 永远不会触发的表达式
+	never, err := parseCron("0 0 31 2 *")
+	assert.NoError(t, err)
+	assert.True(t, never.next(friday).IsZero())
+}
+
+func TestCronScheduler(t *testing.T) {
+	batch := Limit{MaxTokens: 1, RefillRate: 1, RefillInterval: time.Minute}
+	rules := []CronRule{
+		{Spec: "0 1 * * *", Limit: batch},
+		{Spec: "0 5 * * *"},
+	}
+
+	// 在批处理窗口内创建时直接进入该窗口
+	s := newCronScheduler(rules, time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC))
+	limit, ok := s.limit(time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC))
+	assert.True(t, ok)
+	assert.Equal(t, batch, limit)
+
+	// 窗口结束后恢复主配置
+	_, ok = s.limit(time.Date(2026, 10, 16, 5, 0, 0, 0, time.UTC))
+	assert.False(t, ok)
+
+	// 第二天再次切换
+	limit, ok = s.limit(time.Date(2026, 10, 17, 1, 30, 0, 0, time.UTC))
+	assert.True(t, ok)
+	assert.Equal(t, batch, limit)
+}
+
+func TestRateLimiterCron(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	config := RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Cron: []CronRule{
+			// 每分钟都会触发的规则始终生效
+			{Spec: "* * * * *", Limit: RateLimitParams{MaxTokens: 3, RefillRate: 3, RefillInterval: time.Minute}},
+		},
+	}
+
+	limiterMiddleware, err := NewRateLimiter(config)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "3", w.Header().Get("X-RateLimit-Limit"))
+
+	// 无效的表达式会被拒绝
+	config.Cron = []CronRule{{Spec: "0 25 * * *"}}
+	assert.Error(t, config.Validate())
+}
@@ -0,0 +1,25 @@
+package limiter
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setDebugHeaders explains the decision for a request. The key may reveal
+// client details such as IP addresses, so Debug is meant for staging.
+func (rl *RateLimiter) setDebugHeaders(c *gin.Context, key string, r rejection, u usage, known bool) {
+	algorithm := rl.config.Algorithm
+	if algorithm == "" {
+		algorithm = TokenBucket
+	}
+
+	c.Header("X-RateLimit-Debug-Key", key)
+	c.Header("X-RateLimit-Debug-Algorithm", algorithm)
+	if known {
+		c.Header("X-RateLimit-Debug-Bucket-Tokens", strconv.Itoa(u.remaining))
+	}
+	if r.rule != "" {
+		c.Header("X-RateLimit-Debug-Rule", r.rule)
+	}
+}
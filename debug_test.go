@@ -0,0 +1,50 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugHeaders(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+		MaxTokens:          2,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		GlobalLimit:        &Limit{MaxTokens: 1, RefillRate: 1, RefillInterval: time.Minute},
+		Debug:              true,
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "192.168.1.1", w.Header().Get("X-RateLimit-Debug-Key"))
+	assert.Equal(t, TokenBucket, w.Header().Get("X-RateLimit-Debug-Algorithm"))
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Debug-Bucket-Tokens"))
+	assert.Empty(t, w.Header().Get("X-RateLimit-Debug-Rule"))
+
+	// 被全局限额拒绝时报告对应的规则
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, GlobalRule, w.Header().Get("X-RateLimit-Debug-Rule"))
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Debug-Bucket-Tokens"))
+}
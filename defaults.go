@@ -0,0 +1,50 @@
+package limiter
+
+import (
+	"net/http"
+	"time"
+)
+
+// DefaultConfig allows each client IP 60 requests per minute, restored one
+// per second, with everything else at its default. Override only what
+// matters:
+//
+//	config := limiter.DefaultConfig()
+//	config.MaxTokens = 10
+func DefaultConfig() RateLimitConfig {
+	config := RateLimitConfig{
+		MaxTokens:       60,
+		RefillRate:      1,
+		RefillInterval:  time.Second,
+		KeyFunc:         ByClientIP(),
+		BurstMultiplier: 1,
+		Algorithm:       TokenBucket,
+		HeaderFormat:    XRateLimitHeaders,
+		StatusCode:      http.StatusTooManyRequests,
+	}
+	config.ExpirationDuration = defaultExpiration(&config)
+	return config
+}
+
+// defaultExpiration keeps idle buckets until they would be full again,
+// after which they are indistinguishable from new ones, but at least 10
+// minutes.
+func defaultExpiration(r *RateLimitConfig) time.Duration {
+	burst := r.BurstMultiplier
+	if burst <= 0 {
+		burst = 1
+	}
+	refills := (r.MaxTokens*burst + r.RefillRate - 1) / r.RefillRate
+	if full := time.Duration(refills+1) * r.RefillInterval; full > 10*time.Minute {
+		return full
+	}
+	return 10 * time.Minute
+}
+
+// defaultQueueTimeout gives queued requests as long as a full queue needs
+// to drain at the main rate, plus one interval, so that none of them waits
+// forever on a key that is never refilled in time.
+func defaultQueueTimeout(r *RateLimitConfig) time.Duration {
+	refills := (r.MaxQueue + r.RefillRate - 1) / r.RefillRate
+	return time.Duration(refills+1) * r.RefillInterval
+}
@@ -0,0 +1,74 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultConfig(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	// 默认配置无需修改即可通过校验
+	config := DefaultConfig()
+	assert.NoError(t, config.Validate())
+	assert.NotNil(t, config.KeyFunc)
+	assert.Equal(t, 10*time.Minute, config.ExpirationDuration)
+
+	// 只覆盖关心的字段
+	config.MaxTokens = 1
+	limiterMiddleware, err := NewRateLimiter(config)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		router.ServeHTTP(w, req)
+		return w
+	}
+	assert.Equal(t, http.StatusOK, request().Code)
+	assert.Equal(t, http.StatusTooManyRequests, request().Code)
+}
+
+func TestRateLimitConfig_ValidateDefaults(t *testing.T) {
+	// Validate 为零值补全默认值
+	config := RateLimitConfig{
+		MaxTokens:      10,
+		RefillRate:     1,
+		RefillInterval: time.Second,
+	}
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, 1, config.BurstMultiplier)
+	assert.Equal(t, 10*time.Minute, config.ExpirationDuration)
+	assert.NotNil(t, config.KeyFunc)
+
+	// 默认的过期时间至少能让桶重新装满
+	config = RateLimitConfig{
+		MaxTokens:       100,
+		RefillRate:      1,
+		RefillInterval:  time.Minute,
+		BurstMultiplier: 2,
+	}
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, 201*time.Minute, config.ExpirationDuration)
+
+	// 已设置的值保持不变
+	config.ExpirationDuration = time.Hour
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, time.Hour, config.ExpirationDuration)
+
+	// 没有合理默认值的字段仍然报错
+	assert.Error(t, (&RateLimitConfig{}).Validate())
+}
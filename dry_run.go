@@ -0,0 +1,25 @@
+package limiter
+
+import "github.com/gin-gonic/gin"
+
+// dryRun records a request that DryRun admits although it is over its
+// limit.
+func (rl *RateLimiter) dryRun(c *gin.Context, info LimitInfo) {
+	rl.dryRunDenied.Add(1)
+	if !rl.config.DisableHeaders {
+		c.Header("X-RateLimit-DryRun", "would-deny")
+	}
+	if rl.config.OnDryRun != nil {
+		rl.config.OnDryRun(c, info)
+	}
+}
+
+// dryRunRule reports whether DryRun admits a request that the stage rule
+// rejects before any bucket is consulted, and records it if so.
+func (rl *RateLimiter) dryRunRule(c *gin.Context, key, rule string) bool {
+	if !rl.config.DryRun {
+		return false
+	}
+	rl.dryRun(c, LimitInfo{Key: key, Rule: rule})
+	return true
+}
@@ -0,0 +1,127 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRun(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	var infos []LimitInfo
+	limiter, err := New(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		Timeout:            time.Second * 5,
+		ExpirationDuration: time.Minute * 5,
+		DryRun:             true,
+		OnDryRun: func(c *gin.Context, info LimitInfo) {
+			infos = append(infos, info)
+		},
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiter.RateLimitMiddleware())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Empty(t, w.Header().Get("X-RateLimit-DryRun"))
+
+	// 超限的请求不会等待也不会被拒绝，只会被记录下来
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "would-deny", w.Header().Get("X-RateLimit-DryRun"))
+	}
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Len(t, infos, 2)
+	assert.Equal(t, "192.168.1.1", infos[0].Key)
+
+	stats := limiter.Stats()
+	assert.Equal(t, uint64(3), stats.Allowed)
+	assert.Equal(t, uint64(0), stats.Denied)
+	assert.Equal(t, uint64(2), stats.DryRunDenied)
+}
+
+func TestDryRunPreChecks(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	var rules []string
+	limiter, err := New(RateLimitConfig{
+		MaxTokens:          2,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		MaxInFlight:        1,
+		Cost: func(c *gin.Context) int {
+			if c.Query("cost") != "" {
+				return 3
+			}
+			return 1
+		},
+		DryRun: true,
+		OnDryRun: func(c *gin.Context, info LimitInfo) {
+			rules = append(rules, info.Rule)
+		},
+	})
+	assert.NoError(t, err)
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	router := gin.New()
+	router.Use(limiter.RateLimitMiddleware())
+	router.GET("/", func(c *gin.Context) {
+		if c.Query("block") != "" {
+			close(entered)
+			<-release
+		}
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func(target string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", target, nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 开销超过桶容量的请求不会返回 413
+	w := request("/?cost=1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "would-deny", w.Header().Get("X-RateLimit-DryRun"))
+
+	// 超出并发上限的请求也只会被记录
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		request("/?block=1")
+	}()
+	<-entered
+	assert.Equal(t, http.StatusOK, request("/").Code)
+	close(release)
+	<-done
+
+	assert.Equal(t, []string{CapacityRule, InFlightRule}, rules)
+	assert.Equal(t, uint64(0), limiter.Stats().Denied)
+}
@@ -0,0 +1,95 @@
+package etcdstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// retryInterval is how long ConfigUpdates first waits before reading and
+// watching the key again. Each further failure doubles the wait up to 32
+// times this.
+const retryInterval = time.Second
+
+// configClient is the part of *clientv3.Client that ConfigUpdates uses.
+type configClient interface {
+	Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error)
+	Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan
+}
+
+// ConfigUpdates relays the current value of key in etcd, and every value
+// put there later, as updates for limiter.RateLimiter.WatchConfig until
+// ctx is done:
+//
+//	go rl.WatchConfig(ctx, etcdstore.ConfigUpdates(ctx, client, "/limits/api", nil), nil)
+//
+// If reading the key fails or the watch ends, e.g. because etcd compacted
+// the revision, the error is passed to onError unless it is nil, and key
+// is read and watched again after a backoff of one to 32 seconds. The
+// current value is relayed again after every such restart.
+func ConfigUpdates(ctx context.Context, client *clientv3.Client, key string, onError func(error)) <-chan []byte {
+	return configUpdates(ctx, client, key, onError, retryInterval)
+}
+
+func configUpdates(ctx context.Context, client configClient, key string, onError func(error), retry time.Duration) <-chan []byte {
+	updates := make(chan []byte)
+	go func() {
+		defer close(updates)
+		send := func(value []byte) bool {
+			select {
+			case updates <- value:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		delay := retry
+		for {
+			resp, err := client.Get(ctx, key)
+			if err == nil {
+				delay = retry
+				if len(resp.Kvs) > 0 && !send(resp.Kvs[0].Value) {
+					return
+				}
+				err = watchKey(ctx, client, key, resp.Header.Revision+1, send)
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			if onError != nil {
+				onError(err)
+			}
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return
+			}
+			if delay *= 2; delay > retry*32 {
+				delay = retry * 32
+			}
+		}
+	}()
+	return updates
+}
+
+// watchKey sends the values put to key from revision on until the
+// watch fails or ends, and returns why.
+func watchKey(ctx context.Context, client configClient, key string, revision int64, send func([]byte) bool) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for watch := range client.Watch(ctx, key, clientv3.WithRev(revision)) {
+		if err := watch.Err(); err != nil {
+			return err
+		}
+		for _, event := range watch.Events {
+			if event.Type == clientv3.EventTypePut && !send(event.Kv.Value) {
+				return ctx.Err()
+			}
+		}
+	}
+	return errors.New("limiter: etcd watch of " + key + " closed")
+}
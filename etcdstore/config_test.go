@@ -0,0 +1,86 @@
+package etcdstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// fakeEtcd 依次返回预设的读取结果和监听通道
+type fakeEtcd struct {
+	gets    []error
+	watches []chan clientv3.WatchResponse
+	value   string
+}
+
+func (f *fakeEtcd) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	err := f.gets[0]
+	f.gets = f.gets[1:]
+	if err != nil {
+		return nil, err
+	}
+	return &clientv3.GetResponse{
+		Header: &clientv3.ResponseHeader{Revision: 1},
+		Kvs:    []*clientv3.KeyValue{{Value: []byte(f.value)}},
+	}, nil
+}
+
+// Watch 像真实客户端一样在 ctx 结束时关闭通道
+func (f *fakeEtcd) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	watch := f.watches[0]
+	f.watches = f.watches[1:]
+	out := make(chan clientv3.WatchResponse)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case resp := <-watch:
+				select {
+				case out <- resp:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func TestConfigUpdates(t *testing.T) {
+	first := make(chan clientv3.WatchResponse, 2)
+	second := make(chan clientv3.WatchResponse)
+	client := &fakeEtcd{
+		gets:    []error{errors.New("etcd unavailable"), nil, nil},
+		watches: []chan clientv3.WatchResponse{first, second},
+		value:   "v1",
+	}
+	var errs []error
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates := configUpdates(ctx, client, "/limits/api", func(err error) { errs = append(errs, err) }, time.Millisecond)
+
+	// 首次读取失败后重试，并传递当前值
+	assert.Equal(t, "v1", string(<-updates))
+	assert.Equal(t, 1, len(errs))
+
+	// 监听到的变更依次传递
+	first <- clientv3.WatchResponse{Events: []*clientv3.Event{{Type: clientv3.EventTypePut, Kv: &clientv3.KeyValue{Value: []byte("v2")}}}}
+	assert.Equal(t, "v2", string(<-updates))
+
+	// 修订版本被压缩时报告错误，重新读取并监听
+	client.value = "v3"
+	first <- clientv3.WatchResponse{CompactRevision: 5}
+	assert.Equal(t, "v3", string(<-updates))
+	assert.Equal(t, 2, len(errs))
+
+	// ctx 结束后关闭通道
+	cancel()
+	_, ok := <-updates
+	assert.False(t, ok)
+}
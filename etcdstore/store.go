@@ -0,0 +1,119 @@
+// Package etcdstore keeps the buckets of a limiter in etcd, so that
+// several instances enforce one shared limit, and relays limits published
+// in etcd to running limiters.
+package etcdstore
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	limiter "github.com/colommar/gin-ratelimiter"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+var _ limiter.Store = (*Store)(nil)
+
+// Store is a limiter.Store that keeps buckets in etcd. Writes are guarded
+// by a ModRevision transaction and attached to a lease so idle buckets
+// vanish on their own.
+type Store struct {
+	kv     kv
+	prefix string
+
+	mutex        sync.Mutex
+	leaseID      clientv3.LeaseID
+	leaseTTL     time.Duration
+	leaseRenewAt time.Time
+}
+
+func New(client *clientv3.Client, prefix string) *Store {
+	return newStore(etcdKV{client}, prefix)
+}
+
+func newStore(kv kv, prefix string) *Store {
+	return &Store{
+		kv:      kv,
+		prefix:  prefix,
+		leaseID: clientv3.NoLease,
+	}
+}
+
+func (s *Store) Get(ctx context.Context, key string) ([]byte, int64, error) {
+	return s.kv.get(ctx, s.prefix+key)
+}
+
+func (s *Store) CompareAndSwap(ctx context.Context, key string, revision int64, value []byte, ttl time.Duration) (bool, error) {
+	leaseID, err := s.lease(ctx, ttl)
+	if err != nil {
+		return false, err
+	}
+	return s.kv.putIf(ctx, s.prefix+key, revision, value, leaseID)
+}
+
+// lease hands out a shared lease instead of granting one per write. Each
+// lease lives for twice the ttl but is only handed out during the first
+// half, so a bucket survives at least ttl after its last write.
+func (s *Store) lease(ctx context.Context, ttl time.Duration) (clientv3.LeaseID, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	if s.leaseID != clientv3.NoLease && s.leaseTTL == ttl && now.Before(s.leaseRenewAt) {
+		return s.leaseID, nil
+	}
+
+	seconds := int64((2*ttl + time.Second - 1) / time.Second)
+	leaseID, err := s.kv.grant(ctx, seconds)
+	if err != nil {
+		return clientv3.NoLease, err
+	}
+
+	s.leaseID = leaseID
+	s.leaseTTL = ttl
+	s.leaseRenewAt = now.Add(ttl)
+	return s.leaseID, nil
+}
+
+// kv is the part of etcd that Store uses.
+type kv interface {
+	get(ctx context.Context, key string) (value []byte, revision int64, err error)
+	// putIf writes value to key, attached to lease, if the ModRevision of
+	// key is still revision; a missing key has revision 0.
+	putIf(ctx context.Context, key string, revision int64, value []byte, lease clientv3.LeaseID) (bool, error)
+	grant(ctx context.Context, seconds int64) (clientv3.LeaseID, error)
+}
+
+type etcdKV struct {
+	client *clientv3.Client
+}
+
+func (e etcdKV) get(ctx context.Context, key string) ([]byte, int64, error) {
+	resp, err := e.client.Get(ctx, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, 0, nil
+	}
+	return resp.Kvs[0].Value, resp.Kvs[0].ModRevision, nil
+}
+
+func (e etcdKV) putIf(ctx context.Context, key string, revision int64, value []byte, lease clientv3.LeaseID) (bool, error) {
+	resp, err := e.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(key), "=", revision)).
+		Then(clientv3.OpPut(key, string(value), clientv3.WithLease(lease))).
+		Commit()
+	if err != nil {
+		return false, err
+	}
+	return resp.Succeeded, nil
+}
+
+func (e etcdKV) grant(ctx context.Context, seconds int64) (clientv3.LeaseID, error) {
+	resp, err := e.client.Grant(ctx, seconds)
+	if err != nil {
+		return clientv3.NoLease, err
+	}
+	return resp.ID, nil
+}
@@ -0,0 +1,192 @@
+package etcdstore
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	limiter "github.com/colommar/gin-ratelimiter"
+)
+
+// fakeKV 按 etcd 的语义模拟修订号和租约：修订号全局递增，不存在的键修订号为 0
+type fakeKV struct {
+	mutex    sync.Mutex
+	revision int64
+	values   map[string][]byte
+	revs     map[string]int64
+	leases   map[string]clientv3.LeaseID
+	grants   []int64
+	err      error
+}
+
+func newFakeKV() *fakeKV {
+	return &fakeKV{
+		values: make(map[string][]byte),
+		revs:   make(map[string]int64),
+		leases: make(map[string]clientv3.LeaseID),
+	}
+}
+
+func (f *fakeKV) get(ctx context.Context, key string) ([]byte, int64, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.err != nil {
+		return nil, 0, f.err
+	}
+	return f.values[key], f.revs[key], nil
+}
+
+func (f *fakeKV) putIf(ctx context.Context, key string, revision int64, value []byte, lease clientv3.LeaseID) (bool, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.err != nil {
+		return false, f.err
+	}
+	if f.revs[key] != revision {
+		return false, nil
+	}
+	f.revision++
+	f.values[key] = value
+	f.revs[key] = f.revision
+	f.leases[key] = lease
+	return true, nil
+}
+
+func (f *fakeKV) grant(ctx context.Context, seconds int64) (clientv3.LeaseID, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.err != nil {
+		return clientv3.NoLease, f.err
+	}
+	f.grants = append(f.grants, seconds)
+	return clientv3.LeaseID(len(f.grants)), nil
+}
+
+func TestStoreCompareAndSwap(t *testing.T) {
+	kv := newFakeKV()
+	store := newStore(kv, "limits/")
+	ctx := context.Background()
+
+	// 不存在的键返回空值和修订号 0
+	value, revision, err := store.Get(ctx, "user")
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+	assert.Equal(t, int64(0), revision)
+
+	// 修订号 0 表示新建，写入时带上前缀
+	ok, err := store.CompareAndSwap(ctx, "user", 0, []byte("a"), time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("a"), kv.values["limits/user"])
+
+	value, revision, err = store.Get(ctx, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("a"), value)
+	assert.NotEqual(t, int64(0), revision)
+
+	// 过期的修订号写入失败，值保持不变
+	ok, err = store.CompareAndSwap(ctx, "user", 0, []byte("b"), time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, []byte("a"), kv.values["limits/user"])
+
+	// 当前修订号写入成功
+	ok, err = store.CompareAndSwap(ctx, "user", revision, []byte("c"), time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	value, _, _ = store.Get(ctx, "user")
+	assert.Equal(t, []byte("c"), value)
+}
+
+func TestStoreLease(t *testing.T) {
+	kv := newFakeKV()
+	store := newStore(kv, "")
+	ctx := context.Background()
+
+	// 同一个 ttl 的写入共享一个租约，租约时长为 ttl 的两倍并向上取整到秒
+	_, err := store.CompareAndSwap(ctx, "a", 0, []byte("1"), 1500*time.Millisecond)
+	assert.NoError(t, err)
+	_, err = store.CompareAndSwap(ctx, "b", 0, []byte("1"), 1500*time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3}, kv.grants)
+	assert.Equal(t, kv.leases["a"], kv.leases["b"])
+
+	// ttl 变化时重新申请租约
+	_, err = store.CompareAndSwap(ctx, "c", 0, []byte("1"), 10*time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3, 1}, kv.grants)
+
+	// 超过 ttl 后不再复用旧租约
+	time.Sleep(20 * time.Millisecond)
+	_, err = store.CompareAndSwap(ctx, "d", 0, []byte("1"), 10*time.Millisecond)
+	assert.NoError(t, err)
+	assert.Len(t, kv.grants, 3)
+	assert.NotEqual(t, kv.leases["c"], kv.leases["d"])
+}
+
+func TestStoreErrors(t *testing.T) {
+	kv := newFakeKV()
+	kv.err = errors.New("unavailable")
+	store := newStore(kv, "")
+	ctx := context.Background()
+
+	_, _, err := store.Get(ctx, "user")
+	assert.Error(t, err)
+
+	// 申请租约失败时不会写入
+	ok, err := store.CompareAndSwap(ctx, "user", 0, []byte("a"), time.Minute)
+	assert.Error(t, err)
+	assert.False(t, ok)
+	assert.Empty(t, kv.values)
+}
+
+func TestStoreSharedLimiters(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	store := newStore(newFakeKV(), "limits/")
+	config := limiter.RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Second,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Store:              store,
+	}
+
+	// 两个中间件实例共享同一个 etcd Store
+	first, err := limiter.NewRateLimiter(config)
+	assert.NoError(t, err)
+	second, err := limiter.NewRateLimiter(config)
+	assert.NoError(t, err)
+
+	newRouter := func(middleware gin.HandlerFunc) *gin.Engine {
+		router := gin.New()
+		router.Use(middleware)
+		router.GET("/", func(c *gin.Context) {
+			c.String(http.StatusOK, "Hello, world!")
+		})
+		return router
+	}
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+
+	w := httptest.NewRecorder()
+	newRouter(first).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 第二个实例看到令牌已被消耗
+	w = httptest.NewRecorder()
+	newRouter(second).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
@@ -0,0 +1,71 @@
+package limiter
+
+import (
+	"errors"
+	"net/netip"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PrivateNetworks are the loopback, RFC 1918, link-local and unique local
+// ranges, for use as ExemptNetworks.
+var PrivateNetworks = []string{
+	"127.0.0.0/8",
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"169.254.0.0/16",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+}
+
+// parsePrefix accepts a CIDR or a single address.
+func parsePrefix(s string) (netip.Prefix, bool) {
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, false
+		}
+		prefix = netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen())
+	}
+	return prefix.Masked(), true
+}
+
+func parseExemptNetworks(networks []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(networks))
+	for _, network := range networks {
+		prefix, ok := parsePrefix(network)
+		if !ok {
+			return nil, errors.New("ExemptNetworks contains invalid network " + network)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, nil
+}
+
+// exempted reports whether the client of c is in one of the
+// ExemptNetworks. The client is the direct peer, or with TrustedProxies
+// the address they forwarded; c.ClientIP() would also believe headers
+// that any client can send.
+func (rl *RateLimiter) exempted(c *gin.Context) bool {
+	if len(rl.exempt) == 0 {
+		return false
+	}
+	client := peerAddr(c.Request)
+	if rl.clientAddr != nil {
+		client = rl.clientAddr(c.Request)
+	}
+	addr, err := netip.ParseAddr(client)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range rl.exempt {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
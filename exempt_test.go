@@ -0,0 +1,88 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterExemptNetworks(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	config := RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		ExemptNetworks:     append(PrivateNetworks, "203.0.113.7"),
+	}
+
+	limiterMiddleware, err := NewRateLimiter(config)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func(remoteAddr string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remoteAddr
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 内网、回环和单独列出的地址不受限流
+	for _, remoteAddr := range []string{"192.168.1.1:1234", "10.1.2.3:1234", "127.0.0.1:1234", "[::1]:1234", "[fe80::1]:1234", "203.0.113.7:1234"} {
+		assert.Equal(t, http.StatusOK, request(remoteAddr))
+		assert.Equal(t, http.StatusOK, request(remoteAddr))
+	}
+
+	// 公网地址正常限流
+	assert.Equal(t, http.StatusOK, request("198.51.100.1:1234"))
+	assert.Equal(t, http.StatusTooManyRequests, request("198.51.100.1:1234"))
+
+	// 伪造 X-Forwarded-For 的公网客户端仍然受到限流
+	spoof := func(remoteAddr string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", "10.0.0.1")
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusOK, spoof("198.51.100.2:1234"))
+	assert.Equal(t, http.StatusTooManyRequests, spoof("198.51.100.2:1234"))
+
+	// 设置 TrustedProxies 后只信任可信代理转发的地址
+	config.ExemptNetworks = []string{"10.0.0.0/8"}
+	config.TrustedProxies = []string{"192.168.1.1"}
+	limiterMiddleware, err = NewRateLimiter(config)
+	assert.NoError(t, err)
+	router = gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, http.StatusOK, spoof("192.168.1.1:1234"))
+	}
+	assert.Equal(t, http.StatusOK, spoof("198.51.100.3:1234"))
+	assert.Equal(t, http.StatusTooManyRequests, spoof("198.51.100.3:1234"))
+
+	// 无效的网段和代理地址会被拒绝
+	config.TrustedProxies = []string{"proxy"}
+	assert.Error(t, config.Validate())
+	config.TrustedProxies = nil
+	config.ExemptNetworks = []string{"10.0.0.0/33"}
+	assert.Error(t, config.Validate())
+}
@@ -0,0 +1,171 @@
+package limiter
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// fairQueue hands the GlobalLimit to the keys waiting for it by weighted
+// fair queuing. Every waiter gets a virtual finish tag that grows by n
+// divided by its weight with each request of its key, and only the waiter
+// with the lowest tag may take global tokens. A key that sends many
+// requests thus pushes its own tags back rather than starving the others.
+type fairQueue struct {
+	waiters []*fairWaiter
+	last    map[string]float64
+	virtual float64
+	seq     uint64
+	changed chan struct{}
+	mutex   sync.Mutex
+}
+
+type fairWaiter struct {
+	key    string
+	finish float64
+	seq    uint64
+	done   bool
+}
+
+func newFairQueue() *fairQueue {
+	return &fairQueue{
+		last:    make(map[string]float64),
+		changed: make(chan struct{}),
+	}
+}
+
+func (q *fairQueue) enter(key string, n, weight int) *fairWaiter {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	start := q.virtual
+	if last, ok := q.last[key]; ok && last > start {
+		start = last
+	}
+	q.seq++
+	w := &fairWaiter{key: key, finish: start + float64(n)/float64(weight), seq: q.seq}
+	q.last[key] = w.finish
+
+	i := sort.Search(len(q.waiters), func(i int) bool {
+		return q.waiters[i].finish > w.finish
+	})
+	q.waiters = append(q.waiters, nil)
+	copy(q.waiters[i+1:], q.waiters[i:])
+	q.waiters[i] = w
+	if i == 0 {
+		q.notify()
+	}
+	return w
+}
+
+// busy reports whether any key is waiting for the GlobalLimit.
+func (q *fairQueue) busy() bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return len(q.waiters) > 0
+}
+
+// head reports whether w has the lowest tag, and returns a channel that
+// is closed once that may have changed.
+func (q *fairQueue) head(w *fairWaiter) (bool, <-chan struct{}) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return len(q.waiters) > 0 && q.waiters[0] == w, q.changed
+}
+
+func (q *fairQueue) leave(w *fairWaiter) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if w.done {
+		return
+	}
+	w.done = true
+	for i, waiter := range q.waiters {
+		if waiter != w {
+			continue
+		}
+		q.waiters = append(q.waiters[:i], q.waiters[i+1:]...)
+		if i == 0 {
+			q.virtual = w.finish
+			q.notify()
+		}
+		break
+	}
+	// Keys whose tag virtual time has caught up with start afresh anyway.
+	for key, last := range q.last {
+		if last <= q.virtual {
+			delete(q.last, key)
+		}
+	}
+}
+
+// notify wakes every waiter to check whether it is at the head now. The
+// caller must hold the lock.
+func (q *fairQueue) notify() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// waitFair waits for a request that the GlobalLimit denied until it is
+// the fair queue's turn and a token frees up, or timeout elapses. Once
+// another stage denies it, it leaves the fair queue so as not to hold up
+// the other keys, and waits like any other request.
+func (rl *RateLimiter) waitFair(ctx context.Context, key string, n int, scopes []string, weight int, timeout time.Duration, r rejection) (bool, rejection, error) {
+	w := rl.fair.enter(key, n, weight)
+	defer rl.fair.leave(w)
+
+	start := time.Now()
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	for {
+		head, changed := rl.fair.head(w)
+		var retry <-chan time.Time
+		var timer *time.Timer
+		if head {
+			timer = time.NewTimer(r.retryAfter)
+			retry = timer.C
+		}
+
+		select {
+		case <-retry:
+		case <-changed:
+			if timer != nil {
+				timer.Stop()
+			}
+			continue
+		case <-expired:
+			if timer != nil {
+				timer.Stop()
+			}
+			return false, r, nil
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return false, r, nil
+		}
+
+		allowed, next, err := rl.takeScoped(ctx, key, n, scopes)
+		if err != nil || allowed {
+			return allowed, next, err
+		}
+		r = next
+		if r.rule != GlobalRule {
+			rl.fair.leave(w)
+			if timeout > 0 {
+				if timeout -= time.Since(start); timeout <= 0 {
+					return false, r, nil
+				}
+			}
+			return rl.wait(ctx, key, n, scopes, timeout, r)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFairQueueOrder(t *testing.T) {
+	q := newFairQueue()
+
+	// 激进的键不断推后自己的标签
+	a1 := q.enter("a", 1, 1)
+	a2 := q.enter("a", 1, 1)
+	a3 := q.enter("a", 1, 1)
+	b1 := q.enter("b", 1, 1)
+	// 权重更高的键获得更多份额
+	c1 := q.enter("c", 1, 2)
+	c2 := q.enter("c", 1, 2)
+	assert.Equal(t, []*fairWaiter{c1, a1, b1, c2, a2, a3}, q.waiters)
+
+	head, changed := q.head(c1)
+	assert.True(t, head)
+
+	// 队首离开后通知其他等待者
+	q.leave(c1)
+	select {
+	case <-changed:
+	default:
+		t.Fatal("waiters should be notified")
+	}
+	head, _ = q.head(a1)
+	assert.True(t, head)
+
+	for _, w := range []*fairWaiter{a1, b1, c2, a2, a3} {
+		q.leave(w)
+	}
+	assert.False(t, q.busy())
+	assert.Empty(t, q.last)
+}
+
+func TestRateLimiterFairQueuing(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	config := RateLimitConfig{
+		MaxTokens:          100,
+		RefillRate:         100,
+		RefillInterval:     time.Second,
+		KeyFunc:            ByHeader("X-User"),
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		MaxQueue:           10,
+		FairQueuing:        true,
+		GlobalLimit:        &Limit{MaxTokens: 1, RefillRate: 1, RefillInterval: time.Millisecond * 30},
+	}
+
+	limiterMiddleware, err := NewRateLimiter(config)
+	assert.NoError(t, err)
+
+	var mutex sync.Mutex
+	var order []string
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		mutex.Lock()
+		order = append(order, c.GetHeader("X-User"))
+		mutex.Unlock()
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	var wg sync.WaitGroup
+	request := func(user string) {
+		defer wg.Done()
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		req.Header.Set("X-User", user)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	// 一个键先发出大量请求，另一个键随后到达也不会被饿死
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go request("aggressive")
+	}
+	time.Sleep(time.Millisecond * 10)
+	wg.Add(1)
+	go request("polite")
+	wg.Wait()
+
+	assert.Len(t, order, 5)
+	assert.Contains(t, order[:3], "polite")
+
+	// 缺少 MaxQueue 或 GlobalLimit 时拒绝配置
+	config.GlobalLimit = nil
+	assert.Error(t, config.Validate())
+}
@@ -0,0 +1,33 @@
+package limiter
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FingerprintHeaders are the request headers ByFingerprint combines when
+// no others are given.
+var FingerprintHeaders = []string{"User-Agent", "Accept", "Accept-Language", "Accept-Encoding"}
+
+// ByFingerprint keys requests by a SHA-256 hash of the client IP prefix
+// and the values of headers, or FingerprintHeaders if none are given.
+// Bots that rotate addresses within a block but send identical headers
+// share one bucket, while different browsers behind one NAT address do
+// not. Passing 0 for ipv4Bits and ipv6Bits leaves the IP out, which also
+// groups a botnet spread over unrelated networks.
+func ByFingerprint(ipv4Bits, ipv6Bits int, headers ...string) func(*gin.Context) string {
+	if len(headers) == 0 {
+		headers = FingerprintHeaders
+	}
+	return func(c *gin.Context) string {
+		parts := make([]string, 0, len(headers)+1)
+		if ipv4Bits > 0 || ipv6Bits > 0 {
+			parts = append(parts, ipPrefix(c.ClientIP(), ipv4Bits, ipv6Bits))
+		}
+		for _, header := range headers {
+			parts = append(parts, strings.TrimSpace(c.GetHeader(header)))
+		}
+		return hashKey(strings.Join(parts, "\x00"))
+	}
+}
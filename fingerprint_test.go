@@ -0,0 +1,41 @@
+package limiter
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestByFingerprint(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	client := func(ip, userAgent string) func(req *http.Request) {
+		return func(req *http.Request) {
+			req.RemoteAddr = ip + ":1234"
+			req.Header.Set("User-Agent", userAgent)
+			req.Header.Set("Accept-Language", "en-US")
+		}
+	}
+	keyFunc := ByFingerprint(24, 48)
+	key := keyFunc(newKeyContext(client("203.0.113.7", "bot/1.0")))
+
+	// 键是稳定的哈希值
+	assert.Len(t, key, 64)
+	assert.Equal(t, key, keyFunc(newKeyContext(client("203.0.113.7", "bot/1.0"))))
+
+	// 同一网段内轮换 IP 的相同客户端共享键
+	assert.Equal(t, key, keyFunc(newKeyContext(client("203.0.113.99", "bot/1.0"))))
+
+	// 不同的请求头或网段生成不同的键
+	assert.NotEqual(t, key, keyFunc(newKeyContext(client("203.0.113.7", "browser/2.0"))))
+	assert.NotEqual(t, key, keyFunc(newKeyContext(client("198.51.100.7", "bot/1.0"))))
+
+	// 不含 IP 时跨网段的相同客户端共享键
+	keyFunc = ByFingerprint(0, 0, "User-Agent")
+	assert.Equal(t,
+		keyFunc(newKeyContext(client("203.0.113.7", "bot/1.0"))),
+		keyFunc(newKeyContext(client("198.51.100.7", "bot/1.0"))))
+}
@@ -0,0 +1,72 @@
+package limiter
+
+import (
+	"sync"
+	"time"
+)
+
+// fixedWindow admits MaxTokens requests per calendar window; every counter
+// resets when the wall clock crosses a multiple of RefillInterval (e.g. at
+// :00 for one-minute windows).
+type fixedWindow struct {
+	limit   int
+	window  time.Duration
+	windows map[string]*windowCount
+	mutex   sync.Mutex
+}
+
+func newFixedWindow(limit int, window time.Duration) *fixedWindow {
+	return &fixedWindow{
+		limit:   limit,
+		window:  window,
+		windows: make(map[string]*windowCount),
+	}
+}
+
+func (f *fixedWindow) Take(key string, n int, now time.Time) (bool, time.Duration) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	start := now.Truncate(f.window)
+	w, exists := f.windows[key]
+	if !exists || !w.start.Equal(start) {
+		w = &windowCount{start: start}
+		f.windows[key] = w
+	}
+
+	if w.current+n > f.limit {
+		return false, start.Add(f.window).Sub(now)
+	}
+	w.current += n
+	return true, 0
+}
+
+func (f *fixedWindow) Quota(key string, now time.Time) (int, int, time.Duration) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	start := now.Truncate(f.window)
+	w, exists := f.windows[key]
+	if !exists || !w.start.Equal(start) || w.current == 0 {
+		return f.limit, f.limit, 0
+	}
+	return f.limit, maxInt(f.limit-w.current, 0), start.Add(f.window).Sub(now)
+}
+
+func (f *fixedWindow) Reset(key string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	delete(f.windows, key)
+}
+
+func (f *fixedWindow) Cleanup(now time.Time, expiration time.Duration) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for key, w := range f.windows {
+		if now.Sub(w.start) > expiration {
+			delete(f.windows, key)
+		}
+	}
+}
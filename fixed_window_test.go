@@ -0,0 +1,27 @@
+package limiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixedWindow_ResetsAtBoundary(t *testing.T) {
+	window := newFixedWindow(2, time.Minute)
+	start := time.Date(2024, 1, 1, 12, 0, 30, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		allowed, _ := window.Take("key", 1, start)
+		assert.True(t, allowed)
+	}
+
+	// 超出额度后需要等到下一个整分钟
+	allowed, retryAfter := window.Take("key", 1, start)
+	assert.False(t, allowed)
+	assert.Equal(t, time.Second*30, retryAfter)
+
+	// 12:01:00 计数重置
+	allowed, _ = window.Take("key", 1, time.Date(2024, 1, 1, 12, 1, 0, 0, time.UTC))
+	assert.True(t, allowed)
+}
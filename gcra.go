@@ -0,0 +1,122 @@
+package limiter
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// gcra implements the generic cell rate algorithm. It is equivalent to a
+// token bucket of MaxTokens*BurstMultiplier tokens refilled at RefillRate
+// per RefillInterval, but only stores the theoretical arrival time (TAT)
+// of the next request per key.
+type gcra struct {
+	interval time.Duration
+	burst    int
+	tats     map[string]time.Time
+	mutex    sync.Mutex
+}
+
+func newGCRA(config RateLimitConfig) *gcra {
+	return &gcra{
+		interval: config.RefillInterval / time.Duration(config.RefillRate),
+		burst:    config.MaxTokens * config.BurstMultiplier,
+		tats:     make(map[string]time.Time),
+	}
+}
+
+// next returns the TAT after admitting n requests at now, or how long the
+// caller has to wait if that would exceed the burst tolerance.
+func (g *gcra) next(tat time.Time, n int, now time.Time) (time.Time, bool, time.Duration) {
+	if tat.Before(now) {
+		tat = now
+	}
+	newTat := tat.Add(time.Duration(n) * g.interval)
+	allowAt := newTat.Add(-time.Duration(g.burst) * g.interval)
+	if now.Before(allowAt) {
+		return tat, false, allowAt.Sub(now)
+	}
+	return newTat, true, 0
+}
+
+func (g *gcra) Take(key string, n int, now time.Time) (bool, time.Duration) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	tat, allowed, retryAfter := g.next(g.tats[key], n, now)
+	if allowed {
+		g.tats[key] = tat
+	}
+	return allowed, retryAfter
+}
+
+func (g *gcra) Quota(key string, now time.Time) (int, int, time.Duration) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	return g.quota(g.tats[key], now)
+}
+
+// quota derives the remaining burst from how far tat is ahead of now.
+func (g *gcra) quota(tat time.Time, now time.Time) (int, int, time.Duration) {
+	ahead := tat.Sub(now)
+	used := ceilDiv(ahead, g.interval)
+	if used == 0 {
+		return g.burst, g.burst, 0
+	}
+	return g.burst, maxInt(g.burst-used, 0), ahead
+}
+
+func (g *gcra) Reset(key string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	delete(g.tats, key)
+}
+
+func (g *gcra) Cleanup(now time.Time, expiration time.Duration) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	for key, tat := range g.tats {
+		if now.Sub(tat) > expiration {
+			delete(g.tats, key)
+		}
+	}
+}
+
+func (g *gcra) takeFromStore(ctx context.Context, store Store, key string, n int, ttl time.Duration) (bool, rejection, error) {
+	for attempt := 0; attempt < maxStoreAttempts; attempt++ {
+		value, revision, err := store.Get(ctx, key)
+		if err != nil {
+			return false, rejection{}, err
+		}
+
+		var tat time.Time
+		if len(value) == 8 {
+			tat = time.Unix(0, int64(binary.BigEndian.Uint64(value)))
+		}
+
+		now := time.Now()
+		next, allowed, retryAfter := g.next(tat, n, now)
+		if !allowed {
+			r := rejection{retryAfter: retryAfter, quoted: true}
+			r.usage.limit, r.usage.remaining, r.usage.reset = g.quota(tat, now)
+			return false, r, nil
+		}
+
+		buf := make([]byte, 8)
+		binary.BigEndian.PutUint64(buf, uint64(next.UnixNano()))
+		ok, err := store.CompareAndSwap(ctx, key, revision, buf, ttl)
+		if err != nil {
+			return false, rejection{}, err
+		}
+		if ok {
+			r := rejection{quoted: true}
+			r.usage.limit, r.usage.remaining, r.usage.reset = g.quota(next, now)
+			return true, r, nil
+		}
+	}
+	return false, rejection{}, ErrStoreContention
+}
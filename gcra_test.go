@@ -0,0 +1,57 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGCRA_BurstAndRate(t *testing.T) {
+	// 每秒 2 个请求，突发容量为 2
+	g := newGCRA(RateLimitConfig{
+		MaxTokens:       2,
+		RefillRate:      2,
+		RefillInterval:  time.Second,
+		BurstMultiplier: 1,
+	})
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		allowed, _ := g.Take("key", 1, now)
+		assert.True(t, allowed)
+	}
+	allowed, retryAfter := g.Take("key", 1, now)
+	assert.False(t, allowed)
+	assert.Equal(t, time.Millisecond*500, retryAfter)
+
+	// 半秒后恢复一个请求的额度
+	allowed, _ = g.Take("key", 1, now.Add(time.Millisecond*500))
+	assert.True(t, allowed)
+	allowed, _ = g.Take("key", 1, now.Add(time.Millisecond*500))
+	assert.False(t, allowed)
+}
+
+func TestGCRA_TakeFromStore(t *testing.T) {
+	store := newMapStore()
+	g := newGCRA(RateLimitConfig{
+		MaxTokens:       1,
+		RefillRate:      1,
+		RefillInterval:  time.Minute,
+		BurstMultiplier: 1,
+	})
+	ctx := context.Background()
+
+	allowed, _, err := g.takeFromStore(ctx, store, "key", 1, time.Minute*5)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+
+	// 存储中只保存一个 8 字节的 TAT
+	value, _, _ := store.Get(ctx, "key")
+	assert.Len(t, value, 8)
+
+	allowed, _, err = g.takeFromStore(ctx, store, "key", 1, time.Minute*5)
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+}
@@ -0,0 +1,60 @@
+package limiter
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CountryResolver returns the ISO 3166-1 alpha-2 code of the country ip
+// is located in, or "" if it is unknown. The maxmind package provides one
+// backed by a MaxMind database.
+type CountryResolver interface {
+	Country(ip net.IP) (string, error)
+}
+
+// clientCountry resolves the country of c.ClientIP(), reporting lookup
+// failures on c and returning "" for them.
+func clientCountry(c *gin.Context, resolver CountryResolver) string {
+	ip := net.ParseIP(c.ClientIP())
+	if ip == nil {
+		return ""
+	}
+	country, err := resolver.Country(ip)
+	if err != nil {
+		_ = c.Error(err)
+		return ""
+	}
+	return strings.ToUpper(country)
+}
+
+// CountryClass is a PolicySet selector that names the class of a request
+// by the country of the client, e.g. mapping several countries to one
+// region. Other countries give "".
+func CountryClass(resolver CountryResolver, classes map[string]string) func(*gin.Context) string {
+	return func(c *gin.Context) string {
+		if country := clientCountry(c, resolver); country != "" {
+			return classes[country]
+		}
+		return ""
+	}
+}
+
+// BlockCountries rejects requests from the given countries with 403
+// Forbidden before they reach the limiter. Clients whose country is
+// unknown are let through.
+func BlockCountries(resolver CountryResolver, countries ...string) gin.HandlerFunc {
+	blocked := make(map[string]bool, len(countries))
+	for _, country := range countries {
+		blocked[strings.ToUpper(country)] = true
+	}
+	return func(c *gin.Context) {
+		if country := clientCountry(c, resolver); country != "" && blocked[country] {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		c.Next()
+	}
+}
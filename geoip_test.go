@@ -0,0 +1,53 @@
+package limiter
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type testCountryResolver map[string]string
+
+func (r testCountryResolver) Country(ip net.IP) (string, error) {
+	return r[ip.String()], nil
+}
+
+func TestCountryPolicies(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	resolver := testCountryResolver{"203.0.113.1": "de", "198.51.100.1": "FR", "192.0.2.1": "KP"}
+	client := func(ip string) *gin.Context {
+		return newKeyContext(func(req *http.Request) { req.RemoteAddr = ip + ":1234" })
+	}
+
+	// 按国家划分类别
+	classes := CountryClass(resolver, map[string]string{"DE": "eu", "FR": "eu"})
+	assert.Equal(t, "eu", classes(client("203.0.113.1")))
+	assert.Equal(t, "eu", classes(client("198.51.100.1")))
+	assert.Equal(t, "", classes(client("192.0.2.1")))
+	assert.Equal(t, "", classes(client("192.168.1.1")))
+
+	router := gin.New()
+	router.Use(BlockCountries(resolver, "kp"))
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func(ip string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 被封锁国家的请求返回 403，未知国家放行
+	assert.Equal(t, http.StatusForbidden, request("192.0.2.1"))
+	assert.Equal(t, http.StatusOK, request("203.0.113.1"))
+	assert.Equal(t, http.StatusOK, request("192.168.1.1"))
+}
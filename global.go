@@ -0,0 +1,63 @@
+package limiter
+
+import (
+	"errors"
+	"time"
+)
+
+// Limit describes a token bucket that is configured separately from the
+// main per-key bucket.
+type Limit struct {
+	MaxTokens      int
+	RefillRate     int
+	RefillInterval time.Duration
+}
+
+func (l *Limit) Validate() error {
+	if l.MaxTokens <= 0 {
+		return errors.New("MaxTokens must be greater than 0")
+	}
+	if l.RefillRate <= 0 {
+		return errors.New("RefillRate must be greater than 0")
+	}
+	if l.RefillInterval <= 0 {
+		return errors.New("RefillInterval must be greater than 0")
+	}
+	return nil
+}
+
+func newLimitBucket(limit Limit, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		tokens:         limit.MaxTokens,
+		lastRefill:     now,
+		maxTokens:      limit.MaxTokens,
+		refillRate:     limit.RefillRate,
+		refillInterval: limit.RefillInterval,
+	}
+}
+
+func (b *tokenBucket) take(n int, now time.Time) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.refill(now)
+	if b.tokens >= n {
+		b.tokens -= n
+		return true
+	}
+	return false
+}
+
+func (b *tokenBucket) reject(rule string, n int, now time.Time) rejection {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.rejection(rule, n, now)
+}
+
+func (b *tokenBucket) refund(n int) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.tokens = minInt(b.tokens+n, b.maxTokens)
+}
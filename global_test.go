@@ -0,0 +1,66 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterGlobalLimit(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	config := RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		GlobalLimit: &Limit{
+			MaxTokens:      2,
+			RefillRate:     1,
+			RefillInterval: time.Minute,
+		},
+	}
+
+	limiterMiddleware, err := NewRateLimiter(config)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func(ip string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("10.0.0.1"))
+	// 被单键限流拒绝的请求不消耗全局令牌
+	assert.Equal(t, http.StatusTooManyRequests, request("10.0.0.1"))
+	assert.Equal(t, http.StatusOK, request("10.0.0.2"))
+	// 全局令牌耗尽后，新的客户端也会被限流
+	assert.Equal(t, http.StatusTooManyRequests, request("10.0.0.3"))
+}
+
+func TestValidateGlobalLimit(t *testing.T) {
+	config := RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Second,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		GlobalLimit:        &Limit{MaxTokens: 0, RefillRate: 1, RefillInterval: time.Second},
+	}
+	assert.EqualError(t, config.Validate(), "GlobalLimit.MaxTokens must be greater than 0")
+}
@@ -0,0 +1,104 @@
+package limiter
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GraphQLAnalyzer computes the cost of a GraphQL query, e.g. from its
+// depth, its fields or list sizes taken from variables.
+type GraphQLAnalyzer func(query string, variables map[string]any) (int, error)
+
+// GraphQLCost returns a Cost function that charges GraphQL requests by
+// their complexity, so a budget can be given in points per minute rather
+// than in requests. Bodies larger than maxBytes, unparsable requests and
+// analyzer errors cost 1; the GraphQL server rejects those anyway.
+func GraphQLCost(maxBytes int64, analyzer GraphQLAnalyzer) func(*gin.Context) int {
+	return func(c *gin.Context) int {
+		body, ok := peekBody(c, maxBytes)
+		if !ok {
+			return 1
+		}
+		var request struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		if json.Unmarshal(body, &request) != nil {
+			return 1
+		}
+		cost, err := analyzer(request.Query, request.Variables)
+		if err != nil {
+			_ = c.Error(err)
+			return 1
+		}
+		return cost
+	}
+}
+
+// CountGraphQLFields is a simple GraphQLAnalyzer that charges one point
+// per field selected in the query, aliases and fragment spreads aside.
+func CountGraphQLFields(query string, _ map[string]any) (int, error) {
+	fields := 0
+	depth, args := 0, 0
+	skipName := false
+	for i := 0; i < len(query); i++ {
+		switch ch := query[i]; {
+		case ch == '#':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case ch == '"':
+			// Strings only appear in arguments and are skipped whole.
+			for i++; i < len(query) && query[i] != '"'; i++ {
+				if query[i] == '\\' {
+					i++
+				}
+			}
+		case ch == '{':
+			depth++
+		case ch == '}':
+			depth--
+		case ch == '(':
+			args++
+		case ch == ')':
+			args--
+		case ch == '.' || ch == '@' || ch == '$':
+			skipName = true
+		case isNameStart(ch):
+			start := i
+			for i+1 < len(query) && isNameChar(query[i+1]) {
+				i++
+			}
+			name := query[start : i+1]
+			if skipName || depth == 0 || args > 0 || name == "on" || nextIsColon(query, i+1) {
+				// Spreads, directives, variables, type conditions and aliases.
+				skipName = name == "on"
+				continue
+			}
+			fields++
+		}
+	}
+	return maxInt(fields, 1), nil
+}
+
+func isNameStart(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func isNameChar(ch byte) bool {
+	return isNameStart(ch) || (ch >= '0' && ch <= '9')
+}
+
+func nextIsColon(query string, i int) bool {
+	for ; i < len(query); i++ {
+		switch query[i] {
+		case ' ', '\t', '\n', '\r', ',':
+			continue
+		case ':':
+			return true
+		}
+		return false
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package limiter
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountGraphQLFields(t *testing.T) {
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{`{ user { id name } }`, 3},
+		{`query GetUser($id: ID!) { user(id: $id, filter: "a{b}") { id posts(first: 10) { title } } }`, 4},
+		// 别名、片段和指令不单独计费
+		{`{ me: user { ...UserFields friends @include(if: $all) { ... on User { id } } } }`, 3},
+		{`# comment { x }
+		{ a }`, 1},
+		{``, 1},
+	}
+	for _, tt := range tests {
+		cost, err := CountGraphQLFields(tt.query, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, cost, tt.query)
+	}
+}
+
+func TestGraphQLCost(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+		MaxTokens:          5,
+		RefillRate:         5,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		Cost:               GraphQLCost(1024, CountGraphQLFields),
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.POST("/graphql", func(c *gin.Context) {
+		c.String(http.StatusOK, "{}")
+	})
+
+	// 按查询复杂度扣减点数
+	for _, tt := range []struct {
+		query string
+		want  int
+	}{
+		{`{ user { id name } }`, http.StatusOK},
+		{`{ user { id name } }`, http.StatusTooManyRequests},
+		{`{ viewer { id } }`, http.StatusOK},
+	} {
+		req, _ := http.NewRequest("POST", "/graphql", strings.NewReader(`{"query":"`+tt.query+`"}`))
+		req.RemoteAddr = "192.168.1.1:1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tt.want, w.Code, tt.query)
+	}
+
+	// 开销超过桶容量的查询永远无法满足，直接拒绝且不提示重试
+	req, _ := http.NewRequest("POST", "/graphql", strings.NewReader(`{"query":"{ a b c d e f }"}`))
+	req.RemoteAddr = "192.168.1.2:1234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	// 分析出错时按 1 计费
+	c := newKeyContext(func(req *http.Request) {})
+	c.Request, _ = http.NewRequest("POST", "/graphql", strings.NewReader(`{"query":"{ a }"}`))
+	cost := GraphQLCost(1024, func(string, map[string]any) (int, error) { return 0, errors.New("too deep") })(c)
+	assert.Equal(t, 1, cost)
+	assert.Len(t, c.Errors, 1)
+}
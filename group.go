@@ -0,0 +1,23 @@
+package limiter
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ForGroup creates a limiter from config and attaches it to every route of
+// rg. If config has a Store but no Namespace, the group's base path is
+// used, so groups sharing a Store keep separate budgets. The limiter is
+// returned for cleanup and inspection.
+func ForGroup(rg *gin.RouterGroup, config RateLimitConfig) (*RateLimiter, error) {
+	if config.Store != nil && config.Namespace == "" {
+		config.Namespace = strings.ReplaceAll(rg.BasePath(), ":", "_")
+	}
+	limiter, err := New(config)
+	if err != nil {
+		return nil, err
+	}
+	rg.Use(limiter.RateLimitMiddleware())
+	return limiter, nil
+}
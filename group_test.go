@@ -0,0 +1,47 @@
+package limiter
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForGroup(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	store := newMapStore()
+	apiConfig, adminConfig := perMinute(2), perMinute(1)
+	apiConfig.Store, adminConfig.Store = store, store
+
+	router := gin.New()
+	handler := func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	}
+	api := router.Group("/api")
+	_, err := ForGroup(api, apiConfig)
+	assert.NoError(t, err)
+	api.GET("/items", handler)
+	admin := router.Group("/admin")
+	adminLimiter, err := ForGroup(admin, adminConfig)
+	assert.NoError(t, err)
+	admin.GET("/stats", handler)
+	router.GET("/health", handler)
+
+	// 每个分组使用各自的限流器，共享 Store 时按基础路径区分命名空间
+	assert.Equal(t, http.StatusOK, serve(router, "GET", "/admin/stats", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(router, "GET", "/admin/stats", nil).Code)
+	assert.Equal(t, http.StatusOK, serve(router, "GET", "/api/items", nil).Code)
+	assert.Equal(t, http.StatusOK, serve(router, "GET", "/api/items", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(router, "GET", "/api/items", nil).Code)
+	assert.Equal(t, "/admin", adminLimiter.Config().Namespace)
+
+	// 分组之外的路由不受影响
+	assert.Equal(t, http.StatusOK, serve(router, "GET", "/health", nil).Code)
+
+	// 无效的配置返回错误
+	_, err = ForGroup(router.Group("/bad"), RateLimitConfig{})
+	assert.Error(t, err)
+}
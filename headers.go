@@ -0,0 +1,126 @@
+package limiter
+
+import (
+	"context"
+	"encoding/binary"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// XRateLimitHeaders sends the de facto standard X-RateLimit-Limit,
+	// X-RateLimit-Remaining and X-RateLimit-Reset headers.
+	XRateLimitHeaders = "x-ratelimit"
+	// IETFHeaders sends RateLimit-Policy and RateLimit as described by the
+	// IETF httpapi-ratelimit-headers draft.
+	IETFHeaders = "ietf"
+)
+
+// Quoter is implemented by algorithms that can report how much of the
+// limit of a key is left and how long until it is fully restored, which
+// the limiter sends as rate limit headers.
+type Quoter interface {
+	Quota(key string, now time.Time) (limit, remaining int, reset time.Duration)
+}
+
+// usage is the state of the main limit of a key.
+type usage struct {
+	limit     int
+	remaining int
+	reset     time.Duration
+}
+
+// quota reports the usage of key, or false if the algorithm cannot tell.
+// With a Store that is not cached locally it costs one extra read, which
+// takeKey spares the middleware by reporting the usage itself.
+func (rl *RateLimiter) quota(ctx context.Context, key string, now time.Time) (u usage, ok bool) {
+	if rl.config.Store != nil && rl.config.SyncInterval == 0 {
+		value, _, err := rl.config.Store.Get(ctx, key)
+		if err != nil {
+			return u, false
+		}
+		if g, isGCRA := rl.algorithm.(*gcra); isGCRA {
+			var tat time.Time
+			if len(value) == 8 {
+				tat = time.Unix(0, int64(binary.BigEndian.Uint64(value)))
+			}
+			u.limit, u.remaining, u.reset = g.quota(tat, now)
+			return u, true
+		}
+		bucket := rl.newBucket(key, now)
+		if value != nil {
+			bucket.decode(value)
+		}
+		u.limit, u.remaining, u.reset = bucket.quota(now)
+		return u, true
+	}
+
+	if rl.algorithm != nil {
+		quoter, isQuoter := rl.algorithm.(Quoter)
+		if !isQuoter {
+			return u, false
+		}
+		u.limit, u.remaining, u.reset = quoter.Quota(key, now)
+		return u, true
+	}
+
+	bucket := rl.getBucket(key)
+	bucket.mutex.Lock()
+	defer bucket.mutex.Unlock()
+	u.limit, u.remaining, u.reset = bucket.quota(now)
+	return u, true
+}
+
+// quota returns the capacity of the bucket, the tokens left after a refill
+// and how long until the bucket is full again.
+func (b *tokenBucket) quota(now time.Time) (limit, remaining int, reset time.Duration) {
+	b.refill(now)
+	return b.maxTokens, maxInt(b.tokens, 0), b.retryAfter(b.maxTokens, now)
+}
+
+func (rl *RateLimiter) setHeaders(c *gin.Context, u usage, now time.Time) {
+	switch rl.config.HeaderFormat {
+	case IETFHeaders:
+		window := strconv.Itoa(ceilDiv(rl.policyWindow(u.limit), time.Second))
+		c.Header("RateLimit-Policy", `"default";q=`+strconv.Itoa(u.limit)+";w="+window)
+		c.Header("RateLimit", `"default";r=`+strconv.Itoa(u.remaining)+";t="+strconv.Itoa(ceilDiv(u.reset, time.Second)))
+	default:
+		c.Header("X-RateLimit-Limit", strconv.Itoa(u.limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(u.remaining))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(now.Add(u.reset).Unix(), 10))
+	}
+}
+
+// setRetryAfter sends retryAfter in whole seconds, rounded up so that a
+// client retrying on time finds a token, or as an HTTP-date if configured.
+func (rl *RateLimiter) setRetryAfter(c *gin.Context, retryAfter time.Duration) {
+	seconds := maxInt(ceilDiv(retryAfter, time.Second), 1)
+	if rl.config.RetryAfterDate {
+		c.Header("Retry-After", time.Now().Add(time.Duration(seconds)*time.Second).UTC().Format(http.TimeFormat))
+		return
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+}
+
+// policyWindow is the time it takes to restore a quota of limit from
+// empty, which the IETF headers advertise as the policy window.
+func (rl *RateLimiter) policyWindow(limit int) time.Duration {
+	l := rl.limits()
+	switch rl.config.Algorithm {
+	case SlidingWindowCounter, SlidingWindowLog, FixedWindow:
+		return l.refillInterval
+	}
+	refills := (limit + l.refillRate - 1) / l.refillRate
+	return time.Duration(refills) * l.refillInterval
+}
+
+// ceilDiv returns how many whole intervals d spans, rounded up.
+func ceilDiv(d, interval time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int((d + interval - 1) / interval)
+}
@@ -0,0 +1,221 @@
+package limiter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitHeaders(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+		MaxTokens:          2,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+
+	for _, want := range []struct {
+		code      int
+		remaining string
+	}{
+		{http.StatusOK, "1"},
+		{http.StatusOK, "0"},
+		{http.StatusTooManyRequests, "0"},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, want.code, w.Code)
+		assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, want.remaining, w.Header().Get("X-RateLimit-Remaining"))
+
+		// 重置时间是令牌桶重新填满的 Unix 时间戳
+		reset, err := strconv.ParseInt(w.Header().Get("X-RateLimit-Reset"), 10, 64)
+		assert.NoError(t, err)
+		assert.Greater(t, reset, time.Now().Unix())
+	}
+}
+
+func TestRateLimitHeadersDisabled(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		DisableHeaders:     true,
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Empty(t, w.Header().Get("X-RateLimit-Limit"))
+}
+
+func TestAlgorithmQuota(t *testing.T) {
+	now := time.Now().Truncate(time.Minute)
+	config := RateLimitConfig{
+		MaxTokens:          4,
+		RefillRate:         4,
+		RefillInterval:     time.Minute,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+	}
+
+	for _, name := range []string{SlidingWindowCounter, SlidingWindowLog, LeakyBucket, GCRA, FixedWindow} {
+		config.Algorithm = name
+		algorithm := newAlgorithm(config)
+		quoter := algorithm.(Quoter)
+
+		limit, remaining, reset := quoter.Quota("a", now)
+		assert.Equal(t, 4, limit, name)
+		assert.Equal(t, 4, remaining, name)
+		assert.Equal(t, time.Duration(0), reset, name)
+
+		// 消耗一个令牌后剩余数量减少
+		allowed, _ := algorithm.Take("a", 1, now)
+		assert.True(t, allowed, name)
+		_, remaining, reset = quoter.Quota("a", now)
+		assert.Equal(t, 3, remaining, name)
+		assert.Greater(t, reset, time.Duration(0), name)
+	}
+}
+
+func TestIETFRateLimitHeaders(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+		MaxTokens:          10,
+		RefillRate:         5,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		HeaderFormat:       IETFHeaders,
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// 10 个令牌每分钟补充 5 个，需要两分钟才能填满
+	assert.Equal(t, `"default";q=10;w=120`, w.Header().Get("RateLimit-Policy"))
+	assert.Equal(t, `"default";r=9;t=60`, w.Header().Get("RateLimit"))
+	assert.Empty(t, w.Header().Get("X-RateLimit-Limit"))
+}
+
+func TestRetryAfterHeader(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	for _, date := range []bool{false, true} {
+		limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+			MaxTokens:          1,
+			RefillRate:         1,
+			RefillInterval:     time.Second * 30,
+			KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+			BurstMultiplier:    1,
+			ExpirationDuration: time.Minute * 5,
+			RetryAfterDate:     date,
+		})
+		assert.NoError(t, err)
+
+		router := gin.New()
+		router.Use(limiterMiddleware)
+		router.GET("/", func(c *gin.Context) {
+			c.String(http.StatusOK, "Hello, world!")
+		})
+
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Empty(t, w.Header().Get("Retry-After"))
+
+		// 被拒绝时返回距离下一个令牌可用的时间
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		if date {
+			retryAt, err := http.ParseTime(w.Header().Get("Retry-After"))
+			assert.NoError(t, err)
+			assert.WithinDuration(t, time.Now().Add(time.Second*30), retryAt, time.Second*2)
+		} else {
+			assert.Equal(t, "30", w.Header().Get("Retry-After"))
+		}
+	}
+}
+
+func TestRetryAfterAlgorithm(t *testing.T) {
+	limiter, err := New(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Algorithm:          GCRA,
+		GlobalLimit:        &Limit{MaxTokens: 10, RefillRate: 1, RefillInterval: time.Second},
+	})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	allowed, _, err := limiter.take(ctx, "a", 1, true)
+	assert.True(t, allowed)
+	assert.NoError(t, err)
+
+	// 由算法拒绝时使用算法给出的等待时间
+	allowed, r, _ := limiter.take(ctx, "a", 1, true)
+	assert.False(t, allowed)
+	assert.Equal(t, "", r.rule)
+	assert.InDelta(t, float64(time.Minute), float64(r.retryAfter), float64(time.Second))
+
+	// 由全局限额拒绝时使用全局令牌桶的等待时间
+	for i := 0; i < 9; i++ {
+		limiter.take(ctx, string(rune('b'+i)), 1, true)
+	}
+	allowed, r, _ = limiter.take(ctx, "z", 1, true)
+	assert.False(t, allowed)
+	assert.Equal(t, GlobalRule, r.rule)
+	assert.InDelta(t, float64(time.Second), float64(r.retryAfter), float64(time.Second))
+}
@@ -0,0 +1,30 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+// perMinute 返回每分钟补满 maxTokens 个令牌的配置
+func perMinute(maxTokens int) RateLimitConfig {
+	return RateLimitConfig{
+		MaxTokens:          maxTokens,
+		RefillRate:         maxTokens,
+		RefillInterval:     time.Minute,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+	}
+}
+
+// serve 以客户端 192.168.1.1 的身份发送请求，header 可以为 nil
+func serve(router http.Handler, method, path string, header http.Header) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
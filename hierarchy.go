@@ -0,0 +1,177 @@
+package limiter
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Scope is one level of a hierarchical limit such as global, tenant, user
+// or IP. Buckets of a scope are keyed by the keys of all its ancestors, so
+// user "42" of tenant "a" and of tenant "b" are limited separately. A nil
+// KeyFunc makes the scope a single shared bucket; an empty key skips the
+// scope for that request. Scopes without a Parent are independent
+// dimensions, such as per-IP and per-endpoint limits enforced together.
+type Scope struct {
+	Name    string
+	Parent  string
+	KeyFunc func(*gin.Context) string
+	Limit
+}
+
+type hierarchy struct {
+	// scopes are ordered so that every parent precedes its children.
+	scopes  []Scope
+	idle    time.Duration
+	buckets map[string]*tokenBucket
+	mutex   sync.Mutex
+}
+
+func validateScopes(scopes []Scope) error {
+	names := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		if scope.Name == "" {
+			return errors.New("Scopes must have a Name")
+		}
+		if names[scope.Name] {
+			return errors.New("Scopes[" + scope.Name + "] is defined twice")
+		}
+		names[scope.Name] = true
+		if err := scope.Validate(); err != nil {
+			return errors.New("Scopes[" + scope.Name + "]." + err.Error())
+		}
+	}
+	for _, scope := range scopes {
+		if scope.Parent != "" && !names[scope.Parent] {
+			return errors.New("Scopes[" + scope.Name + "] has unknown Parent " + scope.Parent)
+		}
+	}
+	if len(orderScopes(scopes)) != len(scopes) {
+		return errors.New("Scopes must not contain cycles")
+	}
+	return nil
+}
+
+// orderScopes sorts scopes parents first; scopes on a cycle are left out.
+func orderScopes(scopes []Scope) []Scope {
+	ordered := make([]Scope, 0, len(scopes))
+	placed := make(map[string]bool, len(scopes))
+	for len(ordered) < len(scopes) {
+		progress := false
+		for _, scope := range scopes {
+			if !placed[scope.Name] && (scope.Parent == "" || placed[scope.Parent]) {
+				ordered = append(ordered, scope)
+				placed[scope.Name] = true
+				progress = true
+			}
+		}
+		if !progress {
+			break
+		}
+	}
+	return ordered
+}
+
+func newHierarchy(scopes []Scope) *hierarchy {
+	h := &hierarchy{
+		scopes:  orderScopes(scopes),
+		buckets: make(map[string]*tokenBucket),
+	}
+	for _, scope := range scopes {
+		refills := (scope.MaxTokens + scope.RefillRate - 1) / scope.RefillRate
+		if idle := time.Duration(refills) * scope.RefillInterval; idle > h.idle {
+			h.idle = idle
+		}
+	}
+	return h
+}
+
+// keys returns the bucket key of every scope that applies to c, parents
+// first, in the same order as the scopes. Each level adds the scope name
+// and key with their lengths in front, so keys containing "/" or ":"
+// cannot make two buckets share a key.
+func (h *hierarchy) keys(c *gin.Context) []string {
+	paths := make(map[string]string, len(h.scopes))
+	keys := make([]string, len(h.scopes))
+	for i, scope := range h.scopes {
+		key := "*"
+		if scope.KeyFunc != nil {
+			key = scope.KeyFunc(c)
+		}
+		path := paths[scope.Parent]
+		if key != "" {
+			path += "/" + strconv.Itoa(len(scope.Name)) + ":" + scope.Name + strconv.Itoa(len(key)) + ":" + key
+			keys[i] = path
+		}
+		paths[scope.Name] = path
+	}
+	return keys
+}
+
+func (h *hierarchy) bucket(i int, key string, now time.Time) *tokenBucket {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	bucket, exists := h.buckets[key]
+	if !exists {
+		bucket = newLimitBucket(h.scopes[i].Limit, now)
+		h.buckets[key] = bucket
+	}
+	return bucket
+}
+
+// take charges n tokens to every applicable scope from the root down and
+// rolls back the scopes already charged as soon as one of them denies.
+func (h *hierarchy) take(keys []string, n int, now time.Time) bool {
+	for i, key := range keys {
+		if key == "" {
+			continue
+		}
+		if !h.bucket(i, key, now).take(n, now) {
+			h.refund(keys[:i], n)
+			return false
+		}
+	}
+	return true
+}
+
+// reject reports the scope that takes longest to admit n tokens.
+func (h *hierarchy) reject(keys []string, n int, now time.Time) rejection {
+	var r rejection
+	for i, key := range keys {
+		if key == "" {
+			continue
+		}
+		if candidate := h.bucket(i, key, now).reject(h.scopes[i].Name, n, now); candidate.retryAfter >= r.retryAfter {
+			r = candidate
+		}
+	}
+	return r
+}
+
+func (h *hierarchy) refund(keys []string, n int) {
+	for i, key := range keys {
+		if key != "" {
+			h.bucket(i, key, time.Now()).refund(n)
+		}
+	}
+}
+
+func (h *hierarchy) cleanup(now time.Time, expiration time.Duration) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.idle > expiration {
+		expiration = h.idle
+	}
+	for key, bucket := range h.buckets {
+		bucket.mutex.Lock()
+		if now.Sub(bucket.lastRefill) > expiration {
+			delete(h.buckets, key)
+		}
+		bucket.mutex.Unlock()
+	}
+}
@@ -0,0 +1,174 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateScopes(t *testing.T) {
+	limit := Limit{MaxTokens: 1, RefillRate: 1, RefillInterval: time.Second}
+
+	assert.NoError(t, validateScopes([]Scope{
+		{Name: "user", Parent: "tenant", Limit: limit},
+		{Name: "tenant", Limit: limit},
+	}))
+	assert.Error(t, validateScopes([]Scope{
+		{Name: "user", Parent: "missing", Limit: limit},
+	}))
+	assert.Error(t, validateScopes([]Scope{
+		{Name: "a", Parent: "b", Limit: limit},
+		{Name: "b", Parent: "a", Limit: limit},
+	}))
+}
+
+func TestRateLimiterHierarchicalScopes(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	config := RateLimitConfig{
+		MaxTokens:          100,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Scopes: []Scope{
+			{
+				Name:    "tenant",
+				KeyFunc: func(c *gin.Context) string { return c.GetHeader("X-Tenant") },
+				Limit:   Limit{MaxTokens: 3, RefillRate: 1, RefillInterval: time.Minute},
+			},
+			{
+				Name:    "user",
+				Parent:  "tenant",
+				KeyFunc: func(c *gin.Context) string { return c.GetHeader("X-User") },
+				Limit:   Limit{MaxTokens: 2, RefillRate: 1, RefillInterval: time.Minute},
+			},
+		},
+	}
+
+	limiterMiddleware, err := NewRateLimiter(config)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func(tenant, user string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		req.Header.Set("X-Tenant", tenant)
+		req.Header.Set("X-User", user)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 单个用户无法耗尽租户额度
+	assert.Equal(t, http.StatusOK, request("acme", "alice"))
+	assert.Equal(t, http.StatusOK, request("acme", "alice"))
+	assert.Equal(t, http.StatusTooManyRequests, request("acme", "alice"))
+
+	// 同租户的其他用户使用剩余的租户额度
+	assert.Equal(t, http.StatusOK, request("acme", "bob"))
+	assert.Equal(t, http.StatusTooManyRequests, request("acme", "bob"))
+
+	// 另一个租户下的同名用户拥有独立的令牌桶
+	assert.Equal(t, http.StatusOK, request("globex", "alice"))
+}
+
+func TestRateLimiterIndependentDimensions(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	config := RateLimitConfig{
+		MaxTokens:          100,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Scopes: []Scope{
+			{Name: "ip", KeyFunc: ByClientIP(), Limit: Limit{MaxTokens: 3, RefillRate: 1, RefillInterval: time.Minute}},
+			{Name: "user", KeyFunc: ByHeader("X-User"), Limit: Limit{MaxTokens: 2, RefillRate: 1, RefillInterval: time.Hour}},
+			{Name: "endpoint", KeyFunc: ByRoute(), Limit: Limit{MaxTokens: 4, RefillRate: 1, RefillInterval: time.Minute}},
+		},
+	}
+
+	limiterMiddleware, err := NewRateLimiter(config)
+	assert.NoError(t, err)
+
+	var rule string
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Next()
+		info, _ := FromContext(c)
+		rule = info.Rule
+	})
+	router.Use(limiterMiddleware)
+	router.GET("/a", func(c *gin.Context) {
+		c.String(http.StatusOK, "a")
+	})
+	router.GET("/b", func(c *gin.Context) {
+		c.String(http.StatusOK, "b")
+	})
+
+	request := func(path, ip, user string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		req.RemoteAddr = ip + ":1234"
+		req.Header.Set("X-User", user)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 用户维度先耗尽，拒绝时报告恢复最慢的维度
+	assert.Equal(t, http.StatusOK, request("/a", "192.168.1.1", "alice"))
+	assert.Equal(t, http.StatusOK, request("/a", "192.168.1.2", "alice"))
+	assert.Equal(t, http.StatusTooManyRequests, request("/a", "192.168.1.3", "alice"))
+	assert.Equal(t, "user", rule)
+
+	// IP 维度独立于用户计数，被拒绝的请求不消耗其他维度
+	assert.Equal(t, http.StatusOK, request("/a", "192.168.1.1", "bob"))
+	assert.Equal(t, http.StatusOK, request("/b", "192.168.1.1", "carol"))
+	assert.Equal(t, http.StatusTooManyRequests, request("/b", "192.168.1.1", "dave"))
+	assert.Equal(t, "ip", rule)
+
+	// 接口维度由所有客户端共享
+	assert.Equal(t, http.StatusOK, request("/a", "192.168.1.4", "erin"))
+	assert.Equal(t, http.StatusTooManyRequests, request("/a", "192.168.1.5", "frank"))
+	assert.Equal(t, "endpoint", rule)
+}
+
+func TestHierarchyKeysDoNotCollide(t *testing.T) {
+	h := newHierarchy([]Scope{
+		{Name: "tenant", KeyFunc: ByHeader("X-Tenant"), Limit: Limit{MaxTokens: 1, RefillRate: 1, RefillInterval: time.Minute}},
+		{Name: "user", Parent: "tenant", KeyFunc: ByHeader("X-User"), Limit: Limit{MaxTokens: 1, RefillRate: 1, RefillInterval: time.Minute}},
+		{Name: "a:b", KeyFunc: ByHeader("X-A"), Limit: Limit{MaxTokens: 1, RefillRate: 1, RefillInterval: time.Minute}},
+		{Name: "a", KeyFunc: ByHeader("X-B"), Limit: Limit{MaxTokens: 1, RefillRate: 1, RefillInterval: time.Minute}},
+	})
+	keys := func(headers map[string]string) []string {
+		c := newKeyContext(func(req *http.Request) {
+			for name, value := range headers {
+				req.Header.Set(name, value)
+			}
+		})
+		return h.keys(c)
+	}
+
+	// 含有 "/" 和 ":" 的键不会与其他作用域的令牌桶混淆
+	forged := keys(map[string]string{"X-Tenant": "acme/user:alice"})
+	genuine := keys(map[string]string{"X-Tenant": "acme", "X-User": "alice"})
+	assert.NotEqual(t, forged[0], genuine[1])
+
+	forged = keys(map[string]string{"X-B": "b:c"})
+	genuine = keys(map[string]string{"X-A": "c"})
+	assert.NotEqual(t, forged[3], genuine[2])
+}
@@ -0,0 +1,71 @@
+package limiter
+
+import (
+	"context"
+	"time"
+)
+
+// takeHybrid admits requests against a local copy of the bucket and only
+// talks to the store once per SyncInterval. Tokens spent locally in the
+// meantime are pushed to the store on the next sync, so instances may
+// briefly over-admit in exchange for far fewer round trips.
+func (rl *RateLimiter) takeHybrid(ctx context.Context, key string, n int) (bool, time.Duration, error) {
+	bucket := rl.getBucket(key)
+
+	bucket.mutex.Lock()
+	defer bucket.mutex.Unlock()
+
+	now := time.Now()
+	if now.Sub(bucket.syncedAt) >= rl.config.SyncInterval {
+		// A failed sync keeps the local state; we retry on the next interval.
+		if err := rl.syncBucket(ctx, key, bucket, now); err != nil {
+			bucket.syncedAt = now
+		}
+	}
+
+	rl.configure(bucket, key)
+	bucket.refill(now)
+
+	if bucket.tokens >= n {
+		bucket.tokens -= n
+		bucket.pending += n
+		return true, 0, nil
+	}
+	return false, bucket.retryAfter(n, now), nil
+}
+
+func (rl *RateLimiter) syncBucket(ctx context.Context, key string, local *tokenBucket, now time.Time) error {
+	for attempt := 0; attempt < maxStoreAttempts; attempt++ {
+		value, revision, err := rl.config.Store.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		remote := rl.newBucket(key, now)
+		if value != nil {
+			remote.decode(value)
+		}
+		remote.refill(now)
+		remote.tokens = maxInt(remote.tokens-local.pending, 0)
+
+		ok, err := rl.config.Store.CompareAndSwap(ctx, key, revision, remote.encode(), rl.config.ExpirationDuration)
+		if err != nil {
+			return err
+		}
+		if ok {
+			local.tokens = remote.tokens
+			local.lastRefill = remote.lastRefill
+			local.pending = 0
+			local.syncedAt = now
+			return nil
+		}
+	}
+	return ErrStoreContention
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
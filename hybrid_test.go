@@ -0,0 +1,48 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTakeHybrid_ReconcilesWithStore(t *testing.T) {
+	store := newMapStore()
+	config := RateLimitConfig{
+		MaxTokens:          3,
+		RefillRate:         1,
+		RefillInterval:     time.Hour, // 测试期间不补充令牌
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Hour * 2,
+		Store:              store,
+		SyncInterval:       time.Millisecond * 50,
+	}
+
+	first := &RateLimiter{buckets: make(map[string]*tokenBucket), config: config}
+	second := &RateLimiter{buckets: make(map[string]*tokenBucket), config: config}
+	ctx := context.Background()
+
+	// 两个实例各自在本地消耗令牌，同步前允许轻微超发
+	allowed, _, err := first.takeHybrid(ctx, "key", 1)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+	for i := 0; i < 3; i++ {
+		allowed, _, err = second.takeHybrid(ctx, "key", 1)
+		assert.NoError(t, err)
+		assert.True(t, allowed)
+	}
+
+	// 等待同步周期，两个实例的消耗都会写回存储
+	time.Sleep(time.Millisecond * 60)
+	allowed, _, _ = first.takeHybrid(ctx, "key", 1)
+	assert.True(t, allowed)
+	allowed, _, _ = second.takeHybrid(ctx, "key", 1)
+	assert.False(t, allowed)
+
+	remote := &tokenBucket{}
+	value, _, _ := store.Get(ctx, "key")
+	remote.decode(value)
+	assert.Equal(t, 0, remote.tokens)
+}
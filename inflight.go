@@ -0,0 +1,89 @@
+package limiter
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InFlightRule is the Rule reported in LimitInfo when DryRun admits a request
+// that MaxInFlight would have rejected.
+const InFlightRule = "in_flight"
+
+type keySlots struct {
+	slots chan struct{}
+	users int
+}
+
+// inFlight caps the number of requests per key that are being served at
+// the same time. Entries are reference counted by holders and waiters and
+// removed as soon as the last of them is gone.
+type inFlight struct {
+	max   int
+	keys  map[string]*keySlots
+	mutex sync.Mutex
+}
+
+func newInFlight(max int) *inFlight {
+	return &inFlight{
+		max:  max,
+		keys: make(map[string]*keySlots),
+	}
+}
+
+func (f *inFlight) acquire(ctx context.Context, key string, wait time.Duration) bool {
+	f.mutex.Lock()
+	k, exists := f.keys[key]
+	if !exists {
+		k = &keySlots{slots: make(chan struct{}, f.max)}
+		f.keys[key] = k
+	}
+	k.users++
+	f.mutex.Unlock()
+
+	select {
+	case k.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case k.slots <- struct{}{}:
+			return true
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+
+	f.leave(key, k)
+	return false
+}
+
+func (f *inFlight) release(key string) {
+	f.mutex.Lock()
+	k := f.keys[key]
+	f.mutex.Unlock()
+
+	<-k.slots
+	f.leave(key, k)
+}
+
+func (f *inFlight) leave(key string, k *keySlots) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	k.users--
+	if k.users == 0 {
+		delete(f.keys, key)
+	}
+}
+
+func defaultInFlightExceededHandler(c *gin.Context) {
+	c.AbortWithStatus(http.StatusServiceUnavailable)
+}
@@ -0,0 +1,83 @@
+package limiter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInFlight_AcquireRelease(t *testing.T) {
+	f := newInFlight(1)
+	ctx := context.Background()
+
+	assert.True(t, f.acquire(ctx, "key", 0))
+	assert.False(t, f.acquire(ctx, "key", 0))
+	// 其他键不受影响
+	assert.True(t, f.acquire(ctx, "other", 0))
+
+	// 等待期间释放槽位后可以获取
+	go func() {
+		time.Sleep(time.Millisecond * 20)
+		f.release("key")
+	}()
+	assert.True(t, f.acquire(ctx, "key", time.Second))
+
+	f.release("key")
+	f.release("other")
+	assert.Empty(t, f.keys)
+}
+
+func TestRateLimiterMaxInFlight(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	config := RateLimitConfig{
+		MaxTokens:          100,
+		RefillRate:         1,
+		RefillInterval:     time.Second,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		MaxInFlight:        1,
+	}
+
+	limiterMiddleware, err := NewRateLimiter(config)
+	assert.NoError(t, err)
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		close(started)
+		<-finish
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	first := httptest.NewRecorder()
+	go func() {
+		defer wg.Done()
+		router.ServeHTTP(first, req)
+	}()
+	<-started
+
+	// 同一个键已有请求在处理中，第二个请求返回 503
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	close(finish)
+	wg.Wait()
+	assert.Equal(t, http.StatusOK, first.Code)
+}
@@ -0,0 +1,79 @@
+package limiter
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GlobalRule is the Rule reported in LimitInfo when GlobalLimit denied a
+// request.
+const GlobalRule = "global"
+
+// LimitInfo describes the limit evaluated for a request. Rule is the name
+// of the Rule or Scope that denied it, GlobalRule for GlobalLimit, or
+// empty for the main per-key limit. Limit, Remaining and Reset refer to
+// that same limit and are zero if its algorithm does not report them.
+type LimitInfo struct {
+	Key        string
+	Rule       string
+	Limit      int
+	Remaining  int
+	Reset      time.Time
+	RetryAfter time.Duration
+}
+
+// infoKey is the gin.Context key of the LimitInfo returned by FromContext.
+const infoKey = "limiter.info"
+
+// FromContext returns the state of the limit evaluated for the current
+// request, for handlers and logging middleware running after the limiter.
+// For admitted requests Rule and RetryAfter are empty.
+func FromContext(c *gin.Context) (LimitInfo, bool) {
+	if value, ok := c.Get(infoKey); ok {
+		return value.(LimitInfo), true
+	}
+	return LimitInfo{}, false
+}
+
+// LimitExceededFunc is like LimitExceededHandler but also receives the
+// details of the rejection.
+type LimitExceededFunc func(c *gin.Context, info LimitInfo)
+
+// rejection is what the limiter knows about the stage that denied a
+// request. usage is filled in for named stages, and for the main limit
+// only if quoted, as a Store reports it while taking; otherwise the main
+// limit is looked up separately since not every algorithm can report it.
+// status and handler are set by rules with their own rejection behavior.
+type rejection struct {
+	rule       string
+	usage      usage
+	quoted     bool
+	retryAfter time.Duration
+	status     int
+	handler    gin.HandlerFunc
+}
+
+func limitInfo(key string, r rejection, u usage, now time.Time) LimitInfo {
+	info := LimitInfo{
+		Key:        key,
+		Rule:       r.rule,
+		Limit:      u.limit,
+		Remaining:  u.remaining,
+		RetryAfter: r.retryAfter,
+	}
+	// Every limit that reports its usage has room for at least one token.
+	if u.limit > 0 {
+		info.Reset = now.Add(u.reset)
+	}
+	return info
+}
+
+// rejection describes the bucket as a denying stage named rule. The caller
+// must hold the bucket's lock.
+func (b *tokenBucket) rejection(rule string, n int, now time.Time) rejection {
+	r := rejection{rule: rule}
+	r.usage.limit, r.usage.remaining, r.usage.reset = b.quota(now)
+	r.retryAfter = b.retryAfter(n, now)
+	return r
+}
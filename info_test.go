@@ -0,0 +1,124 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitExceededFunc(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	serve := func(config RateLimitConfig, requests int) []LimitInfo {
+		var infos []LimitInfo
+		config.LimitExceededFunc = func(c *gin.Context, info LimitInfo) {
+			infos = append(infos, info)
+			c.AbortWithStatus(http.StatusTooManyRequests)
+		}
+		limiterMiddleware, err := NewRateLimiter(config)
+		assert.NoError(t, err)
+
+		router := gin.New()
+		router.Use(limiterMiddleware)
+		router.GET("/", func(c *gin.Context) {
+			c.String(http.StatusOK, "Hello, world!")
+		})
+		for i := 0; i < requests; i++ {
+			req, _ := http.NewRequest("GET", "/", nil)
+			req.RemoteAddr = "192.168.1.1:1234"
+			router.ServeHTTP(httptest.NewRecorder(), req)
+		}
+		return infos
+	}
+
+	config := RateLimitConfig{
+		MaxTokens:          2,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+	}
+
+	// 主限额拒绝时 Rule 为空
+	infos := serve(config, 3)
+	assert.Len(t, infos, 1)
+	assert.Equal(t, "192.168.1.1", infos[0].Key)
+	assert.Equal(t, "", infos[0].Rule)
+	assert.Equal(t, 2, infos[0].Limit)
+	assert.Equal(t, 0, infos[0].Remaining)
+	assert.InDelta(t, float64(time.Minute), float64(infos[0].RetryAfter), float64(time.Second))
+	assert.WithinDuration(t, time.Now().Add(time.Minute*2), infos[0].Reset, time.Second)
+
+	// 规则拒绝时报告规则的名称和状态
+	config.Rules = []Rule{
+		{Name: "hourly", Limit: Limit{MaxTokens: 1, RefillRate: 1, RefillInterval: time.Hour}},
+	}
+	infos = serve(config, 2)
+	assert.Len(t, infos, 1)
+	assert.Equal(t, "hourly", infos[0].Rule)
+	assert.Equal(t, 1, infos[0].Limit)
+	assert.Equal(t, 0, infos[0].Remaining)
+	assert.InDelta(t, float64(time.Hour), float64(infos[0].RetryAfter), float64(time.Second))
+}
+
+func TestLimitExceededHandlerConflict(t *testing.T) {
+	_, err := NewRateLimiter(RateLimitConfig{
+		MaxTokens:            1,
+		RefillRate:           1,
+		RefillInterval:       time.Minute,
+		BurstMultiplier:      1,
+		ExpirationDuration:   time.Minute * 5,
+		LimitExceededHandler: defaultLimitExceededHandler,
+		LimitExceededFunc:    func(c *gin.Context, info LimitInfo) {},
+	})
+	assert.Error(t, err)
+}
+
+func TestFromContext(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+		MaxTokens:          3,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		DisableHeaders:     true,
+	})
+	assert.NoError(t, err)
+
+	var info LimitInfo
+	var ok bool
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		info, ok = FromContext(c)
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	// 下游处理函数可以直接读取限流状态
+	assert.True(t, ok)
+	assert.Equal(t, "192.168.1.1", info.Key)
+	assert.Equal(t, 3, info.Limit)
+	assert.Equal(t, 2, info.Remaining)
+	assert.True(t, info.Reset.After(time.Now()))
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	_, ok = FromContext(c)
+	assert.False(t, ok)
+
+	// 算法不报告用量时 Reset 为零值
+	assert.True(t, limitInfo("192.168.1.1", rejection{}, usage{}, time.Now()).Reset.IsZero())
+}
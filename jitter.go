@@ -0,0 +1,16 @@
+package limiter
+
+import (
+	"math/rand"
+	"time"
+)
+
+// jitter returns a random delay below RetryAfterJitter. It is added to the
+// advertised reset and retry times so that throttled clients spread their
+// retries instead of all coming back at the same instant.
+func (rl *RateLimiter) jitter() time.Duration {
+	if rl.config.RetryAfterJitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(rl.config.RetryAfterJitter)))
+}
@@ -0,0 +1,143 @@
+package limiter
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// JWKS is a JWTKeySet that loads RSA and EC keys from a JSON Web Key Set
+// URL, such as the jwks_uri of an OpenID Connect provider. Keys are cached
+// and fetched again in the background once they are older than refresh,
+// while the previous keys stay in use; they also stay in use if a refresh
+// fails. Only the first load makes callers wait.
+type JWKS struct {
+	url       string
+	refresh   time.Duration
+	client    *http.Client
+	keys      map[string]any
+	err       error
+	fetchedAt time.Time
+	fetching  chan struct{}
+	mutex     sync.Mutex
+}
+
+// NewJWKS panics if refresh is not positive.
+func NewJWKS(url string, refresh time.Duration) *JWKS {
+	if refresh <= 0 {
+		panic("limiter: NewJWKS refresh must be positive")
+	}
+	return &JWKS{
+		url:     url,
+		refresh: refresh,
+		client:  &http.Client{Timeout: time.Second * 10},
+	}
+}
+
+func (j *JWKS) Key(kid string) (any, error) {
+	j.mutex.Lock()
+	if j.keys == nil || time.Since(j.fetchedAt) >= j.refresh {
+		if j.fetching == nil {
+			j.fetching = make(chan struct{})
+			go j.update(j.fetching)
+		}
+		if j.keys == nil {
+			fetching := j.fetching
+			j.mutex.Unlock()
+			<-fetching
+			j.mutex.Lock()
+		}
+	}
+	keys, err := j.keys, j.err
+	j.mutex.Unlock()
+
+	if keys == nil {
+		return nil, err
+	}
+	key, ok := keys[kid]
+	if !ok {
+		return nil, errors.New("limiter: unknown JWT key ID " + kid)
+	}
+	return key, nil
+}
+
+// update fetches the key set without holding the mutex and closes done
+// once the result is stored.
+func (j *JWKS) update(done chan struct{}) {
+	keys, err := j.fetch()
+
+	j.mutex.Lock()
+	if err == nil {
+		j.keys = keys
+	}
+	j.err = err
+	j.fetchedAt = time.Now()
+	j.fetching = nil
+	j.mutex.Unlock()
+	close(done)
+}
+
+func (j *JWKS) fetch() (map[string]any, error) {
+	resp, err := j.client.Get(j.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("limiter: fetching JWKS: " + resp.Status)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		switch k.Kty {
+		case "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(k.N)
+			e, errE := base64.RawURLEncoding.DecodeString(k.E)
+			if errN != nil || errE != nil {
+				continue
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case "EC":
+			var curve elliptic.Curve
+			switch k.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				continue
+			}
+			x, errX := base64.RawURLEncoding.DecodeString(k.X)
+			y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+			if errX != nil || errY != nil {
+				continue
+			}
+			keys[k.Kid] = &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		}
+	}
+	return keys, nil
+}
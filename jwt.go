@@ -0,0 +1,177 @@
+package limiter
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errInvalidJWT = errors.New("limiter: invalid JWT")
+
+// JWTKeySet returns the key that verifies tokens signed with key ID kid:
+// a []byte secret for HS256/384/512, an *rsa.PublicKey for RS256/384/512
+// or an *ecdsa.PublicKey for ES256/384/512.
+type JWTKeySet interface {
+	Key(kid string) (any, error)
+}
+
+type staticKeySet struct {
+	key any
+}
+
+// StaticKeySet verifies every token with the same key, whatever its kid.
+func StaticKeySet(key any) JWTKeySet {
+	return staticKeySet{key: key}
+}
+
+func (s staticKeySet) Key(string) (any, error) {
+	return s.key, nil
+}
+
+// ByJWTClaim keys requests by a claim such as "sub" or "tenant_id" of the
+// bearer token in the Authorization header. The token must be signed by a
+// key from keys and be within its exp and nbf times; requests without a
+// valid token or without the claim are keyed like ByClientIP instead.
+func ByJWTClaim(claim string, keys JWTKeySet) func(*gin.Context) string {
+	bearer := ByAuthHeaderBearer()
+	clientIP := ByClientIP()
+	return func(c *gin.Context) string {
+		claims, err := verifyJWT(bearer(c), keys, time.Now())
+		if err != nil {
+			return clientIP(c)
+		}
+		switch value := claims[claim].(type) {
+		case string:
+			if value != "" {
+				return value
+			}
+		case json.Number:
+			return value.String()
+		}
+		return clientIP(c)
+	}
+}
+
+func verifyJWT(token string, keys JWTKeySet, now time.Time) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errInvalidJWT
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errInvalidJWT
+	}
+	key, err := keys.Key(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyJWTSignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]any
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if exp, ok := claims["exp"].(json.Number); ok {
+		if seconds, err := exp.Int64(); err != nil || !now.Before(time.Unix(seconds, 0)) {
+			return nil, errInvalidJWT
+		}
+	}
+	if nbf, ok := claims["nbf"].(json.Number); ok {
+		if seconds, err := nbf.Int64(); err != nil || now.Before(time.Unix(seconds, 0)) {
+			return nil, errInvalidJWT
+		}
+	}
+	return claims, nil
+}
+
+func decodeJWTPart(part string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return errInvalidJWT
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return errInvalidJWT
+	}
+	return nil
+}
+
+// verifyJWTSignature checks signature against the algorithm named in the
+// token, which must match the type of key; "none" is never accepted.
+func verifyJWTSignature(alg string, key any, signed string, signature []byte) error {
+	if len(alg) != 5 {
+		return errInvalidJWT
+	}
+	var hash crypto.Hash
+	switch alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return errInvalidJWT
+	}
+
+	switch key := key.(type) {
+	case []byte:
+		if alg[:2] != "HS" {
+			return errInvalidJWT
+		}
+		mac := hmac.New(hash.New, key)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return errInvalidJWT
+		}
+		return nil
+	case *rsa.PublicKey:
+		if alg[:2] != "RS" {
+			return errInvalidJWT
+		}
+		h := hash.New()
+		h.Write([]byte(signed))
+		if rsa.VerifyPKCS1v15(key, hash, h.Sum(nil), signature) != nil {
+			return errInvalidJWT
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if alg[:2] != "ES" || len(signature) != 2*size {
+			return errInvalidJWT
+		}
+		h := hash.New()
+		h.Write([]byte(signed))
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, h.Sum(nil), r, s) {
+			return errInvalidJWT
+		}
+		return nil
+	}
+	return errors.New("limiter: unsupported JWT key type for " + strconv.Quote(alg))
+}
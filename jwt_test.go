@@ -0,0 +1,157 @@
+package limiter
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func signTestJWT(t *testing.T, header, claims map[string]any, sign func(signed []byte) []byte) string {
+	encode := func(v any) string {
+		data, err := json.Marshal(v)
+		assert.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := encode(header) + "." + encode(claims)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign([]byte(signed)))
+}
+
+func hs256(secret []byte) func([]byte) []byte {
+	return func(signed []byte) []byte {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(signed)
+		return mac.Sum(nil)
+	}
+}
+
+func jwtKey(token string, keyFunc func(*gin.Context) string) string {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("GET", "/", nil)
+	c.Request.RemoteAddr = "192.168.1.1:1234"
+	c.Request.Header.Set("Authorization", "Bearer "+token)
+	return keyFunc(c)
+}
+
+func TestByJWTClaim(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	secret := []byte("secret")
+	keyFunc := ByJWTClaim("sub", StaticKeySet(secret))
+	hs := map[string]any{"alg": "HS256", "typ": "JWT"}
+	exp := time.Now().Add(time.Hour).Unix()
+
+	// 有效的令牌按声明生成键
+	token := signTestJWT(t, hs, map[string]any{"sub": "user-1", "exp": exp}, hs256(secret))
+	assert.Equal(t, "user-1", jwtKey(token, keyFunc))
+	token = signTestJWT(t, hs, map[string]any{"sub": 42}, hs256(secret))
+	assert.Equal(t, "42", jwtKey(token, keyFunc))
+
+	// 无效的令牌回退到客户端 IP
+	for _, token := range []string{
+		"",
+		"not.a.jwt",
+		signTestJWT(t, hs, map[string]any{"sub": "user-1"}, hs256([]byte("wrong"))),
+		signTestJWT(t, hs, map[string]any{"sub": "user-1", "exp": time.Now().Add(-time.Minute).Unix()}, hs256(secret)),
+		signTestJWT(t, hs, map[string]any{"sub": "user-1", "nbf": time.Now().Add(time.Minute).Unix()}, hs256(secret)),
+		signTestJWT(t, map[string]any{"alg": "none"}, map[string]any{"sub": "user-1"}, func([]byte) []byte { return nil }),
+		signTestJWT(t, hs, map[string]any{"tenant_id": "a"}, hs256(secret)),
+	} {
+		assert.Equal(t, "192.168.1.1", jwtKey(token, keyFunc))
+	}
+}
+
+func TestByJWTClaimJWKS(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		b64 := base64.RawURLEncoding.EncodeToString
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kty": "EC", "kid": "ec", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+		}})
+	}))
+	defer server.Close()
+
+	keyFunc := ByJWTClaim("tenant_id", NewJWKS(server.URL, time.Hour))
+	claims := map[string]any{"tenant_id": "acme"}
+
+	// RS256 签名的令牌
+	token := signTestJWT(t, map[string]any{"alg": "RS256", "kid": "rsa"}, claims, func(signed []byte) []byte {
+		digest := sha256.Sum256(signed)
+		signature, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+		assert.NoError(t, err)
+		return signature
+	})
+	assert.Equal(t, "acme", jwtKey(token, keyFunc))
+
+	// ES256 签名的令牌
+	token = signTestJWT(t, map[string]any{"alg": "ES256", "kid": "ec"}, claims, func(signed []byte) []byte {
+		digest := sha256.Sum256(signed)
+		r, s, err := ecdsa.Sign(rand.Reader, ecKey, digest[:])
+		assert.NoError(t, err)
+		return append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	})
+	assert.Equal(t, "acme", jwtKey(token, keyFunc))
+
+	// 算法与密钥类型不符时拒绝
+	token = signTestJWT(t, map[string]any{"alg": "HS256", "kid": "rsa"}, claims, hs256(rsaKey.N.Bytes()))
+	assert.Equal(t, "192.168.1.1", jwtKey(token, keyFunc))
+
+	// 密钥集在刷新间隔内被缓存
+	assert.Equal(t, 1, fetches)
+}
+
+func TestJWKSRefreshesInBackground(t *testing.T) {
+	release := make(chan struct{})
+	var fetches int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&fetches, 1) > 1 {
+			<-release
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa", "n": "AQAB", "e": "AQAB"},
+		}})
+	}))
+	defer server.Close()
+	defer close(release)
+
+	keys := NewJWKS(server.URL, 20*time.Millisecond)
+	_, err := keys.Key("rsa")
+	assert.NoError(t, err)
+
+	// 刷新在后台进行，期间继续使用旧的密钥
+	time.Sleep(30 * time.Millisecond)
+	for n := 0; n < 3; n++ {
+		_, err = keys.Key("rsa")
+		assert.NoError(t, err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fetches))
+
+	// 刷新间隔必须为正
+	assert.Panics(t, func() { NewJWKS(server.URL, 0) })
+}
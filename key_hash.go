@@ -0,0 +1,88 @@
+package limiter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultMaxKeyLength is the MaxKeyLength used when none is set.
+const DefaultMaxKeyLength = 256
+
+func validateKeys(r *RateLimitConfig) error {
+	if strings.Contains(r.Namespace, ":") {
+		return errors.New("Namespace must not contain \":\"")
+	}
+	if r.KeyHashLength != 0 && (r.KeyHashLength < 16 || r.KeyHashLength > 64) {
+		return errors.New("KeyHashLength must be between 16 and 64")
+	}
+	if r.MaxKeyLength != 0 && r.MaxKeyLength < 16 {
+		return errors.New("MaxKeyLength must be at least 16")
+	}
+	return nil
+}
+
+// storeKey turns a key from KeyFunc or a caller into the key its buckets
+// are stored under. With HashKeys the key is replaced by its SHA-256 hex
+// digest, cut to KeyHashLength characters if set, so long tokens or URLs
+// take little room and never reach a Store, header or log in clear text.
+// Otherwise the key is sanitized, and keys longer than MaxKeyLength are
+// hashed, so huge header values cannot be used to exhaust memory. The
+// result is prefixed with the Namespace, if any.
+func (rl *RateLimiter) storeKey(key string) string {
+	if rl.config.Namespace != "" {
+		return rl.config.Namespace + ":" + rl.limitKey(key)
+	}
+	return rl.limitKey(key)
+}
+
+func (rl *RateLimiter) limitKey(key string) string {
+	if !rl.config.HashKeys {
+		key = sanitizeKey(key)
+		maxLength := rl.config.MaxKeyLength
+		if maxLength == 0 {
+			maxLength = DefaultMaxKeyLength
+		}
+		if len(key) <= maxLength {
+			return key
+		}
+		return hashKey(key)[:minInt(maxLength, 64)]
+	}
+	hashed := hashKey(key)
+	if length := rl.config.KeyHashLength; length > 0 {
+		hashed = hashed[:length]
+	}
+	return hashed
+}
+
+// sanitizeKey percent-escapes control characters, invalid UTF-8 and "%"
+// itself, so keys cannot break store protocols, headers or log lines and
+// distinct keys stay distinct.
+func sanitizeKey(key string) string {
+	clean := true
+	for _, r := range key {
+		if r == '%' || r == utf8.RuneError || unicode.IsControl(r) {
+			clean = false
+			break
+		}
+	}
+	if clean {
+		return key
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(key); {
+		r, size := utf8.DecodeRuneInString(key[i:])
+		if r == '%' || (r == utf8.RuneError && size <= 1) || unicode.IsControl(r) {
+			for _, c := range []byte(key[i : i+size]) {
+				fmt.Fprintf(&b, "%%%02X", c)
+			}
+		} else {
+			b.WriteString(key[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
@@ -0,0 +1,145 @@
+package limiter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterHashKeys(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	config := RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            ByAuthHeaderBearer(),
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		HashKeys:           true,
+		KeyHashLength:      16,
+		Debug:              true,
+	}
+
+	rl, err := New(config)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(rl.RateLimitMiddleware())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	token := "eyJhbGciOiJIUzI1NiJ9.very-long-secret-token"
+	request := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		req.Header.Set("Authorization", "Bearer "+token)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 键以截断后的哈希形式存储，不泄露原值
+	w := request()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, hashKey(token)[:16], w.Header().Get("X-RateLimit-Debug-Key"))
+	assert.Len(t, rl.buckets, 1)
+	assert.NotNil(t, rl.buckets[hashKey(token)[:16]])
+
+	// 公共方法使用原始键并得到相同的哈希
+	assert.False(t, rl.Allow(token))
+	assert.NoError(t, rl.Reset(context.Background(), token))
+	assert.Equal(t, http.StatusOK, request().Code)
+
+	// 无效的哈希长度会被拒绝
+	config.KeyHashLength = 8
+	assert.Error(t, config.Validate())
+}
+
+func TestSanitizeKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"192.168.1.1", "192.168.1.1"},
+		{"GET /users/:id 2001:db8::/64", "GET /users/:id 2001:db8::/64"},
+		{"用户", "用户"},
+		{"a\r\nSET evil 1", "a%0D%0ASET evil 1"},
+		{"a\x00b", "a%00b"},
+		{"100%", "100%25"},
+		{"bad\xffutf8", "bad%FFutf8"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, sanitizeKey(tt.key))
+	}
+}
+
+func TestRateLimiterMaxKeyLength(t *testing.T) {
+	config := RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		MaxKeyLength:       32,
+	}
+
+	rl, err := New(config)
+	assert.NoError(t, err)
+
+	// 超长的键被哈希并截断到最大长度
+	long := strings.Repeat("x", 10000)
+	assert.Equal(t, hashKey(long)[:32], rl.storeKey(long))
+	assert.Equal(t, "short", rl.storeKey("short"))
+
+	// 未设置时使用默认的最大长度
+	config.MaxKeyLength = 0
+	rl, err = New(config)
+	assert.NoError(t, err)
+	assert.Equal(t, hashKey(long), rl.storeKey(long))
+	assert.Equal(t, strings.Repeat("x", DefaultMaxKeyLength), rl.storeKey(strings.Repeat("x", DefaultMaxKeyLength)))
+
+	// 过小的最大长度会被拒绝
+	config.MaxKeyLength = 8
+	assert.Error(t, config.Validate())
+}
+
+func TestRateLimiterNamespace(t *testing.T) {
+	store := newMapStore()
+	config := RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Store:              store,
+	}
+
+	// 共享同一个 Store 的两个限流器使用不同的命名空间
+	config.Namespace = "login"
+	login, err := New(config)
+	assert.NoError(t, err)
+	config.Namespace = "api"
+	api, err := New(config)
+	assert.NoError(t, err)
+
+	assert.True(t, login.Allow("192.168.1.1"))
+	assert.False(t, login.Allow("192.168.1.1"))
+	assert.True(t, api.Allow("192.168.1.1"))
+
+	assert.Contains(t, store.values, "login:192.168.1.1")
+	assert.Contains(t, store.values, "api:192.168.1.1")
+
+	// 命名空间不能包含分隔符
+	config.Namespace = "a:b"
+	assert.Error(t, config.Validate())
+}
@@ -0,0 +1,148 @@
+package limiter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/netip"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IPv6Prefix is the prefix length the IP-based key extractors aggregate
+// IPv6 clients into, since anyone with a /64 can rotate through its
+// addresses at will. ByClientIPPrefix takes another length.
+const IPv6Prefix = 64
+
+// ByClientIP keys requests by c.ClientIP(), which honors the trusted
+// proxies configured on the gin engine, aggregating IPv6 clients to
+// IPv6Prefix.
+func ByClientIP() func(*gin.Context) string {
+	return ByClientIPPrefix(32, IPv6Prefix)
+}
+
+// ByClientIPPrefix is ByClientIP with the given prefix lengths, e.g.
+// ByClientIPPrefix(32, 128) keys IPv6 clients by their full address.
+func ByClientIPPrefix(ipv4Bits, ipv6Bits int) func(*gin.Context) string {
+	return func(c *gin.Context) string {
+		return ipPrefix(c.ClientIP(), ipv4Bits, ipv6Bits)
+	}
+}
+
+// ByHeader keys requests by the value of a request header, or "" if it is
+// missing.
+func ByHeader(name string) func(*gin.Context) string {
+	return func(c *gin.Context) string {
+		return strings.TrimSpace(c.GetHeader(name))
+	}
+}
+
+// ByCookie keys requests by the value of a cookie, or "" if it is missing.
+func ByCookie(name string) func(*gin.Context) string {
+	return func(c *gin.Context) string {
+		value, err := c.Cookie(name)
+		if err != nil {
+			return ""
+		}
+		return value
+	}
+}
+
+// ByParam keys requests by a route parameter such as "projectID" of
+// "/projects/:projectID", for per-resource limits. Routes without the
+// parameter give "".
+func ByParam(name string) func(*gin.Context) string {
+	return func(c *gin.Context) string {
+		return c.Param(name)
+	}
+}
+
+// ByRoute keys requests by their route template, c.FullPath(), so that
+// "/users/123" and "/users/456" share the bucket of "/users/:id". Requests
+// that match no route give "".
+func ByRoute() func(*gin.Context) string {
+	return func(c *gin.Context) string {
+		return c.FullPath()
+	}
+}
+
+// PerRoute gives every route its own limit per key, e.g.
+// PerRoute(ByClientIP()) limits each client on each endpoint separately.
+func PerRoute(keyFunc func(*gin.Context) string) func(*gin.Context) string {
+	return func(c *gin.Context) string {
+		return c.FullPath() + " " + keyFunc(c)
+	}
+}
+
+// ByQuery keys requests by a query parameter such as "api_key", or "" if
+// it is missing. With hash set the value is replaced by its SHA-256, so
+// credentials passed in the URL do not end up in memory or in a Store.
+func ByQuery(name string, hash bool) func(*gin.Context) string {
+	return func(c *gin.Context) string {
+		value := c.Query(name)
+		if hash && value != "" {
+			return hashKey(value)
+		}
+		return value
+	}
+}
+
+// ByAuthHeaderBearer keys requests by the token of an
+// "Authorization: Bearer <token>" header. The scheme is matched case
+// insensitively; other schemes and missing headers give "".
+func ByAuthHeaderBearer() func(*gin.Context) string {
+	return func(c *gin.Context) string {
+		scheme, token, ok := strings.Cut(strings.TrimSpace(c.GetHeader("Authorization")), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			return ""
+		}
+		return strings.TrimSpace(token)
+	}
+}
+
+// ByIPPrefix aggregates the IP addresses returned by keyFunc into
+// prefixes of ipv4Bits or ipv6Bits, e.g. "203.0.113.0/24", so that clients
+// rotating addresses within one block share a bucket. Keys that are
+// already prefixes are narrowed further; others are passed through.
+func ByIPPrefix(keyFunc func(*gin.Context) string, ipv4Bits, ipv6Bits int) func(*gin.Context) string {
+	return func(c *gin.Context) string {
+		return ipPrefix(keyFunc(c), ipv4Bits, ipv6Bits)
+	}
+}
+
+func ipPrefix(key string, ipv4Bits, ipv6Bits int) string {
+	addr, err := netip.ParseAddr(key)
+	if err != nil {
+		prefix, err := netip.ParsePrefix(key)
+		if err != nil {
+			return key
+		}
+		bits := ipv6Bits
+		if prefix.Addr().Is4() {
+			bits = ipv4Bits
+		}
+		if bits >= prefix.Bits() {
+			return key
+		}
+		narrowed, _ := prefix.Addr().Prefix(bits)
+		return narrowed.String()
+	}
+	addr = addr.Unmap()
+	bits := ipv6Bits
+	if addr.Is4() {
+		bits = ipv4Bits
+	}
+	if bits >= addr.BitLen() {
+		return addr.String()
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return key
+	}
+	return prefix.String()
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,215 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newKeyContext(setup func(req *http.Request)) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("GET", "/", nil)
+	c.Request.RemoteAddr = "192.168.1.1:1234"
+	setup(c.Request)
+	return c
+}
+
+func TestKeyExtractors(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		keyFunc func(*gin.Context) string
+		setup   func(req *http.Request)
+		want    string
+	}{
+		{"client ip", ByClientIP(), func(req *http.Request) {}, "192.168.1.1"},
+		{"header", ByHeader("X-API-Key"), func(req *http.Request) { req.Header.Set("X-API-Key", " abc ") }, "abc"},
+		{"missing header", ByHeader("X-API-Key"), func(req *http.Request) {}, ""},
+		{"cookie", ByCookie("session"), func(req *http.Request) { req.AddCookie(&http.Cookie{Name: "session", Value: "s1"}) }, "s1"},
+		{"missing cookie", ByCookie("session"), func(req *http.Request) {}, ""},
+		{"query", ByQuery("api_key", false), func(req *http.Request) { req.URL.RawQuery = "api_key=k1" }, "k1"},
+		// 哈希后的值不暴露原始密钥
+		{"hashed query", ByQuery("api_key", true), func(req *http.Request) { req.URL.RawQuery = "api_key=k1" },
+			"6ab9f1eb8f7d3388f4f9d586f66e99fd54080df2c446f0e58668b09c08a16dd0"},
+		{"missing query", ByQuery("api_key", true), func(req *http.Request) {}, ""},
+		{"bearer", ByAuthHeaderBearer(), func(req *http.Request) { req.Header.Set("Authorization", "bearer  t0k3n") }, "t0k3n"},
+		// 其他认证方案不作为键
+		{"basic", ByAuthHeaderBearer(), func(req *http.Request) { req.Header.Set("Authorization", "Basic dXNlcjpwYXNz") }, ""},
+		{"bare scheme", ByAuthHeaderBearer(), func(req *http.Request) { req.Header.Set("Authorization", "Bearer") }, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.keyFunc(newKeyContext(tt.setup)))
+		})
+	}
+}
+
+func TestByIPPrefix(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	keyFunc := ByIPPrefix(ByHeader("X-Client"), 24, 48)
+	tests := []struct {
+		client string
+		want   string
+	}{
+		{"203.0.113.7", "203.0.113.0/24"},
+		{"203.0.113.200", "203.0.113.0/24"},
+		{"::ffff:203.0.113.7", "203.0.113.0/24"},
+		{"2001:db8:1:2::7", "2001:db8:1::/48"},
+		// 非 IP 的键保持不变
+		{"api-key", "api-key"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		c := newKeyContext(func(req *http.Request) { req.Header.Set("X-Client", tt.client) })
+		assert.Equal(t, tt.want, keyFunc(c), tt.client)
+	}
+
+	// 前缀长度等于地址长度时不聚合
+	assert.Equal(t, "203.0.113.7", ipPrefix("203.0.113.7", 32, 128))
+}
+
+func TestIPv6Prefix(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	ipv6 := func(req *http.Request) { req.RemoteAddr = "[2001:db8:1:2:3:4:5:6]:1234" }
+
+	// 默认按 /64 聚合 IPv6 地址
+	assert.Equal(t, "2001:db8:1:2::/64", ByClientIP()(newKeyContext(ipv6)))
+	assert.Equal(t, "192.168.1.1", ByClientIP()(newKeyContext(func(req *http.Request) {})))
+
+	// 已聚合的前缀可以进一步放宽
+	assert.Equal(t, "2001:db8:1::/48", ByIPPrefix(ByClientIP(), 24, 48)(newKeyContext(ipv6)))
+	assert.Equal(t, "2001:db8:1:2::/64", ByIPPrefix(ByClientIP(), 24, 96)(newKeyContext(ipv6)))
+
+	// 前缀长度为 128 时按完整地址生成键
+	assert.Equal(t, "2001:db8:1:2:3:4:5:6", ByClientIPPrefix(32, 128)(newKeyContext(ipv6)))
+}
+
+func TestByParam(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            ByParam("projectID"),
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.POST("/projects/:projectID/tasks", limiterMiddleware, func(c *gin.Context) {
+		c.String(http.StatusOK, "created")
+	})
+
+	// 每个项目拥有独立的令牌桶
+	for _, tt := range []struct {
+		path string
+		want int
+	}{
+		{"/projects/a/tasks", http.StatusOK},
+		{"/projects/a/tasks", http.StatusTooManyRequests},
+		{"/projects/b/tasks", http.StatusOK},
+	} {
+		req, _ := http.NewRequest("POST", tt.path, nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tt.want, w.Code, tt.path)
+	}
+}
+
+func TestFallbackKeyFunc(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            ByHeader("X-API-Key"),
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func(remoteAddr string) int {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 没有请求头的客户端按 IP 限流，而不是共用空键的令牌桶
+	assert.Equal(t, http.StatusOK, request("192.168.1.1:1234"))
+	assert.Equal(t, http.StatusTooManyRequests, request("192.168.1.1:1234"))
+	assert.Equal(t, http.StatusOK, request("192.168.1.2:1234"))
+}
+
+func TestByRoute(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range []struct {
+		name    string
+		keyFunc func(*gin.Context) string
+		want    []int
+	}{
+		// 同一路由模板共享令牌桶
+		{"route", ByRoute(), []int{http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusOK}},
+		// 每个客户端在每个路由上各自限流
+		{"per route", PerRoute(ByClientIP()), []int{http.StatusOK, http.StatusTooManyRequests, http.StatusOK, http.StatusOK}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+				MaxTokens:          1,
+				RefillRate:         1,
+				RefillInterval:     time.Minute,
+				KeyFunc:            tt.keyFunc,
+				BurstMultiplier:    1,
+				ExpirationDuration: time.Minute * 5,
+			})
+			assert.NoError(t, err)
+
+			router := gin.New()
+			router.Use(limiterMiddleware)
+			router.GET("/users/:id", func(c *gin.Context) {
+				c.String(http.StatusOK, c.Param("id"))
+			})
+			router.GET("/orders/:id", func(c *gin.Context) {
+				c.String(http.StatusOK, c.Param("id"))
+			})
+
+			for i, request := range []struct{ path, remoteAddr string }{
+				{"/users/123", "192.168.1.1:1234"},
+				{"/users/456", "192.168.1.1:1234"},
+				{"/users/456", "192.168.1.2:1234"},
+				{"/orders/1", "192.168.1.1:1234"},
+			} {
+				req, _ := http.NewRequest("GET", request.path, nil)
+				req.RemoteAddr = request.remoteAddr
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				assert.Equal(t, tt.want[i], w.Code, request.path)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package limiter
+
+import (
+	"sync"
+	"time"
+)
+
+// leakyBucket lets requests out at a constant rate of RefillRate per
+// RefillInterval. Requests arriving faster are queued, i.e. admitted with a
+// delay, and only rejected once MaxTokens requests are already waiting.
+type leakyBucket struct {
+	capacity int
+	interval time.Duration
+	next     map[string]time.Time
+	mutex    sync.Mutex
+}
+
+func newLeakyBucket(capacity, rate int, interval time.Duration) *leakyBucket {
+	return &leakyBucket{
+		capacity: capacity,
+		interval: interval / time.Duration(rate),
+		next:     make(map[string]time.Time),
+	}
+}
+
+func (l *leakyBucket) Take(key string, n int, now time.Time) (bool, time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	start := l.next[key]
+	if start.Before(now) {
+		start = now
+	}
+
+	drained := start.Add(time.Duration(n) * l.interval)
+	if excess := drained.Sub(now) - time.Duration(l.capacity)*l.interval; excess > 0 {
+		return false, excess
+	}
+
+	l.next[key] = drained
+	return true, start.Sub(now)
+}
+
+func (l *leakyBucket) Quota(key string, now time.Time) (int, int, time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	drained := l.next[key].Sub(now)
+	queued := ceilDiv(drained, l.interval)
+	if queued == 0 {
+		return l.capacity, l.capacity, 0
+	}
+	return l.capacity, maxInt(l.capacity-queued, 0), drained
+}
+
+func (l *leakyBucket) Reset(key string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delete(l.next, key)
+}
+
+func (l *leakyBucket) Cleanup(now time.Time, expiration time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for key, next := range l.next {
+		if now.Sub(next) > expiration {
+			delete(l.next, key)
+		}
+	}
+}
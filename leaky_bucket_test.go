@@ -0,0 +1,95 @@
+package limiter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeakyBucket_ConstantOutflow(t *testing.T) {
+	// 每秒流出 10 个请求，队列最多容纳 2 个
+	bucket := newLeakyBucket(2, 10, time.Second)
+	now := time.Now()
+
+	allowed, delay := bucket.Take("key", 1, now)
+	assert.True(t, allowed)
+	assert.Equal(t, time.Duration(0), delay)
+
+	// 后续请求按固定间隔排队
+	allowed, delay = bucket.Take("key", 1, now)
+	assert.True(t, allowed)
+	assert.Equal(t, time.Millisecond*100, delay)
+
+	// 队列已满，拒绝请求
+	allowed, retryAfter := bucket.Take("key", 1, now)
+	assert.False(t, allowed)
+	assert.Equal(t, time.Millisecond*100, retryAfter)
+
+	// 漏出一个请求后可以再次排队
+	allowed, delay = bucket.Take("key", 1, now.Add(time.Millisecond*100))
+	assert.True(t, allowed)
+	assert.Equal(t, time.Millisecond*100, delay)
+}
+
+func TestRateLimiterLeakyBucketDelaysRequests(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	config := RateLimitConfig{
+		MaxTokens:          5,
+		RefillRate:         20,
+		RefillInterval:     time.Second,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Algorithm:          LeakyBucket,
+	}
+
+	limiterMiddleware, err := NewRateLimiter(config)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+
+	// 三个请求按 50ms 的间隔依次放行
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), time.Millisecond*100)
+}
+
+func TestValidateLeakyBucketRate(t *testing.T) {
+	config := RateLimitConfig{
+		MaxTokens:          10,
+		RefillRate:         1000,
+		RefillInterval:     time.Nanosecond * 999,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Algorithm:          LeakyBucket,
+	}
+	// 间隔会被截断为 0 的速率被拒绝，而不是在请求时除零
+	assert.Error(t, config.Validate())
+
+	config.RefillInterval = time.Microsecond
+	assert.NoError(t, config.Validate())
+	limiter, err := New(config)
+	assert.NoError(t, err)
+	assert.True(t, limiter.Allow("key"))
+	u, ok := limiter.quota(context.Background(), "key", time.Now())
+	assert.True(t, ok)
+	assert.Equal(t, 10, u.limit)
+}
@@ -1,21 +1,85 @@
 package limiter
 
 import (
+	"context"
 	"errors"
 	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/netip"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type RateLimitConfig struct {
-	MaxTokens            int
-	RefillRate           int
-	RefillInterval       time.Duration
-	KeyFunc              func(*gin.Context) string
-	BurstMultiplier      int
-	Timeout              time.Duration
-	LimitExceededHandler gin.HandlerFunc
-	ExpirationDuration   time.Duration
+	MaxTokens               int
+	RefillRate              int
+	RefillInterval          time.Duration
+	KeyFunc                 func(*gin.Context) string
+	FallbackKeyFunc         func(*gin.Context) string
+	ExemptNetworks          []string
+	TrustedProxies          []string
+	SkipPaths               []string
+	ExemptMethods           []string
+	Skipper                 func(c *gin.Context) bool
+	Namespace               string
+	HashKeys                bool
+	KeyHashLength           int
+	MaxKeyLength            int
+	Cost                    func(*gin.Context) int
+	BurstMultiplier         int
+	Timeout                 time.Duration
+	LimitExceededHandler    gin.HandlerFunc
+	LimitExceededFunc       LimitExceededFunc
+	ExpirationDuration      time.Duration
+	Store                   Store
+	SyncInterval            time.Duration
+	Algorithm               string
+	Adaptive                *AdaptiveConfig
+	LoadShedding            *LoadSheddingConfig
+	Tarpit                  *TarpitConfig
+	Reputation              *ReputationConfig
+	ConcurrencyControl      *ConcurrencyControlConfig
+	MaxInFlight             int
+	InFlightWait            time.Duration
+	InFlightExceededHandler gin.HandlerFunc
+	MaxQueue                int
+	FairQueuing             bool
+	QueueWeight             func(c *gin.Context) int
+	CountResponse           func(c *gin.Context) bool
+	RefundServerErrors      bool
+	CountStatusClasses      []int
+	AuthFailurePenalty      int
+	BanThreshold            int
+	BanDuration             time.Duration
+	Backoff                 *BackoffConfig
+	DisableHeaders          bool
+	HeaderFormat            string
+	RetryAfterDate          bool
+	RetryAfterJitter        time.Duration
+	StatusCode              int
+	DenialBody              string
+	DenialHTML              string
+	Messages                map[string]string
+	WarningThreshold        float64
+	OnWarning               func(c *gin.Context, info LimitInfo)
+	DryRun                  bool
+	OnDryRun                func(c *gin.Context, info LimitInfo)
+	SampleRate              *float64
+	Debug                   bool
+	Challenge               ChallengeProvider
+	ServerTiming            bool
+	Overrides               map[string]Limit
+	Schedule                []ScheduleWindow
+	Cron                    []CronRule
+	LimitFunc               LimitFunc
+	Plans                   *PlanConfig
+	Priorities              *PriorityConfig
+	GlobalLimit             *Limit
+	UnmatchedRoute          *Limit
+	Rules                   []Rule
+	Scopes                  []Scope
 }
 
 type tokenBucket struct {
@@ -24,26 +88,119 @@ type tokenBucket struct {
 	maxTokens      int
 	refillRate     int
 	refillInterval time.Duration
+	pending        int
+	syncedAt       time.Time
 	mutex          sync.Mutex
 }
 
 type RateLimiter struct {
-	buckets map[string]*tokenBucket
-	config  RateLimitConfig
-	mutex   sync.RWMutex
+	buckets      map[string]*tokenBucket
+	algorithm    Algorithm
+	exempt       []netip.Prefix
+	clientAddr   func(*http.Request) string
+	current      atomic.Pointer[limits]
+	dynamic      *dynamicLimits
+	plans        *plans
+	priorities   *priorities
+	adaptive     *aimd
+	shedder      *loadShedder
+	routes       *routeConcurrency
+	inFlight     *inFlight
+	queue        *waitQueue
+	fair         *fairQueue
+	global       *tokenBucket
+	rules        *stackedRules
+	unmatched    *stackedRules
+	hierarchy    *hierarchy
+	bans         *banList
+	backoff      *backoff
+	reputation   *reputation
+	allowed      atomic.Uint64
+	denied       atomic.Uint64
+	dryRunDenied atomic.Uint64
+	config       RateLimitConfig
+	mutex        sync.RWMutex
 }
 
 func NewRateLimiter(config RateLimitConfig) (gin.HandlerFunc, error) {
+	limiter, err := New(config)
+	if err != nil {
+		return nil, err
+	}
+	return limiter.RateLimitMiddleware(), nil
+}
+
+// New returns the limiter itself rather than only its middleware, for
+// callers that need to clean up, inspect or reset it at runtime.
+func New(config RateLimitConfig) (*RateLimiter, error) {
 	if err := config.Validate(); err != nil {
 		return nil, err
 	}
 
 	limiter := &RateLimiter{
-		buckets: make(map[string]*tokenBucket),
-		config:  config,
+		buckets:   make(map[string]*tokenBucket),
+		algorithm: newAlgorithm(config),
+		config:    config,
+	}
+	limiter.exempt, _ = parseExemptNetworks(config.ExemptNetworks)
+	if len(config.TrustedProxies) > 0 {
+		limiter.clientAddr, _ = newProxyResolver(config.TrustedProxies)
+	}
+	limiter.current.Store(limiter.newLimits(config, time.Now()))
+	if config.LimitFunc != nil {
+		limiter.dynamic = newDynamicLimits()
+	}
+	if config.Plans != nil {
+		limiter.plans = newPlans(*config.Plans)
+		limiter.dynamic = newDynamicLimits()
+	}
+	if config.Priorities != nil {
+		limiter.priorities = newPriorities(*config.Priorities)
+		if config.Priorities.limited() {
+			limiter.dynamic = newDynamicLimits()
+		}
+	}
+	if config.Adaptive != nil {
+		limiter.adaptive = newAIMD(*config.Adaptive, config.RefillRate, config.RefillInterval)
+	}
+	if config.LoadShedding != nil {
+		limiter.shedder = newLoadShedder(*config.LoadShedding)
+	}
+	if config.ConcurrencyControl != nil {
+		limiter.routes = newRouteConcurrency(*config.ConcurrencyControl)
+	}
+	if config.MaxInFlight > 0 {
+		limiter.inFlight = newInFlight(config.MaxInFlight)
+	}
+	if config.MaxQueue > 0 {
+		limiter.queue = newWaitQueue(config.MaxQueue)
+	}
+	if config.FairQueuing {
+		limiter.fair = newFairQueue()
+	}
+	if config.GlobalLimit != nil {
+		limiter.global = newLimitBucket(*config.GlobalLimit, time.Now())
+	}
+	if config.BanThreshold > 0 {
+		limiter.bans = newBanList(config.BanThreshold, config.BanDuration)
+	}
+	if config.Backoff != nil {
+		limiter.backoff = newBackoff(*config.Backoff)
+	}
+	if config.Reputation != nil {
+		limiter.reputation = newReputation(*config.Reputation)
+	}
+	if len(config.Rules) > 0 {
+		limiter.rules = newStackedRules(config.Rules)
+	}
+	if config.UnmatchedRoute != nil {
+		limiter.unmatched = newUnmatched(*config.UnmatchedRoute)
+	}
+	if len(config.Scopes) > 0 {
+		limiter.hierarchy = newHierarchy(config.Scopes)
 	}
 
-	return limiter.RateLimitMiddleware(), nil
+	return limiter, nil
 }
 
 func (rl *RateLimiter) getBucket(key string) *tokenBucket {
@@ -56,13 +213,7 @@ func (rl *RateLimiter) getBucket(key string) *tokenBucket {
 		defer rl.mutex.Unlock()
 
 		if bucket, exists = rl.buckets[key]; !exists {
-			bucket = &tokenBucket{
-				tokens:         rl.config.MaxTokens,
-				lastRefill:     time.Now(),
-				maxTokens:      rl.config.MaxTokens * rl.config.BurstMultiplier,
-				refillRate:     rl.config.RefillRate,
-				refillInterval: rl.config.RefillInterval,
-			}
+			bucket = rl.newBucket(key, time.Now())
 			rl.buckets[key] = bucket
 		}
 	}
@@ -70,6 +221,27 @@ func (rl *RateLimiter) getBucket(key string) *tokenBucket {
 	return bucket
 }
 
+func (rl *RateLimiter) newBucket(key string, now time.Time) *tokenBucket {
+	if limit, ok := rl.limitFor(key); ok {
+		return newLimitBucket(limit, now)
+	}
+	l := rl.limits()
+	return &tokenBucket{
+		tokens:         l.maxTokens,
+		lastRefill:     now,
+		maxTokens:      l.maxTokens * l.burstMultiplier,
+		refillRate:     rl.refillRate(),
+		refillInterval: l.refillInterval,
+	}
+}
+
+func (rl *RateLimiter) refillRate() int {
+	if rl.adaptive != nil {
+		return rl.adaptive.current()
+	}
+	return rl.limits().refillRate
+}
+
 func (rl *RateLimiter) CleanupExpiredBuckets() {
 	rl.mutex.Lock()
 	defer rl.mutex.Unlock()
@@ -82,58 +254,572 @@ func (rl *RateLimiter) CleanupExpiredBuckets() {
 		}
 		bucket.mutex.Unlock()
 	}
+
+	if rl.rules != nil {
+		rl.rules.cleanup(now, rl.config.ExpirationDuration)
+	}
+	if rl.unmatched != nil {
+		rl.unmatched.cleanup(now, rl.config.ExpirationDuration)
+	}
+	if rl.dynamic != nil {
+		rl.dynamic.cleanup(now, rl.config.ExpirationDuration)
+	}
+	if rl.plans != nil {
+		rl.plans.cleanup(now)
+	}
+	if rl.hierarchy != nil {
+		rl.hierarchy.cleanup(now, rl.config.ExpirationDuration)
+	}
+	if rl.bans != nil {
+		rl.bans.cleanup(now, rl.config.ExpirationDuration)
+	}
+	if rl.backoff != nil {
+		rl.backoff.cleanup(now)
+	}
+	if rl.reputation != nil {
+		rl.reputation.cleanup(now)
+	}
+	if cleaner, ok := rl.algorithm.(Cleaner); ok {
+		cleaner.Cleanup(now, rl.config.ExpirationDuration)
+	}
 }
 
 func defaultLimitExceededHandler(c *gin.Context) {
-	c.AbortWithStatus(429)
+	d := getDenial(c)
+	if d.html {
+		// Browsers reload the page by themselves once the wait is over.
+		c.Header("Refresh", strconv.Itoa(maxInt(ceilDiv(d.info.RetryAfter, time.Second), 1)))
+		c.Data(d.status, "text/html; charset=utf-8", d.body)
+		c.Abort()
+		return
+	}
+	if d.body != nil {
+		c.Data(d.status, "application/json; charset=utf-8", d.body)
+		c.Abort()
+		return
+	}
+	if d.message != "" {
+		c.String(d.status, d.message)
+		c.Abort()
+		return
+	}
+	c.AbortWithStatus(d.status)
+}
+
+// rawKey returns the key of c before it is hashed or namespaced. Requests
+// for which KeyFunc finds nothing, such as ByHeader without the header,
+// are keyed by FallbackKeyFunc rather than all sharing the key "".
+func (rl *RateLimiter) rawKey(c *gin.Context) string {
+	if key := rl.config.KeyFunc(c); key != "" {
+		return key
+	}
+	return rl.config.FallbackKeyFunc(c)
+}
+
+func (rl *RateLimiter) statusCode() int {
+	if rl.config.StatusCode != 0 {
+		return rl.config.StatusCode
+	}
+	return http.StatusTooManyRequests
 }
 
 func (rl *RateLimiter) RateLimitMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		key := rl.config.KeyFunc(c)
-		bucket := rl.getBucket(key)
+		ch := rl.check(c)
+		defer ch.release()
+		if ch.answer(c) {
+			c.Next()
+			ch.finish(c)
+		}
+	}
+}
 
-		bucket.mutex.Lock()
-		defer bucket.mutex.Unlock()
+// check is the outcome of evaluating a request against one limiter,
+// kept apart from answering it so that All can charge several limiters
+// before any of them answers.
+type check struct {
+	rl      *RateLimiter
+	key     string
+	n       int
+	scopes  []string
+	allowed bool
+	r       rejection
+	// deny answers a request that was turned away before settle, such as
+	// by a ban or a full queue.
+	deny func(c *gin.Context)
+	// pass lets the request through untouched, e.g. when it is skipped or
+	// the Store failed.
+	pass bool
+	// settled requests were charged by the main path, so finish refunds
+	// and samples them after the handlers ran.
+	settled  bool
+	start    time.Time
+	handled  time.Time
+	route    *gradientLimiter
+	releases []func()
+}
+
+// check runs every stage of the middleware for c up to settle, charging
+// the buckets but leaving the answer to answer.
+func (rl *RateLimiter) check(c *gin.Context) *check {
+	ch := &check{rl: rl}
+	if rl.skipped(c) || rl.exempted(c) {
+		ch.pass = true
+		return ch
+	}
 
-		now := time.Now()
-		elapsed := now.Sub(bucket.lastRefill)
-		refillTokens := int(elapsed.Seconds()/rl.config.RefillInterval.Seconds()) * bucket.refillRate
+	class, rank, ranks := rl.priority(c)
+	if rl.shedder != nil && rl.shedder.shedRank(time.Now(), rank, ranks) && !rl.dryRunRule(c, "", LoadSheddingRule) {
+		ch.deny = abortWith(http.StatusServiceUnavailable)
+		return ch
+	}
 
-		if refillTokens > 0 {
-			bucket.tokens = minInt(bucket.tokens+refillTokens, bucket.maxTokens)
-			bucket.lastRefill = now
+	if rl.routes != nil {
+		route := rl.routes.get(c.FullPath())
+		if route.acquire() {
+			ch.route = route
+			ch.releases = append(ch.releases, route.release)
+		} else if !rl.dryRunRule(c, "", ConcurrencyRule) {
+			ch.deny = abortWith(http.StatusServiceUnavailable)
+			return ch
 		}
+		// Otherwise the request holds no slot, so it must not feed the
+		// latency samples of the route either.
+	}
 
-		if bucket.tokens >= 1 {
-			bucket.tokens--
-			c.Next()
-		} else {
-			if rl.config.Timeout > 0 {
-				timer := time.NewTimer(rl.config.Timeout)
-				select {
-				case <-timer.C:
-					handler := rl.config.LimitExceededHandler
-					if handler == nil {
-						handler = defaultLimitExceededHandler
-					}
-					handler(c)
-					c.Abort()
-				case <-c.Done():
-					timer.Stop()
-				}
-			} else {
-				handler := rl.config.LimitExceededHandler
-				if handler == nil {
-					handler = defaultLimitExceededHandler
-				}
+	raw := rl.rawKey(c)
+	key := rl.storeKey(raw)
+	ch.key = key
+	if !rl.sampled(key) {
+		ch.pass = true
+		return ch
+	}
+
+	if rl.bans != nil {
+		if banned := rl.bans.banned(key, time.Now()); banned > 0 && !rl.dryRunRule(c, key, BanRule) {
+			return ch.refuse(rejection{rule: BanRule, retryAfter: banned})
+		}
+	}
+	if rl.backoff != nil && rl.backoff.blocked(key, time.Now()) > 0 && !rl.dryRunRule(c, key, BackoffRule) {
+		// Hammering while blocked only makes the block longer.
+		blocked := rl.backoff.violate(key, time.Now())
+		return ch.refuse(rejection{rule: BackoffRule, retryAfter: blocked})
+	}
+	score := 1.0
+	if rl.reputation != nil {
+		score = rl.reputation.score(c, time.Now())
+		if rl.reputation.blocked(score) && !rl.dryRunRule(c, key, ReputationRule) {
+			// The score is looked up again once its cache entry expires.
+			return ch.refuse(rejection{
+				rule:       ReputationRule,
+				retryAfter: rl.reputation.config.CacheTTL,
+				status:     http.StatusForbidden,
+			})
+		}
+	}
+	if rl.dynamic != nil {
+		rl.dynamic.set(key, rl.params(c, raw), time.Now())
+	}
+
+	if rl.inFlight != nil {
+		if rl.inFlight.acquire(c.Request.Context(), key, rl.config.InFlightWait) {
+			ch.releases = append(ch.releases, func() { rl.inFlight.release(key) })
+		} else if !rl.dryRunRule(c, key, InFlightRule) {
+			handler := rl.config.InFlightExceededHandler
+			if handler == nil {
+				handler = defaultInFlightExceededHandler
+			}
+			ch.deny = func(c *gin.Context) {
 				handler(c)
 				c.Abort()
 			}
+			return ch
+		}
+	}
+	if rl.unmatched != nil && c.FullPath() == "" {
+		ch.allowed, ch.r = rl.takeUnmatched(key)
+		return ch
+	}
+
+	ch.start = time.Now()
+	if rl.hierarchy != nil {
+		ch.scopes = rl.hierarchy.keys(c)
+	}
+	ctx := c.Request.Context()
+	ch.n = 1
+	if rl.config.Cost != nil {
+		ch.n = maxInt(rl.config.Cost(c), 1)
+	}
+	if rl.reputation != nil {
+		ch.n = rl.reputation.scale(ch.n, score, rl.capacity(key, ch.scopes))
+	}
+	if !rl.fits(key, ch.n, ch.scopes) {
+		if rl.dryRunRule(c, key, CapacityRule) {
+			ch.pass = true
+			return ch
+		}
+		// No amount of waiting would admit the request, so there is no
+		// Retry-After to give either.
+		ch.deny = func(c *gin.Context) {
+			rl.denied.Add(1)
+			c.AbortWithStatus(http.StatusRequestEntityTooLarge)
+		}
+		return ch
+	}
+	var err error
+	if rl.queue != nil && !rl.config.DryRun {
+		ch.allowed, ch.r, err = rl.takeQueued(ctx, key, ch.n, ch.scopes, class.Priority, rl.queueWeight(c))
+		if err == errQueueFull {
+			ch.r.rule, ch.r.status = QueueRule, http.StatusServiceUnavailable
+			return ch.refuse(ch.r)
+		}
+	} else {
+		ch.allowed, ch.r, err = rl.takeScoped(ctx, key, ch.n, ch.scopes)
+		if err == nil && !ch.allowed && rl.config.Timeout > 0 && !rl.config.DryRun {
+			ch.allowed, ch.r, err = rl.wait(ctx, key, ch.n, ch.scopes, rl.config.Timeout, ch.r)
+		}
+	}
+	if err == nil && !ch.allowed && rl.config.Challenge != nil && !rl.config.DryRun && rl.solved(c) {
+		// A solved challenge lifts the limit of the key.
+		if err = rl.reset(ctx, key); err == nil {
+			ch.allowed, ch.r, err = rl.takeScoped(ctx, key, ch.n, ch.scopes)
+		}
+	}
+	if rl.config.ServerTiming {
+		setServerTiming(c, time.Since(ch.start))
+	}
+	if err != nil {
+		// Fail open: an unreachable store must not take the API down with it.
+		_ = c.Error(err)
+		ch.pass = true
+		return ch
+	}
+	if !ch.allowed && ctx.Err() != nil {
+		// The client is gone; there is nobody left to answer.
+		ch.deny = func(c *gin.Context) { c.Abort() }
+		return ch
+	}
+	ch.settled = true
+	return ch
+}
+
+func (ch *check) refuse(r rejection) *check {
+	ch.r = r
+	ch.deny = func(c *gin.Context) { ch.rl.refuse(c, ch.key, r) }
+	return ch
+}
+
+func abortWith(status int) func(c *gin.Context) {
+	return func(c *gin.Context) { c.AbortWithStatus(status) }
+}
+
+// denied reports whether the request is turned away. DryRun requests
+// that the buckets deny are still let through by settle.
+func (ch *check) denied() bool {
+	return ch.deny != nil || !ch.pass && !ch.allowed && !ch.rl.config.DryRun
+}
+
+// answer answers a denied request, or settles an admitted one, and
+// reports whether it may go on to the handlers.
+func (ch *check) answer(c *gin.Context) bool {
+	if ch.deny != nil {
+		ch.deny(c)
+		return false
+	}
+	if ch.pass {
+		return true
+	}
+	if !ch.rl.settle(c, ch.key, ch.allowed, ch.r) {
+		return false
+	}
+	ch.handled = time.Now()
+	return true
+}
+
+// cancel gives back the tokens of a request that a later limiter denied.
+func (ch *check) cancel(c *gin.Context) {
+	if !ch.settled || !ch.allowed {
+		return
+	}
+	if err := ch.rl.refund(c.Request.Context(), ch.key, ch.n, ch.scopes); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+// finish refunds, penalizes and samples a request that went through the
+// main path once the handlers ran.
+func (ch *check) finish(c *gin.Context) {
+	if !ch.settled {
+		return
+	}
+	rl := ch.rl
+	ctx := c.Request.Context()
+	// Only requests that took tokens may get them back afterwards.
+	if ch.allowed && !rl.counts(c) {
+		if err := rl.refund(ctx, ch.key, ch.n, ch.scopes); err != nil {
+			_ = c.Error(err)
+		}
+	}
+	status := c.Writer.Status()
+	if rl.config.AuthFailurePenalty > 0 && (status == http.StatusUnauthorized || status == http.StatusForbidden) {
+		if err := rl.penalize(ctx, ch.key, rl.config.AuthFailurePenalty); err != nil {
+			_ = c.Error(err)
+		}
+	}
+	if rl.adaptive != nil {
+		rl.adaptive.observe(status, time.Since(ch.start), time.Now())
+	}
+	if ch.route != nil {
+		ch.route.sample(time.Since(ch.handled), time.Now())
+	}
+}
+
+// release frees the concurrency slots the request holds.
+func (ch *check) release() {
+	for i := len(ch.releases) - 1; i >= 0; i-- {
+		ch.releases[i]()
+	}
+}
+
+// settle answers for the outcome of taking tokens for key, the same way
+// for the middleware and the combinators. It reports the limit that
+// denied the request, or else the main one, in LimitInfo and the headers,
+// lets DryRun admit a denial, records bans and delays the response for
+// Tarpit. A request that stays denied is answered by deny. It returns
+// whether the request may go on to the handlers.
+func (rl *RateLimiter) settle(c *gin.Context, key string, allowed bool, r rejection) bool {
+	ctx := c.Request.Context()
+	now := time.Now()
+	u, known := r.usage, r.rule != "" || r.quoted
+	if !known {
+		u, known = rl.quota(ctx, key, now)
+	}
+	if jitter := rl.jitter(); jitter > 0 {
+		if u.reset > 0 {
+			u.reset += jitter
+		}
+		if !allowed {
+			r.retryAfter += jitter
+		}
+	}
+	if rl.backoff != nil && !allowed && !rl.config.DryRun {
+		if blocked := rl.backoff.violate(key, now); blocked > r.retryAfter {
+			r.retryAfter = blocked
+		}
+	}
+	info := limitInfo(key, r, u, now)
+	c.Set(infoKey, info)
+	if known && !rl.config.DisableHeaders {
+		rl.setHeaders(c, u, now)
+	}
+	if rl.config.Debug {
+		rl.setDebugHeaders(c, key, r, u, known)
+	}
+
+	if !allowed && rl.config.DryRun {
+		rl.dryRun(c, info)
+		allowed = true
+	}
+
+	if rl.bans != nil {
+		rl.bans.record(key, !allowed, now)
+	}
+
+	if rl.config.Tarpit != nil && !rl.config.DryRun {
+		if delay := rl.config.Tarpit.delay(u, known, allowed); delay > 0 && !sleep(ctx, delay) {
+			c.Abort()
+			return false
+		}
+	}
+
+	if !allowed {
+		rl.deny(c, r, info)
+		return false
+	}
+	rl.allowed.Add(1)
+	issueVisitor(c)
+	if known && rl.config.WarningThreshold > 0 {
+		rl.warn(c, key, u, now)
+	}
+	return true
+}
+
+// deny answers a denied request with the status, Retry-After and handler
+// that the configuration and the denying rule call for.
+func (rl *RateLimiter) deny(c *gin.Context, r rejection, info LimitInfo) {
+	rl.denied.Add(1)
+	if !rl.config.DisableHeaders {
+		rl.setRetryAfter(c, r.retryAfter)
+	}
+	d := denial{status: rl.statusCode(), info: info}
+	if r.status != 0 {
+		d.status = r.status
+	}
+	if rl.config.Messages != nil {
+		d.message = message(rl.config.Messages, c.GetHeader("Accept-Language"))
+		d.message = string(renderDenial(d.message, d, func(s string) string { return s }))
+	}
+	handler := rl.config.LimitExceededHandler
+	if rl.config.LimitExceededFunc != nil {
+		handler = func(c *gin.Context) {
+			rl.config.LimitExceededFunc(c, d.info)
+		}
+	}
+	if rl.config.Challenge != nil {
+		handler = func(c *gin.Context) {
+			rl.config.Challenge.Challenge(c, d.info)
+		}
+	}
+	if r.handler != nil {
+		handler = r.handler
+	}
+	if handler == nil {
+		handler = defaultLimitExceededHandler
+		if rl.config.DenialHTML != "" && (rl.config.DenialBody == "" || acceptsHTML(c)) {
+			d.body = renderDenialHTML(rl.config.DenialHTML, d)
+			d.html = true
+		} else if rl.config.DenialBody != "" {
+			d.body = renderDenialBody(rl.config.DenialBody, d)
+		}
+	}
+	c.Set(denialKey, d)
+	handler(c)
+	c.Abort()
+}
+
+// refuse answers a request that a rule such as a ban denied before any
+// tokens were taken. It reports the rule in LimitInfo and sets the same
+// headers as settle, then lets deny answer the request.
+func (rl *RateLimiter) refuse(c *gin.Context, key string, r rejection) {
+	now := time.Now()
+	u, known := rl.quota(c.Request.Context(), key, now)
+	r.retryAfter += rl.jitter()
+	info := limitInfo(key, r, u, now)
+	c.Set(infoKey, info)
+	if known && !rl.config.DisableHeaders {
+		rl.setHeaders(c, u, now)
+	}
+	if rl.config.Debug {
+		rl.setDebugHeaders(c, key, r, u, known)
+	}
+	rl.deny(c, r, info)
+}
+
+func (rl *RateLimiter) takeScoped(ctx context.Context, key string, n int, scopes []string) (bool, rejection, error) {
+	if scopes == nil {
+		return rl.take(ctx, key, n, true)
+	}
+	now := time.Now()
+	if !rl.hierarchy.take(scopes, n, now) {
+		return false, rl.hierarchy.reject(scopes, n, now), nil
+	}
+
+	allowed, r, err := rl.take(ctx, key, n, true)
+	if err == nil && !allowed {
+		rl.hierarchy.refund(scopes, n)
+	}
+	return allowed, r, err
+}
+
+// take charges the global bucket, the stacked rules and finally the main
+// bucket of key. Tokens taken by an earlier stage are given back if a
+// later one denies, so a rejected request never consumes any capacity.
+// The rejection names the stage that denied and when it admits n tokens.
+// wait is passed on to takeKey.
+func (rl *RateLimiter) take(ctx context.Context, key string, n int, wait bool) (bool, rejection, error) {
+	now := time.Now()
+	if rl.global != nil && !rl.global.take(n, now) {
+		return false, rl.global.reject(GlobalRule, n, now), nil
+	}
+	if rl.rules != nil && !rl.rules.take(key, n, now) {
+		if rl.global != nil {
+			rl.global.refund(n)
+		}
+		return false, rl.rules.reject(key, n, now), nil
+	}
+
+	allowed, r, err := rl.takeKey(ctx, key, n, wait)
+	if err == nil && !allowed {
+		if rl.rules != nil {
+			rl.rules.refund(key, n)
+		}
+		if rl.global != nil {
+			rl.global.refund(n)
+		}
+	}
+	return allowed, r, err
+}
+
+// takeKey charges the main bucket of key. A Store that is not cached
+// locally reports the usage it read along the way, which saves quota a
+// second round trip. An Algorithm may admit the request after a delay,
+// such as LeakyBucket for a queued request; takeKey waits that out only
+// if wait is set.
+func (rl *RateLimiter) takeKey(ctx context.Context, key string, n int, wait bool) (bool, rejection, error) {
+	if rl.config.Store != nil {
+		if g, ok := rl.algorithm.(*gcra); ok {
+			return g.takeFromStore(ctx, rl.config.Store, key, n, rl.config.ExpirationDuration)
+		}
+		if rl.config.SyncInterval > 0 {
+			allowed, retryAfter, err := rl.takeHybrid(ctx, key, n)
+			return allowed, rejection{retryAfter: retryAfter}, err
+		}
+		return rl.takeFromStore(ctx, key, n)
+	}
+
+	if rl.algorithm != nil {
+		allowed, delay := rl.algorithm.Take(key, n, time.Now())
+		if !allowed {
+			return false, rejection{retryAfter: delay}, nil
+		}
+		if wait && delay > 0 {
+			timer := time.NewTimer(delay)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				return false, rejection{}, nil
+			}
 		}
+		return true, rejection{}, nil
+	}
+
+	bucket := rl.getBucket(key)
+
+	bucket.mutex.Lock()
+	defer bucket.mutex.Unlock()
+
+	now := time.Now()
+	rl.configure(bucket, key)
+	bucket.refill(now)
+
+	if bucket.tokens >= n {
+		bucket.tokens -= n
+		return true, rejection{}, nil
+	}
+	return false, rejection{retryAfter: bucket.retryAfter(n, now)}, nil
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.lastRefill)
+	refillTokens := int(elapsed.Seconds()/b.refillInterval.Seconds()) * b.refillRate
+
+	if refillTokens > 0 {
+		b.tokens = minInt(b.tokens+refillTokens, b.maxTokens)
+		b.lastRefill = now
 	}
 }
 
+// retryAfter is how long until the bucket holds n tokens, given that it
+// was refilled at now. The caller must hold the bucket's lock.
+func (b *tokenBucket) retryAfter(n int, now time.Time) time.Duration {
+	if b.tokens >= n {
+		return 0
+	}
+	refills := (n - b.tokens + b.refillRate - 1) / b.refillRate
+	return b.lastRefill.Add(time.Duration(refills) * b.refillInterval).Sub(now)
+}
+
 func minInt(a, b int) int {
 	if a < b {
 		return a
@@ -151,11 +837,184 @@ func (r *RateLimitConfig) Validate() error {
 	if r.RefillInterval <= 0 {
 		return errors.New("RefillInterval must be greater than 0")
 	}
-	if r.BurstMultiplier <= 0 {
-		return errors.New("BurstMultiplier must be greater than 0")
+	if r.RefillInterval < time.Duration(r.RefillRate) {
+		// LeakyBucket and GCRA space requests RefillInterval/RefillRate apart.
+		return errors.New("RefillRate must not exceed one token per nanosecond")
+	}
+	if r.BurstMultiplier == 0 {
+		r.BurstMultiplier = 1
+	}
+	if r.BurstMultiplier < 0 {
+		return errors.New("BurstMultiplier must not be negative")
+	}
+	if r.ExpirationDuration == 0 {
+		r.ExpirationDuration = defaultExpiration(r)
 	}
 	if r.ExpirationDuration <= r.RefillInterval {
 		return errors.New("ExpirationDuration must be greater than RefillInterval")
 	}
+	if r.SyncInterval < 0 {
+		return errors.New("SyncInterval must not be negative")
+	}
+	if r.Adaptive != nil {
+		if err := r.Adaptive.Validate(); err != nil {
+			return err
+		}
+		if r.Algorithm != "" && r.Algorithm != TokenBucket {
+			return errors.New("Adaptive is only supported by the token bucket algorithm")
+		}
+	}
+	if r.LoadShedding != nil {
+		if err := r.LoadShedding.Validate(); err != nil {
+			return err
+		}
+	}
+	if r.Backoff != nil {
+		if err := r.Backoff.Validate(); err != nil {
+			return err
+		}
+	}
+	if r.Reputation != nil {
+		if err := r.Reputation.Validate(); err != nil {
+			return err
+		}
+	}
+	if r.Tarpit != nil {
+		if err := r.Tarpit.Validate(); err != nil {
+			return err
+		}
+	}
+	if r.MaxInFlight < 0 {
+		return errors.New("MaxInFlight must not be negative")
+	}
+	if r.InFlightWait < 0 {
+		return errors.New("InFlightWait must not be negative")
+	}
+	if r.MaxQueue < 0 {
+		return errors.New("MaxQueue must not be negative")
+	}
+	if r.Timeout < 0 {
+		return errors.New("Timeout must not be negative")
+	}
+	if r.MaxQueue > 0 && r.Timeout == 0 {
+		r.Timeout = defaultQueueTimeout(r)
+	}
+	if r.FairQueuing && (r.MaxQueue == 0 || r.GlobalLimit == nil) {
+		return errors.New("FairQueuing requires MaxQueue and GlobalLimit")
+	}
+	if r.BanThreshold < 0 {
+		return errors.New("BanThreshold must not be negative")
+	}
+	if r.BanThreshold > 0 && r.BanDuration <= 0 {
+		return errors.New("BanDuration must be greater than 0")
+	}
+	if r.AuthFailurePenalty < 0 {
+		return errors.New("AuthFailurePenalty must not be negative")
+	}
+	for _, class := range r.CountStatusClasses {
+		if class < 1 || class > 5 {
+			return errors.New("CountStatusClasses must be between 1 and 5")
+		}
+	}
+	if (r.CountResponse != nil || r.RefundServerErrors || len(r.CountStatusClasses) > 0) &&
+		r.Algorithm != "" && r.Algorithm != TokenBucket {
+		return errors.New("CountResponse, RefundServerErrors and CountStatusClasses are only supported by the token bucket algorithm")
+	}
+	if r.RetryAfterJitter < 0 {
+		return errors.New("RetryAfterJitter must not be negative")
+	}
+	if r.LimitExceededHandler != nil && r.LimitExceededFunc != nil {
+		return errors.New("only one of LimitExceededHandler and LimitExceededFunc may be set")
+	}
+	if r.WarningThreshold < 0 || r.WarningThreshold > 1 {
+		return errors.New("WarningThreshold must be between 0 and 1")
+	}
+	if r.SampleRate != nil && (*r.SampleRate < 0 || *r.SampleRate > 1) {
+		return errors.New("SampleRate must be between 0 and 1")
+	}
+	if r.StatusCode != 0 && (r.StatusCode < 400 || r.StatusCode > 599) {
+		return errors.New("StatusCode must be a 4xx or 5xx status")
+	}
+	if r.HeaderFormat != "" && r.HeaderFormat != XRateLimitHeaders && r.HeaderFormat != IETFHeaders {
+		return errors.New("unknown HeaderFormat " + r.HeaderFormat)
+	}
+	if r.GlobalLimit != nil {
+		if err := r.GlobalLimit.Validate(); err != nil {
+			return errors.New("GlobalLimit." + err.Error())
+		}
+	}
+	if err := validateOverrides(r); err != nil {
+		return err
+	}
+	if err := validateSchedule(r); err != nil {
+		return err
+	}
+	if err := validateCron(r); err != nil {
+		return err
+	}
+	if r.LimitFunc != nil && r.Algorithm != "" && r.Algorithm != TokenBucket {
+		return errors.New("LimitFunc is only supported by the token bucket algorithm")
+	}
+	if r.Plans != nil {
+		if err := r.Plans.Validate(); err != nil {
+			return err
+		}
+		if r.LimitFunc != nil {
+			return errors.New("Plans and LimitFunc must not both be set")
+		}
+		if r.Algorithm != "" && r.Algorithm != TokenBucket {
+			return errors.New("Plans are only supported by the token bucket algorithm")
+		}
+	}
+	if r.Priorities != nil {
+		if err := r.Priorities.Validate(); err != nil {
+			return err
+		}
+		if r.Priorities.limited() && (r.LimitFunc != nil || r.Plans != nil) {
+			return errors.New("Priorities with a Limit must not be combined with LimitFunc or Plans")
+		}
+		if r.Priorities.limited() && r.Algorithm != "" && r.Algorithm != TokenBucket {
+			return errors.New("Priorities with a Limit are only supported by the token bucket algorithm")
+		}
+	}
+	if r.UnmatchedRoute != nil {
+		if err := r.UnmatchedRoute.Validate(); err != nil {
+			return errors.New("UnmatchedRoute." + err.Error())
+		}
+	}
+	for _, rule := range r.Rules {
+		if err := rule.Validate(); err != nil {
+			return errors.New("Rules[" + rule.Name + "]." + err.Error())
+		}
+	}
+	if err := validateScopes(r.Scopes); err != nil {
+		return err
+	}
+	if r.ConcurrencyControl != nil {
+		if err := r.ConcurrencyControl.Validate(); err != nil {
+			return err
+		}
+	}
+	if _, ok := lookupAlgorithm(r.Algorithm); !ok && r.Algorithm != "" && r.Algorithm != TokenBucket {
+		return errors.New("unknown Algorithm " + r.Algorithm)
+	}
+	if r.Algorithm != "" && r.Algorithm != TokenBucket && r.Algorithm != GCRA && r.Store != nil {
+		return errors.New("Store is only supported by the token bucket and GCRA algorithms")
+	}
+	if _, err := parseExemptNetworks(r.ExemptNetworks); err != nil {
+		return err
+	}
+	if _, err := newProxyResolver(r.TrustedProxies); err != nil {
+		return errors.New("TrustedProxies: " + err.Error())
+	}
+	if err := validateKeys(r); err != nil {
+		return err
+	}
+	if r.KeyFunc == nil {
+		r.KeyFunc = ByClientIP()
+	}
+	if r.FallbackKeyFunc == nil {
+		r.FallbackKeyFunc = ByClientIP()
+	}
 	return nil
 }
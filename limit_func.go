@@ -0,0 +1,126 @@
+package limiter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitParams are the token bucket parameters that LimitFunc returns
+// for a key.
+type RateLimitParams = Limit
+
+// LimitFunc looks up the limit of a key at request time, e.g. from the
+// customer's plan in a database. It is given the key as KeyFunc returned
+// it. Params that are not valid, such as the zero value, leave the key on
+// Overrides or the main config.
+type LimitFunc func(c *gin.Context, key string) RateLimitParams
+
+// dynamicLimits remembers what LimitFunc last returned for each key, so
+// that the bucket of the key and calls without a gin.Context such as
+// Allow see the same limit.
+type dynamicLimits struct {
+	limits map[string]dynamicLimit
+	mutex  sync.Mutex
+}
+
+type dynamicLimit struct {
+	limit Limit
+	seen  time.Time
+}
+
+func newDynamicLimits() *dynamicLimits {
+	return &dynamicLimits{limits: make(map[string]dynamicLimit)}
+}
+
+func (d *dynamicLimits) set(key string, params RateLimitParams, now time.Time) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if params.Validate() != nil {
+		delete(d.limits, key)
+		return
+	}
+	d.limits[key] = dynamicLimit{limit: params, seen: now}
+}
+
+func (d *dynamicLimits) get(key string) (Limit, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	l, ok := d.limits[key]
+	return l.limit, ok
+}
+
+func (d *dynamicLimits) remove(key string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	delete(d.limits, key)
+}
+
+func (d *dynamicLimits) cleanup(now time.Time, expiration time.Duration) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for key, l := range d.limits {
+		if now.Sub(l.seen) > expiration {
+			delete(d.limits, key)
+		}
+	}
+}
+
+// params returns what LimitFunc, Plans or the priority class of c give
+// for the raw key of c.
+func (rl *RateLimiter) params(c *gin.Context, key string) RateLimitParams {
+	if rl.plans != nil {
+		return rl.plans.params(c, key)
+	}
+	if rl.config.LimitFunc != nil {
+		return rl.config.LimitFunc(c, key)
+	}
+	class, _, _ := rl.priority(c)
+	return class.Limit
+}
+
+// limitFor returns the limit that replaces the main config for key, if
+// LimitFunc, Plans, Priorities, Overrides, the Schedule or Cron give one.
+func (rl *RateLimiter) limitFor(key string) (Limit, bool) {
+	if rl.dynamic != nil {
+		if limit, ok := rl.dynamic.get(key); ok {
+			return limit, true
+		}
+	}
+	l := rl.limits()
+	if limit, ok := l.overrides[key]; ok {
+		return limit, true
+	}
+	now := time.Now()
+	if limit, ok := l.schedule.limit(now); ok {
+		return limit, true
+	}
+	if l.cron != nil {
+		return l.cron.limit(now)
+	}
+	return Limit{}, false
+}
+
+// configure brings an existing bucket of key up to date with its limit,
+// which adaptive limiting or LimitFunc may have changed since the bucket
+// was created. The caller must hold the bucket's lock.
+func (rl *RateLimiter) configure(bucket *tokenBucket, key string) {
+	limit, ok := rl.limitFor(key)
+	if !ok {
+		l := rl.limits()
+		limit = Limit{
+			MaxTokens:      l.maxTokens * l.burstMultiplier,
+			RefillRate:     rl.refillRate(),
+			RefillInterval: l.refillInterval,
+		}
+	}
+	bucket.maxTokens = limit.MaxTokens
+	bucket.refillRate = limit.RefillRate
+	bucket.refillInterval = limit.RefillInterval
+	bucket.tokens = minInt(bucket.tokens, bucket.maxTokens)
+}
@@ -0,0 +1,73 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterLimitFunc(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	// 模拟数据库中的客户限额
+	plans := map[string]int{"acme": 3}
+	config := RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            ByHeader("X-API-Key"),
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		LimitFunc: func(c *gin.Context, key string) RateLimitParams {
+			if n, ok := plans[key]; ok {
+				return RateLimitParams{MaxTokens: n, RefillRate: n, RefillInterval: time.Minute}
+			}
+			return RateLimitParams{}
+		},
+	}
+
+	limiter, err := New(config)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiter.RateLimitMiddleware())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func(apiKey string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		req.Header.Set("X-API-Key", apiKey)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 回调返回的限额生效
+	w := request("acme")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "3", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, http.StatusOK, request("acme").Code)
+	assert.Equal(t, http.StatusOK, request("acme").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request("acme").Code)
+
+	// 零值参数使用默认配置
+	assert.Equal(t, http.StatusOK, request("other").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request("other").Code)
+
+	// 限额在请求时更新
+	plans["acme"] = 1
+	w = request("acme")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Limit"))
+
+	// 只支持令牌桶算法
+	config.Algorithm = SlidingWindowCounter
+	assert.Error(t, config.Validate())
+}
@@ -99,12 +99,12 @@ func TestRateLimiter_ValidateErrors(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name: "BurstMultiplier is zero",
+			name: "BurstMultiplier is negative",
 			config: RateLimitConfig{
 				MaxTokens:          1,
 				RefillRate:         1,
 				RefillInterval:     time.Second,
-				BurstMultiplier:    0,
+				BurstMultiplier:    -1,
 				ExpirationDuration: time.Minute * 5,
 			},
 			wantErr: true,
@@ -270,3 +270,44 @@ func TestRateLimiterNoTimeout(t *testing.T) {
 	router.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusTooManyRequests, w.Code) // 默认返回 429
 }
+
+func TestRateLimiterDefaultKeyFunc(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	// 未设置 KeyFunc 的配置
+	config := RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Second,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+	}
+
+	// Validate 会补全默认的 KeyFunc
+	assert.NoError(t, config.Validate())
+	assert.NotNil(t, config.KeyFunc)
+
+	config.KeyFunc = nil
+	limiterMiddleware, err := NewRateLimiter(config)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func(clientIP string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = clientIP + ":1234"
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 默认按客户端 IP 限流
+	assert.Equal(t, http.StatusOK, request("192.168.1.1"))
+	assert.Equal(t, http.StatusTooManyRequests, request("192.168.1.1"))
+	assert.Equal(t, http.StatusOK, request("192.168.1.2"))
+}
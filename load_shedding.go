@@ -0,0 +1,104 @@
+package limiter
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"runtime"
+	"runtime/metrics"
+	"sync"
+	"time"
+)
+
+// LoadSheddingRule is the Rule reported in LimitInfo when DryRun admits a request
+// that LoadShedding would have rejected.
+const LoadSheddingRule = "load_shedding"
+
+// LoadSheddingConfig rejects a share of all requests with 503 while the
+// process is overloaded, before any per-key bucket is consulted. The share
+// grows linearly from 0 at the threshold to 1 at full CPU or at twice the
+// memory threshold. A zero threshold disables that signal.
+type LoadSheddingConfig struct {
+	CPUThreshold    float64
+	MemoryThreshold uint64
+	SampleInterval  time.Duration
+}
+
+func (l *LoadSheddingConfig) Validate() error {
+	if l.CPUThreshold < 0 || l.CPUThreshold >= 1 {
+		return errors.New("LoadShedding.CPUThreshold must be between 0 and 1")
+	}
+	if l.CPUThreshold == 0 && l.MemoryThreshold == 0 {
+		return errors.New("LoadShedding needs a CPUThreshold or a MemoryThreshold")
+	}
+	if l.SampleInterval <= 0 {
+		return errors.New("LoadShedding.SampleInterval must be greater than 0")
+	}
+	return nil
+}
+
+type loadShedder struct {
+	config     LoadSheddingConfig
+	sample     func(now time.Time) (cpu float64, memory uint64)
+	ratio      float64
+	sampledAt  time.Time
+	lastCPU    time.Duration
+	lastSample time.Time
+	mutex      sync.Mutex
+}
+
+func newLoadShedder(config LoadSheddingConfig) *loadShedder {
+	l := &loadShedder{config: config}
+	l.sample = l.runtimeSample
+	return l
+}
+
+// runtimeSample reports the share of available CPU time used by the process
+// since the previous sample, and the memory mapped by the Go runtime. CPU
+// time comes from the operating system rather than the runtime's own
+// estimates, which are only refreshed by garbage collections.
+func (l *loadShedder) runtimeSample(now time.Time) (float64, uint64) {
+	samples := []metrics.Sample{{Name: "/memory/classes/total:bytes"}}
+	metrics.Read(samples)
+
+	busy, ok := processCPUTime()
+	var cpu float64
+	if ok && !l.lastSample.IsZero() {
+		available := now.Sub(l.lastSample).Seconds() * float64(runtime.GOMAXPROCS(0))
+		if available > 0 {
+			cpu = (busy - l.lastCPU).Seconds() / available
+		}
+	}
+	l.lastCPU = busy
+	l.lastSample = now
+	return cpu, samples[0].Value.Uint64()
+}
+
+func (l *loadShedder) shed(now time.Time) bool {
+	return l.shedRank(now, 0, 1)
+}
+
+// shedRank is shed for a request of the given rank among ranks priority
+// levels, 0 being the lowest. Overload is shed from the lowest rank up:
+// all of rank 0 is rejected before rank 1 loses anything.
+func (l *loadShedder) shedRank(now time.Time, rank, ranks int) bool {
+	l.mutex.Lock()
+	if now.Sub(l.sampledAt) >= l.config.SampleInterval {
+		cpu, memory := l.sample(now)
+		l.ratio = 0
+		if l.config.CPUThreshold > 0 && cpu > l.config.CPUThreshold {
+			l.ratio = (cpu - l.config.CPUThreshold) / (1 - l.config.CPUThreshold)
+		}
+		if l.config.MemoryThreshold > 0 && memory > l.config.MemoryThreshold {
+			over := float64(memory-l.config.MemoryThreshold) / float64(l.config.MemoryThreshold)
+			l.ratio = math.Max(l.ratio, over)
+		}
+		l.ratio = math.Min(l.ratio, 1)
+		l.sampledAt = now
+	}
+	ratio := l.ratio
+	l.mutex.Unlock()
+
+	share := math.Min(math.Max(ratio*float64(ranks)-float64(rank), 0), 1)
+	return share > 0 && rand.Float64() < share
+}
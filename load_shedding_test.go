@@ -0,0 +1,50 @@
+package limiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadShedder_RejectsWhenOverloaded(t *testing.T) {
+	shedder := newLoadShedder(LoadSheddingConfig{
+		CPUThreshold:    0.8,
+		MemoryThreshold: 1000,
+		SampleInterval:  time.Second,
+	})
+	cpu, memory := 0.5, uint64(500)
+	shedder.sample = func(now time.Time) (float64, uint64) { return cpu, memory }
+	now := time.Now()
+
+	// 低于阈值时从不拒绝
+	assert.False(t, shedder.shed(now))
+
+	// 内存达到阈值的两倍时拒绝全部请求
+	memory = 2000
+	assert.True(t, shedder.shed(now.Add(time.Second)))
+	assert.Equal(t, 1.0, shedder.ratio)
+
+	// CPU 超出阈值一半时拒绝约一半请求
+	cpu, memory = 0.9, 500
+	shedder.shed(now.Add(time.Second * 2))
+	assert.InDelta(t, 0.5, shedder.ratio, 0.0001)
+}
+
+func TestLoadShedder_RuntimeSample(t *testing.T) {
+	shedder := newLoadShedder(LoadSheddingConfig{MemoryThreshold: 1, SampleInterval: time.Second})
+	now := time.Now()
+
+	shedder.runtimeSample(now)
+	cpu, memory := shedder.runtimeSample(now.Add(time.Second))
+	assert.GreaterOrEqual(t, cpu, 0.0)
+	assert.Greater(t, memory, uint64(0))
+
+	// 两次垃圾回收之间的 CPU 占用也能被观测到
+	start := time.Now()
+	shedder.runtimeSample(start)
+	for time.Since(start) < 50*time.Millisecond {
+	}
+	cpu, _ = shedder.runtimeSample(time.Now())
+	assert.Greater(t, cpu, 0.0)
+}
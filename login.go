@@ -0,0 +1,140 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoginProtectionConfig limits login attempts per account and client IP.
+// Each pair gets MaxAttempts, restored evenly over Window. A failed
+// attempt, one answered with 401 or 403, costs FailurePenalty extra
+// tokens, and LockoutThreshold failures in a row lock the pair out for
+// LockoutDuration. A successful login clears the failures. The failures
+// must fit in MaxAttempts, LockoutThreshold*(1+FailurePenalty) at most,
+// or the limit would stop them before the lockout could trigger.
+type LoginProtectionConfig struct {
+	Account          func(*gin.Context) string
+	MaxAttempts      int
+	Window           time.Duration
+	FailurePenalty   int
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+}
+
+func (l *LoginProtectionConfig) Validate() error {
+	if l.Account == nil {
+		return errors.New("Account must not be nil")
+	}
+	if l.MaxAttempts <= 0 {
+		return errors.New("MaxAttempts must be greater than 0")
+	}
+	if l.Window < time.Duration(l.MaxAttempts) {
+		return errors.New("Window must be at least MaxAttempts nanoseconds")
+	}
+	if l.FailurePenalty < 0 {
+		return errors.New("FailurePenalty must not be negative")
+	}
+	if l.LockoutThreshold <= 0 {
+		return errors.New("LockoutThreshold must be greater than 0")
+	}
+	if l.LockoutDuration <= 0 {
+		return errors.New("LockoutDuration must be greater than 0")
+	}
+	if l.LockoutThreshold*(1+l.FailurePenalty) > l.MaxAttempts {
+		return errors.New("LockoutThreshold failures must fit in MaxAttempts")
+	}
+	return nil
+}
+
+// PresetLoginProtection allows 10 attempts per account and IP every 15
+// minutes, charges failures double and locks a pair out for 15 minutes
+// after 5 failures in a row.
+func PresetLoginProtection(account func(*gin.Context) string) LoginProtectionConfig {
+	return LoginProtectionConfig{
+		Account:          account,
+		MaxAttempts:      10,
+		Window:           15 * time.Minute,
+		FailurePenalty:   1,
+		LockoutThreshold: 5,
+		LockoutDuration:  15 * time.Minute,
+	}
+}
+
+// LoginProtection guards login endpoints against brute force.
+type LoginProtection struct {
+	limiter  *RateLimiter
+	lockouts *banList
+}
+
+func NewLoginProtection(config LoginProtectionConfig) (*LoginProtection, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	clientIP := ByClientIP()
+	refillInterval := config.Window / time.Duration(config.MaxAttempts)
+	limiter, err := New(RateLimitConfig{
+		MaxTokens:      config.MaxAttempts,
+		RefillRate:     1,
+		RefillInterval: refillInterval,
+		KeyFunc: func(c *gin.Context) string {
+			return loginKey(config.Account(c), clientIP(c))
+		},
+		BurstMultiplier:    1,
+		ExpirationDuration: config.Window + refillInterval,
+		AuthFailurePenalty: config.FailurePenalty,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginProtection{
+		limiter:  limiter,
+		lockouts: newBanList(config.LockoutThreshold, config.LockoutDuration),
+	}, nil
+}
+
+func loginKey(account, ip string) string {
+	return account + "|" + ip
+}
+
+func (lp *LoginProtection) RateLimitMiddleware() gin.HandlerFunc {
+	limit := lp.limiter.RateLimitMiddleware()
+	return func(c *gin.Context) {
+		key := lp.limiter.storeKey(lp.limiter.rawKey(c))
+		if locked := lp.lockouts.banned(key, time.Now()); locked > 0 {
+			lp.limiter.denied.Add(1)
+			lp.limiter.setRetryAfter(c, locked)
+			c.AbortWithStatus(lp.limiter.statusCode())
+			return
+		}
+
+		limit(c)
+
+		switch c.Writer.Status() {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			lp.lockouts.record(key, true, time.Now())
+		case http.StatusTooManyRequests:
+			// Denied by the limit, so no login was attempted.
+		default:
+			lp.lockouts.record(key, false, time.Now())
+		}
+	}
+}
+
+// Unlock lifts the lockout and the limit of account from ip, e.g. after
+// the user proved their identity by resetting the password.
+func (lp *LoginProtection) Unlock(ctx context.Context, account, ip string) error {
+	key := loginKey(account, ipPrefix(ip, 32, IPv6Prefix))
+	lp.lockouts.record(lp.limiter.storeKey(key), false, time.Now())
+	return lp.limiter.Reset(ctx, key)
+}
+
+func (lp *LoginProtection) CleanupExpiredBuckets() {
+	lp.limiter.CleanupExpiredBuckets()
+	lp.lockouts.cleanup(time.Now(), lp.limiter.config.ExpirationDuration)
+}
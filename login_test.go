@@ -0,0 +1,88 @@
+package limiter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginProtection(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	protection, err := NewLoginProtection(LoginProtectionConfig{
+		Account:          ByHeader("X-Account"),
+		MaxAttempts:      6,
+		Window:           time.Hour,
+		FailurePenalty:   1,
+		LockoutThreshold: 3,
+		LockoutDuration:  time.Minute,
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(protection.RateLimitMiddleware())
+	router.POST("/login", func(c *gin.Context) {
+		if c.GetHeader("X-Password") != "secret" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.String(http.StatusOK, "welcome")
+	})
+
+	login := func(account, password, ip string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/login", nil)
+		req.RemoteAddr = ip + ":1234"
+		req.Header.Set("X-Account", account)
+		req.Header.Set("X-Password", password)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 失败的尝试消耗双倍令牌
+	assert.Equal(t, http.StatusUnauthorized, login("alice", "guess", "192.168.1.1").Code)
+	assert.Equal(t, http.StatusUnauthorized, login("alice", "guess", "192.168.1.1").Code)
+	assert.Equal(t, http.StatusOK, login("alice", "secret", "192.168.1.1").Code)
+	assert.Equal(t, http.StatusOK, login("alice", "secret", "192.168.1.1").Code)
+	w := login("alice", "secret", "192.168.1.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "600", w.Header().Get("Retry-After"))
+
+	// 其他账户和其他 IP 不受影响
+	assert.Equal(t, http.StatusOK, login("bob", "secret", "192.168.1.1").Code)
+	assert.Equal(t, http.StatusOK, login("alice", "secret", "192.168.1.2").Code)
+
+	// 解锁后恢复
+	assert.NoError(t, protection.Unlock(context.Background(), "alice", "192.168.1.1"))
+	assert.Equal(t, http.StatusOK, login("alice", "secret", "192.168.1.1").Code)
+	assert.NoError(t, protection.Unlock(context.Background(), "alice", "192.168.1.1"))
+
+	// 连续失败达到阈值后锁定，锁定时间而不是限额决定 Retry-After
+	assert.Equal(t, http.StatusUnauthorized, login("carol", "guess", "192.168.1.1").Code)
+	assert.Equal(t, http.StatusUnauthorized, login("carol", "guess", "192.168.1.1").Code)
+	assert.Equal(t, http.StatusUnauthorized, login("carol", "guess", "192.168.1.1").Code)
+	w = login("carol", "secret", "192.168.1.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	// 无效的配置会被拒绝
+	_, err = NewLoginProtection(LoginProtectionConfig{MaxAttempts: 5, Window: time.Minute})
+	assert.Error(t, err)
+	_, err = NewLoginProtection(LoginProtectionConfig{
+		Account:          ByHeader("X-Account"),
+		MaxAttempts:      5,
+		Window:           time.Minute,
+		FailurePenalty:   1,
+		LockoutThreshold: 3,
+		LockoutDuration:  time.Minute,
+	})
+	assert.EqualError(t, err, "LockoutThreshold failures must fit in MaxAttempts")
+	_, err = NewLoginProtection(PresetLoginProtection(ByHeader("X-Account")))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,44 @@
+// Package maxmind resolves client IPs with MaxMind GeoIP2 and GeoLite2
+// databases, for the ASN and country selectors of the limiter.
+package maxmind
+
+import (
+	"net"
+
+	limiter "github.com/colommar/gin-ratelimiter"
+	"github.com/oschwald/geoip2-golang"
+)
+
+var _ limiter.ASNResolver = (*ASNDatabase)(nil)
+
+// ASNDatabase is a limiter.ASNResolver backed by a MaxMind GeoLite2-ASN or
+// GeoIP2-ISP database.
+type ASNDatabase struct {
+	reader asnReader
+}
+
+// asnReader is the part of geoip2.Reader that ASNDatabase uses.
+type asnReader interface {
+	ASN(ip net.IP) (*geoip2.ASN, error)
+	Close() error
+}
+
+func OpenASN(path string) (*ASNDatabase, error) {
+	reader, err := geoip2.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &ASNDatabase{reader: reader}, nil
+}
+
+func (d *ASNDatabase) ASN(ip net.IP) (uint, error) {
+	record, err := d.reader.ASN(ip)
+	if err != nil {
+		return 0, err
+	}
+	return record.AutonomousSystemNumber, nil
+}
+
+func (d *ASNDatabase) Close() error {
+	return d.reader.Close()
+}
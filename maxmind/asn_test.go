@@ -0,0 +1,62 @@
+package maxmind
+
+import (
+	"errors"
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oschwald/geoip2-golang"
+	"github.com/stretchr/testify/assert"
+
+	limiter "github.com/colommar/gin-ratelimiter"
+)
+
+// fakeASNReader 按 IP 返回预设的自治系统号
+type fakeASNReader struct {
+	asns   map[string]uint
+	closed bool
+}
+
+func (r *fakeASNReader) ASN(ip net.IP) (*geoip2.ASN, error) {
+	asn, ok := r.asns[ip.String()]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &geoip2.ASN{AutonomousSystemNumber: asn}, nil
+}
+
+func (r *fakeASNReader) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestASNDatabase(t *testing.T) {
+	reader := &fakeASNReader{asns: map[string]uint{"203.0.113.7": 64500}}
+	db := &ASNDatabase{reader: reader}
+
+	asn, err := db.ASN(net.ParseIP("203.0.113.7"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint(64500), asn)
+
+	// 查询失败时返回错误和 0
+	asn, err = db.ASN(net.ParseIP("198.51.100.1"))
+	assert.Error(t, err)
+	assert.Equal(t, uint(0), asn)
+
+	// 作为 ByASN 的解析器按自治系统生成键
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	c.Request.RemoteAddr = "203.0.113.7:1234"
+	assert.Equal(t, "AS64500", limiter.ByASN(db)(c))
+
+	assert.NoError(t, db.Close())
+	assert.True(t, reader.closed)
+}
+
+func TestOpenASNMissingFile(t *testing.T) {
+	_, err := OpenASN("testdata/missing.mmdb")
+	assert.Error(t, err)
+}
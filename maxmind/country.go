@@ -0,0 +1,145 @@
+package maxmind
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	limiter "github.com/colommar/gin-ratelimiter"
+	"github.com/oschwald/geoip2-golang"
+)
+
+var _ limiter.CountryResolver = (*CountryDatabase)(nil)
+
+var errClosed = errors.New("maxmind: database is closed")
+
+// CountryDatabase is a limiter.CountryResolver backed by a MaxMind GeoIP2
+// or GeoLite2 Country or City database. The file is opened again once it
+// is older than refresh, so updated databases are picked up without a
+// restart; if that fails the previous one stays in use. A refresh of 0
+// never reopens it.
+//
+// Reopening happens in the background: lookups keep using the previous
+// reader until the new one is swapped in, and the previous one is closed
+// once the lookups still using it are done.
+type CountryDatabase struct {
+	path      string
+	refresh   time.Duration
+	open      func(path string) (countryReader, error)
+	current   atomic.Pointer[countryHandle]
+	loadedAt  atomic.Int64
+	reloading atomic.Bool
+	closed    bool
+	mutex     sync.Mutex
+}
+
+// countryReader is the part of geoip2.Reader that CountryDatabase uses.
+type countryReader interface {
+	Country(ip net.IP) (*geoip2.Country, error)
+	Close() error
+}
+
+// countryHandle guards one reader, so that it is not closed under a lookup.
+type countryHandle struct {
+	reader countryReader
+	closed bool
+	mutex  sync.RWMutex
+}
+
+func OpenCountry(path string, refresh time.Duration) (*CountryDatabase, error) {
+	return openCountry(path, refresh, func(path string) (countryReader, error) {
+		reader, err := geoip2.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		return reader, nil
+	})
+}
+
+func openCountry(path string, refresh time.Duration, open func(string) (countryReader, error)) (*CountryDatabase, error) {
+	reader, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	d := &CountryDatabase{path: path, refresh: refresh, open: open}
+	d.current.Store(&countryHandle{reader: reader})
+	d.loadedAt.Store(time.Now().UnixNano())
+	return d, nil
+}
+
+func (d *CountryDatabase) Country(ip net.IP) (string, error) {
+	d.reloadIfStale(time.Now())
+
+	for {
+		h := d.current.Load()
+		h.mutex.RLock()
+		if h.closed {
+			h.mutex.RUnlock()
+			// A reload replaced it in the meantime: use the new one.
+			if h != d.current.Load() {
+				continue
+			}
+			return "", errClosed
+		}
+		record, err := h.reader.Country(ip)
+		h.mutex.RUnlock()
+		if err != nil {
+			return "", err
+		}
+		return record.Country.IsoCode, nil
+	}
+}
+
+// reloadIfStale starts a reload once the database is older than refresh,
+// unless one is already running.
+func (d *CountryDatabase) reloadIfStale(now time.Time) {
+	if d.refresh <= 0 || now.Sub(time.Unix(0, d.loadedAt.Load())) < d.refresh {
+		return
+	}
+	if !d.reloading.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer d.reloading.Store(false)
+		d.reload(now)
+	}()
+}
+
+func (d *CountryDatabase) reload(now time.Time) {
+	d.loadedAt.Store(now.UnixNano())
+	reader, err := d.open(d.path)
+	if err != nil {
+		return
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.closed {
+		_ = reader.Close()
+		return
+	}
+	_ = d.current.Swap(&countryHandle{reader: reader}).close()
+}
+
+func (d *CountryDatabase) Close() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.closed = true
+	return d.current.Load().close()
+}
+
+// close waits for the lookups using h and then closes its reader.
+func (h *countryHandle) close() error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.closed {
+		return nil
+	}
+	h.closed = true
+	return h.reader.Close()
+}
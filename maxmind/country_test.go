@@ -0,0 +1,176 @@
+package maxmind
+
+import (
+	"errors"
+	"net"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oschwald/geoip2-golang"
+	"github.com/stretchr/testify/assert"
+
+	limiter "github.com/colommar/gin-ratelimiter"
+)
+
+// fakeCountryReader 对所有 IP 返回同一个国家，关闭后查询报错
+type fakeCountryReader struct {
+	country string
+	closed  atomic.Bool
+}
+
+func (r *fakeCountryReader) Country(ip net.IP) (*geoip2.Country, error) {
+	if r.closed.Load() {
+		return nil, errors.New("closed")
+	}
+	if ip.String() == "198.51.100.1" {
+		return nil, errors.New("not found")
+	}
+	record := &geoip2.Country{}
+	record.Country.IsoCode = r.country
+	return record, nil
+}
+
+func (r *fakeCountryReader) Close() error {
+	r.closed.Store(true)
+	return nil
+}
+
+// openReaders 依次打开预设的读取器
+func openReaders(readers ...countryReader) func(string) (countryReader, error) {
+	var mutex sync.Mutex
+	return func(string) (countryReader, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		reader := readers[0]
+		readers = readers[1:]
+		if reader == nil {
+			return nil, errors.New("unreadable")
+		}
+		return reader, nil
+	}
+}
+
+func TestCountryDatabase(t *testing.T) {
+	reader := &fakeCountryReader{country: "DE"}
+	db, err := openCountry("GeoLite2-Country.mmdb", 0, openReaders(reader))
+	assert.NoError(t, err)
+
+	country, err := db.Country(net.ParseIP("203.0.113.7"))
+	assert.NoError(t, err)
+	assert.Equal(t, "DE", country)
+
+	// 查询失败时返回错误
+	_, err = db.Country(net.ParseIP("198.51.100.1"))
+	assert.Error(t, err)
+
+	// 作为 CountryClass 的解析器划分类别
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	c.Request.RemoteAddr = "203.0.113.7:1234"
+	assert.Equal(t, "eu", limiter.CountryClass(db, map[string]string{"DE": "eu"})(c))
+
+	// 关闭后查询返回错误
+	assert.NoError(t, db.Close())
+	assert.True(t, reader.closed.Load())
+	_, err = db.Country(net.ParseIP("203.0.113.7"))
+	assert.Equal(t, errClosed, err)
+
+	_, err = openCountry("missing.mmdb", 0, openReaders(nil))
+	assert.Error(t, err)
+}
+
+func TestCountryDatabaseReloadsInBackground(t *testing.T) {
+	first := &fakeCountryReader{country: "DE"}
+	second := &fakeCountryReader{country: "FR"}
+	release := make(chan struct{})
+	var opens atomic.Int32
+	open := openReaders(first, second)
+	db, err := openCountry("GeoLite2-Country.mmdb", time.Minute, func(path string) (countryReader, error) {
+		if opens.Add(1) > 1 {
+			<-release
+		}
+		return open(path)
+	})
+	assert.NoError(t, err)
+	db.loadedAt.Store(time.Now().Add(-time.Hour).UnixNano())
+
+	// 重新打开阻塞时查询不等待，继续使用旧的数据库
+	ip := net.ParseIP("203.0.113.7")
+	country, err := db.Country(ip)
+	assert.NoError(t, err)
+	assert.Equal(t, "DE", country)
+
+	// 同一时间只有一次重新打开
+	_, _ = db.Country(ip)
+	assert.Eventually(t, func() bool { return opens.Load() == 2 }, time.Second, time.Millisecond)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		country, _ := db.Country(ip)
+		return country == "FR"
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int32(2), opens.Load())
+	assert.True(t, first.closed.Load())
+	assert.False(t, second.closed.Load())
+}
+
+func TestCountryDatabaseReloadFailure(t *testing.T) {
+	first := &fakeCountryReader{country: "DE"}
+	db, err := openCountry("GeoLite2-Country.mmdb", time.Minute, openReaders(first, nil))
+	assert.NoError(t, err)
+
+	// 重新打开失败时继续使用旧的数据库
+	db.reload(time.Now())
+	country, err := db.Country(net.ParseIP("203.0.113.7"))
+	assert.NoError(t, err)
+	assert.Equal(t, "DE", country)
+	assert.False(t, first.closed.Load())
+}
+
+func TestCountryDatabaseReloadAfterClose(t *testing.T) {
+	first := &fakeCountryReader{country: "DE"}
+	second := &fakeCountryReader{country: "FR"}
+	db, err := openCountry("GeoLite2-Country.mmdb", time.Minute, openReaders(first, second))
+	assert.NoError(t, err)
+
+	// 关闭后完成的重新打开不会替换数据库，新读取器被关闭
+	assert.NoError(t, db.Close())
+	db.reload(time.Now())
+	assert.True(t, second.closed.Load())
+	_, err = db.Country(net.ParseIP("203.0.113.7"))
+	assert.Equal(t, errClosed, err)
+}
+
+func TestCountryDatabaseConcurrentReload(t *testing.T) {
+	readers := make([]countryReader, 51)
+	for i := range readers {
+		readers[i] = &fakeCountryReader{country: "DE"}
+	}
+	db, err := openCountry("GeoLite2-Country.mmdb", time.Minute, openReaders(readers...))
+	assert.NoError(t, err)
+
+	// 替换读取器时进行中的查询不会用到已关闭的读取器
+	var wg sync.WaitGroup
+	var failures atomic.Int32
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				if _, err := db.Country(net.ParseIP("203.0.113.7")); err != nil {
+					failures.Add(1)
+				}
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		db.reload(time.Now())
+	}
+	wg.Wait()
+	assert.Equal(t, int32(0), failures.Load())
+}
@@ -0,0 +1,56 @@
+package limiter
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// message picks the entry of messages that best matches an Accept-Language
+// header, trying each language by preference first as given and then by
+// its primary subtag ("de-CH" falls back to "de"). It falls back to "en".
+func message(messages map[string]string, acceptLanguage string) string {
+	type language struct {
+		tag     string
+		quality float64
+	}
+
+	var languages []language
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+		if tag != "" && tag != "*" && quality > 0 {
+			languages = append(languages, language{strings.ToLower(tag), quality})
+		}
+	}
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+
+	for _, l := range languages {
+		if msg, ok := lookupMessage(messages, l.tag); ok {
+			return msg
+		}
+		if primary, _, found := strings.Cut(l.tag, "-"); found {
+			if msg, ok := lookupMessage(messages, primary); ok {
+				return msg
+			}
+		}
+	}
+	msg, _ := lookupMessage(messages, "en")
+	return msg
+}
+
+func lookupMessage(messages map[string]string, tag string) (string, bool) {
+	for lang, msg := range messages {
+		if strings.EqualFold(lang, tag) {
+			return msg, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,70 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageNegotiation(t *testing.T) {
+	messages := map[string]string{
+		"en":    "Too many requests",
+		"zh-CN": "请求过多",
+		"de":    "Zu viele Anfragen",
+	}
+
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"zh-CN,zh;q=0.9,en;q=0.8", "请求过多"},
+		{"de-CH", "Zu viele Anfragen"},
+		{"fr;q=0.9, de;q=0.5", "Zu viele Anfragen"},
+		{"en;q=0.1, de", "Zu viele Anfragen"},
+		// 没有匹配的语言时回退到英文
+		{"fr", "Too many requests"},
+		{"", "Too many requests"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, message(messages, tt.acceptLanguage), tt.acceptLanguage)
+	}
+}
+
+func TestLocalizedDenial(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Messages: map[string]string{
+			"en": "Too many requests, retry in {retry_after} seconds.",
+			"zh": "请求过多，请在 {retry_after} 秒后重试。",
+		},
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+	req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "请求过多，请在 60 秒后重试。", w.Body.String())
+}
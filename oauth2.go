@@ -0,0 +1,252 @@
+package limiter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TokenIntrospector resolves the OAuth2 client an access token was issued
+// to. It returns "" for inactive tokens.
+type TokenIntrospector interface {
+	ClientID(ctx context.Context, token string) (string, error)
+}
+
+// CachingIntrospector is implemented by TokenIntrospectors that cache
+// their answers. ByOAuth2ClientID does not charge the lookup budget of a
+// client IP for tokens that CachedClientID already knows.
+type CachingIntrospector interface {
+	TokenIntrospector
+	CachedClientID(token string) (clientID string, ok bool)
+}
+
+const (
+	// introspectionCacheSize bounds the answers an Introspector keeps.
+	introspectionCacheSize = 10000
+	// introspectionRetry is how long a failed introspection is cached,
+	// so an unavailable endpoint is not asked again on every request.
+	introspectionRetry = 5 * time.Second
+)
+
+// errIntrospectionBudget is added to the context when the client IP has
+// used up its introspections for the minute.
+var errIntrospectionBudget = errors.New("limiter: token introspection: too many lookups from client IP")
+
+type introspection struct {
+	clientID string
+	err      error
+	expires  time.Time
+}
+
+// introspectionCall is a lookup in progress that later callers for the
+// same token wait for instead of asking the endpoint again. It is
+// canceled once all of its callers have gone.
+type introspectionCall struct {
+	done     chan struct{}
+	cancel   context.CancelFunc
+	waiters  int
+	clientID string
+	err      error
+}
+
+// Introspector is a TokenIntrospector that asks an RFC 7662 introspection
+// endpoint, authenticating with the resource server's own client
+// credentials. Answers, including inactive tokens, are cached for ttl and
+// failures for a few seconds, so a busy client costs one call per token
+// and ttl rather than one per request. Concurrent lookups of one token
+// share a single call, which is canceled once all of them have gone, and
+// at most 10000 answers are kept.
+type Introspector struct {
+	endpoint     string
+	clientID     string
+	clientSecret string
+	ttl          time.Duration
+	client       *http.Client
+	cache        map[string]introspection
+	calls        map[string]*introspectionCall
+	swept        time.Time
+	mutex        sync.Mutex
+}
+
+func NewIntrospector(endpoint, clientID, clientSecret string, ttl time.Duration) *Introspector {
+	return &Introspector{
+		endpoint:     endpoint,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		ttl:          ttl,
+		client:       &http.Client{Timeout: time.Second * 10},
+		cache:        make(map[string]introspection),
+		calls:        make(map[string]*introspectionCall),
+	}
+}
+
+func (i *Introspector) ClientID(ctx context.Context, token string) (string, error) {
+	now := time.Now()
+	i.mutex.Lock()
+	if cached, ok := i.cache[token]; ok && now.Before(cached.expires) {
+		i.mutex.Unlock()
+		return cached.clientID, cached.err
+	}
+	call, ok := i.calls[token]
+	if !ok {
+		lookup, cancel := context.WithCancel(context.Background())
+		call = &introspectionCall{done: make(chan struct{}), cancel: cancel}
+		i.calls[token] = call
+		go i.lookup(lookup, call, token, now)
+	}
+	call.waiters++
+	i.mutex.Unlock()
+
+	select {
+	case <-call.done:
+		return call.clientID, call.err
+	case <-ctx.Done():
+		i.mutex.Lock()
+		if call.waiters--; call.waiters == 0 && i.calls[token] == call {
+			// Nobody is left to use the answer, and later callers start
+			// a lookup of their own.
+			delete(i.calls, token)
+			call.cancel()
+		}
+		i.mutex.Unlock()
+		return "", ctx.Err()
+	}
+}
+
+// lookup runs call for everyone waiting on it and caches the answer,
+// unless the call was canceled.
+func (i *Introspector) lookup(ctx context.Context, call *introspectionCall, token string, now time.Time) {
+	defer call.cancel()
+	clientID, expires, err := i.introspect(ctx, token, now)
+
+	i.mutex.Lock()
+	call.clientID, call.err = clientID, err
+	if i.calls[token] == call {
+		delete(i.calls, token)
+		i.store(token, introspection{clientID: clientID, err: err, expires: expires}, now)
+	}
+	i.mutex.Unlock()
+	close(call.done)
+}
+
+// CachedClientID returns the cached answer for token without asking the
+// endpoint. Cached failures are reported as "".
+func (i *Introspector) CachedClientID(token string) (string, bool) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	cached, ok := i.cache[token]
+	if !ok || !time.Now().Before(cached.expires) {
+		return "", false
+	}
+	return cached.clientID, true
+}
+
+func (i *Introspector) introspect(ctx context.Context, token string, now time.Time) (string, time.Time, error) {
+	retry := now.Add(introspectionRetry)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.endpoint,
+		strings.NewReader(url.Values{"token": {token}, "token_type_hint": {"access_token"}}.Encode()))
+	if err != nil {
+		return "", retry, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(i.clientID), url.QueryEscape(i.clientSecret))
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return "", retry, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", retry, errors.New("limiter: token introspection: " + resp.Status)
+	}
+
+	var result struct {
+		Active   bool   `json:"active"`
+		ClientID string `json:"client_id"`
+		Exp      int64  `json:"exp"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", retry, err
+	}
+	if !result.Active {
+		result.ClientID = ""
+	}
+
+	expires := now.Add(i.ttl)
+	if result.Exp > 0 && time.Unix(result.Exp, 0).Before(expires) {
+		expires = time.Unix(result.Exp, 0)
+	}
+	return result.ClientID, expires, nil
+}
+
+// store caches answer for token. When the cache is full, expired answers
+// are swept at most once a second and otherwise an arbitrary answer is
+// evicted, so a flood of unknown tokens costs O(1) per lookup.
+func (i *Introspector) store(token string, answer introspection, now time.Time) {
+	if !now.Before(answer.expires) {
+		return
+	}
+	if len(i.cache) >= introspectionCacheSize && now.Sub(i.swept) >= time.Second {
+		i.swept = now
+		for token, cached := range i.cache {
+			if !now.Before(cached.expires) {
+				delete(i.cache, token)
+			}
+		}
+	}
+	if len(i.cache) >= introspectionCacheSize {
+		for token := range i.cache {
+			delete(i.cache, token)
+			break
+		}
+	}
+	i.cache[token] = answer
+}
+
+// ByOAuth2ClientID keys requests by the OAuth2 client that the bearer
+// token was issued to, so limits apply per registered application. Tokens
+// that are missing, inactive or cannot be introspected are keyed by client
+// IP like ByClientIP instead. Each client IP may cause at most maxLookups
+// introspections per minute and is keyed by IP beyond that, so random
+// tokens cannot flood the endpoint; tokens already cached by a
+// CachingIntrospector, such as Introspector, do not count. JWT access
+// tokens that carry the client in a claim are better keyed with
+// ByJWTClaim("client_id", keys), which needs no introspection.
+func ByOAuth2ClientID(introspector TokenIntrospector, maxLookups int) func(*gin.Context) string {
+	bearer := ByAuthHeaderBearer()
+	clientIP := ByClientIP()
+	lookups := newIPQuota(maxLookups, time.Minute)
+	cache, caching := introspector.(CachingIntrospector)
+	return func(c *gin.Context) string {
+		ip := clientIP(c)
+		token := bearer(c)
+		if token == "" {
+			return ip
+		}
+		clientID, cached := "", false
+		if caching {
+			clientID, cached = cache.CachedClientID(token)
+		}
+		if !cached {
+			if !lookups.allow(ip, time.Now()) {
+				_ = c.Error(errIntrospectionBudget)
+				return ip
+			}
+			var err error
+			if clientID, err = introspector.ClientID(c.Request.Context(), token); err != nil {
+				_ = c.Error(err)
+			}
+		}
+		if clientID == "" {
+			return ip
+		}
+		return clientID
+	}
+}
@@ -0,0 +1,148 @@
+package limiter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestByOAuth2ClientID(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		user, password, _ := r.BasicAuth()
+		assert.Equal(t, "gateway", user)
+		assert.Equal(t, "s3cret", password)
+		assert.NoError(t, r.ParseForm())
+		switch r.PostForm.Get("token") {
+		case "app-token":
+			_ = json.NewEncoder(w).Encode(map[string]any{"active": true, "client_id": "billing-app"})
+		case "revoked-token":
+			_ = json.NewEncoder(w).Encode(map[string]any{"active": false, "client_id": "billing-app"})
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	keyFunc := ByOAuth2ClientID(NewIntrospector(server.URL, "gateway", "s3cret", time.Minute), 4)
+
+	// 有效令牌按客户端 ID 生成键，且结果被缓存
+	assert.Equal(t, "billing-app", jwtKey("app-token", keyFunc))
+	assert.Equal(t, "billing-app", jwtKey("app-token", keyFunc))
+	assert.Equal(t, 1, calls)
+
+	// 失效的令牌和内省失败回退到客户端 IP
+	assert.Equal(t, "192.168.1.1", jwtKey("revoked-token", keyFunc))
+	assert.Equal(t, "192.168.1.1", jwtKey("broken-token", keyFunc))
+	assert.Equal(t, "192.168.1.1", jwtKey("", keyFunc))
+	assert.Equal(t, 3, calls)
+
+	// 失败结果也会短暂缓存
+	assert.Equal(t, "192.168.1.1", jwtKey("broken-token", keyFunc))
+	assert.Equal(t, 3, calls)
+
+	// 每个 IP 每分钟的内省次数用完后，未缓存的令牌直接按 IP 生成键
+	assert.Equal(t, "192.168.1.1", jwtKey("other-token", keyFunc))
+	assert.Equal(t, "192.168.1.1", jwtKey("random-token", keyFunc))
+	assert.Equal(t, 4, calls)
+	assert.Equal(t, "billing-app", jwtKey("app-token", keyFunc))
+}
+
+func TestIntrospectorSharesConcurrentLookups(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		_ = json.NewEncoder(w).Encode(map[string]any{"active": true, "client_id": "billing-app"})
+	}))
+	defer server.Close()
+
+	introspector := NewIntrospector(server.URL, "gateway", "s3cret", time.Minute)
+
+	// 同一令牌的并发查询只请求一次内省端点
+	var wg sync.WaitGroup
+	results := make([]string, 5)
+	for n := range results {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			results[n], _ = introspector.ClientID(context.Background(), "app-token")
+		}(n)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, []string{"billing-app", "billing-app", "billing-app", "billing-app", "billing-app"}, results)
+}
+
+func TestByOAuth2ClientIDBudget(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	// 自定义的内省实现同样受每个 IP 的查询次数限制
+	var calls int
+	introspector := introspectorFunc(func(ctx context.Context, token string) (string, error) {
+		calls++
+		return "app-" + token, nil
+	})
+	keyFunc := ByOAuth2ClientID(introspector, 2)
+
+	assert.Equal(t, "app-a", jwtKey("a", keyFunc))
+	assert.Equal(t, "app-b", jwtKey("b", keyFunc))
+	assert.Equal(t, "192.168.1.1", jwtKey("c", keyFunc))
+	assert.Equal(t, 2, calls)
+}
+
+type introspectorFunc func(ctx context.Context, token string) (string, error)
+
+func (f introspectorFunc) ClientID(ctx context.Context, token string) (string, error) {
+	return f(ctx, token)
+}
+
+func TestIntrospectorCancelsAbandonedLookups(t *testing.T) {
+	canceled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 读完请求体后服务端才能察觉客户端断开
+		_ = r.ParseForm()
+		<-r.Context().Done()
+		close(canceled)
+	}))
+	defer server.Close()
+
+	introspector := NewIntrospector(server.URL, "gateway", "s3cret", time.Minute)
+
+	// 所有等待查询的请求都取消后，内省请求也随之取消
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := introspector.ClientID(ctx, "app-token")
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	assert.Error(t, <-done)
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("introspection was not canceled")
+	}
+
+	// 取消的查询不会被缓存
+	_, cached := introspector.CachedClientID("app-token")
+	assert.False(t, cached)
+}
@@ -0,0 +1,48 @@
+package limiter
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+)
+
+func validateOverrides(r *RateLimitConfig) error {
+	if len(r.Overrides) > 0 && r.Algorithm != "" && r.Algorithm != TokenBucket {
+		return errors.New("Overrides are only supported by the token bucket algorithm")
+	}
+	for key, limit := range r.Overrides {
+		if err := limit.Validate(); err != nil {
+			return errors.New("Overrides[" + key + "]." + err.Error())
+		}
+	}
+	return nil
+}
+
+// LoadOverrides reads Overrides from a JSON file that maps keys to limits:
+//
+//	{"partner-key": {"max_tokens": 1000, "refill_rate": 1000, "refill_interval": "1m"}}
+func LoadOverrides(path string) (map[string]Limit, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file map[string]struct {
+		MaxTokens      int    `json:"max_tokens"`
+		RefillRate     int    `json:"refill_rate"`
+		RefillInterval string `json:"refill_interval"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	overrides := make(map[string]Limit, len(file))
+	for key, limit := range file {
+		interval, err := time.ParseDuration(limit.RefillInterval)
+		if err != nil {
+			return nil, errors.New("Overrides[" + key + "].RefillInterval: " + err.Error())
+		}
+		overrides[key] = Limit{MaxTokens: limit.MaxTokens, RefillRate: limit.RefillRate, RefillInterval: interval}
+	}
+	return overrides, nil
+}
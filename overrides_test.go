@@ -0,0 +1,101 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterOverrides(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	config := RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            ByHeader("X-API-Key"),
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Overrides: map[string]Limit{
+			"partner": {MaxTokens: 3, RefillRate: 3, RefillInterval: time.Minute},
+		},
+	}
+
+	limiter, err := New(config)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiter.RateLimitMiddleware())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func(apiKey string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		req.Header.Set("X-API-Key", apiKey)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 覆盖配置的键使用自己的限额
+	w := request("partner")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "3", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, http.StatusOK, request("partner").Code)
+	assert.Equal(t, http.StatusOK, request("partner").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request("partner").Code)
+
+	// 其他键使用默认配置
+	assert.Equal(t, http.StatusOK, request("other").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request("other").Code)
+
+	// 补充令牌时同样使用覆盖配置的速率
+	for _, key := range []string{"partner", "other"} {
+		bucket := limiter.getBucket(key)
+		bucket.mutex.Lock()
+		bucket.lastRefill = bucket.lastRefill.Add(-time.Minute)
+		bucket.mutex.Unlock()
+	}
+	assert.Equal(t, http.StatusOK, request("partner").Code)
+	assert.Equal(t, http.StatusOK, request("partner").Code)
+	assert.Equal(t, http.StatusOK, request("partner").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request("partner").Code)
+	assert.Equal(t, http.StatusOK, request("other").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request("other").Code)
+
+	// 预留按键自己的容量检查
+	assert.True(t, limiter.ReserveN("partner", 3).OK())
+	assert.False(t, limiter.ReserveN("other", 3).OK())
+
+	// 无效的覆盖配置会被拒绝
+	config.Overrides = map[string]Limit{"bad": {}}
+	assert.Error(t, config.Validate())
+	config.Overrides = map[string]Limit{"partner": {MaxTokens: 3, RefillRate: 3, RefillInterval: time.Minute}}
+	config.Algorithm = SlidingWindowCounter
+	assert.Error(t, config.Validate())
+}
+
+func TestLoadOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overrides.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"partner": {"max_tokens": 1000, "refill_rate": 100, "refill_interval": "1m"}}`), 0o600))
+
+	overrides, err := LoadOverrides(path)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Limit{"partner": {MaxTokens: 1000, RefillRate: 100, RefillInterval: time.Minute}}, overrides)
+
+	// 无效的文件返回错误
+	assert.NoError(t, os.WriteFile(path, []byte(`{"partner": {"refill_interval": "soon"}}`), 0o600))
+	_, err = LoadOverrides(path)
+	assert.Error(t, err)
+	_, err = LoadOverrides(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
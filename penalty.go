@@ -0,0 +1,78 @@
+package limiter
+
+import (
+	"context"
+	"time"
+)
+
+// penalize takes up to n more tokens from key after a failed login, so
+// brute-force attempts run out of budget faster than regular traffic. The
+// buckets are drained at most to empty; unlike a request a penalty is never
+// denied.
+func (rl *RateLimiter) penalize(ctx context.Context, key string, n int) error {
+	now := time.Now()
+	if rl.rules != nil {
+		rl.rules.drain(key, n, now)
+	}
+
+	if rl.config.Store != nil && rl.config.SyncInterval == 0 {
+		if g, ok := rl.algorithm.(*gcra); ok {
+			_, _, err := g.takeFromStore(ctx, rl.config.Store, key, n, rl.config.ExpirationDuration)
+			return err
+		}
+		return rl.drainStore(ctx, key, n)
+	}
+	if rl.algorithm != nil {
+		rl.algorithm.Take(key, n, now)
+		return nil
+	}
+
+	bucket := rl.getBucket(key)
+	bucket.mutex.Lock()
+	defer bucket.mutex.Unlock()
+
+	rl.configure(bucket, key)
+	bucket.refill(now)
+	drained := minInt(n, maxInt(bucket.tokens, 0))
+	bucket.tokens -= drained
+	if rl.config.Store != nil {
+		// Pushed to the store on the next sync.
+		bucket.pending += drained
+	}
+	return nil
+}
+
+func (rl *RateLimiter) drainStore(ctx context.Context, key string, n int) error {
+	for attempt := 0; attempt < maxStoreAttempts; attempt++ {
+		value, revision, err := rl.config.Store.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		bucket := rl.newBucket(key, now)
+		if value != nil {
+			bucket.decode(value)
+		}
+		bucket.refill(now)
+		bucket.tokens = maxInt(bucket.tokens-n, 0)
+
+		ok, err := rl.config.Store.CompareAndSwap(ctx, key, revision, bucket.encode(), rl.config.ExpirationDuration)
+		if err != nil || ok {
+			return err
+		}
+	}
+	return ErrStoreContention
+}
+
+func (s *stackedRules) drain(key string, n int, now time.Time) {
+	set := s.get(key, now)
+
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+
+	for _, bucket := range set.buckets {
+		bucket.refill(now)
+		bucket.tokens = maxInt(bucket.tokens-n, 0)
+	}
+}
@@ -0,0 +1,56 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthFailurePenalty(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	for _, store := range []Store{nil, newMapStore()} {
+		limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+			MaxTokens:          10,
+			RefillRate:         1,
+			RefillInterval:     time.Minute,
+			KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+			BurstMultiplier:    1,
+			ExpirationDuration: time.Minute * 5,
+			Store:              store,
+			AuthFailurePenalty: 4,
+		})
+		assert.NoError(t, err)
+
+		router := gin.New()
+		router.Use(limiterMiddleware)
+		router.POST("/login", func(c *gin.Context) {
+			if c.GetHeader("Authorization") != "secret" {
+				c.AbortWithStatus(http.StatusUnauthorized)
+				return
+			}
+			c.String(http.StatusOK, "welcome")
+		})
+
+		// 成功的请求只消耗一个令牌
+		req, _ := http.NewRequest("POST", "/login", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		// 每次认证失败额外扣除四个令牌
+		req.Header.Set("Authorization", "guess")
+		for _, want := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests} {
+			w = httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, want, w.Code)
+		}
+	}
+}
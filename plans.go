@@ -0,0 +1,196 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PlanResolver maps a key, such as an API key or account ID, to the name
+// of its plan tier, e.g. "free", "pro" or "enterprise".
+type PlanResolver interface {
+	Plan(ctx context.Context, key string) (string, error)
+}
+
+// PlanFunc adapts a function to a PlanResolver.
+type PlanFunc func(ctx context.Context, key string) (string, error)
+
+func (f PlanFunc) Plan(ctx context.Context, key string) (string, error) {
+	return f(ctx, key)
+}
+
+// PlanConfig gives each plan tier its own token bucket parameters. Keys
+// whose plan has no tier, or whose lookup fails, get the Default tier, or
+// the main config if Default is "". Concurrent lookups of one key share a
+// single call to the Resolver, which runs for up to ten seconds even if
+// the request that started it ends sooner.
+type PlanConfig struct {
+	Resolver PlanResolver
+	Tiers    map[string]RateLimitParams
+	Default  string
+	// CacheTTL is how long a key's plan is cached. 0 disables caching, so
+	// every request asks the Resolver. Failed lookups are not cached.
+	CacheTTL time.Duration
+}
+
+// planLookupTimeout bounds a shared lookup, which does not end with the
+// request that started it.
+const planLookupTimeout = 10 * time.Second
+
+func (p *PlanConfig) Validate() error {
+	if p.Resolver == nil {
+		return errors.New("Plans.Resolver must not be nil")
+	}
+	if len(p.Tiers) == 0 {
+		return errors.New("Plans.Tiers must not be empty")
+	}
+	for name, tier := range p.Tiers {
+		if err := tier.Validate(); err != nil {
+			return errors.New("Plans.Tiers[" + name + "]." + err.Error())
+		}
+	}
+	if _, ok := p.Tiers[p.Default]; p.Default != "" && !ok {
+		return errors.New("Plans.Default must name one of the Tiers")
+	}
+	if p.CacheTTL < 0 {
+		return errors.New("Plans.CacheTTL must not be negative")
+	}
+	return nil
+}
+
+type cachedPlan struct {
+	plan    string
+	expires time.Time
+}
+
+// planCall is a lookup in progress that later callers for the same key
+// wait for instead of asking the Resolver again.
+type planCall struct {
+	done  chan struct{}
+	plan  string
+	err   error
+	stale bool
+}
+
+type plans struct {
+	config PlanConfig
+	cache  map[string]cachedPlan
+	calls  map[string]*planCall
+	mutex  sync.Mutex
+}
+
+func newPlans(config PlanConfig) *plans {
+	return &plans{
+		config: config,
+		cache:  make(map[string]cachedPlan),
+		calls:  make(map[string]*planCall),
+	}
+}
+
+// params returns the tier of the plan of key. A failed lookup is recorded
+// on c and treated like an unknown plan.
+func (p *plans) params(c *gin.Context, key string) RateLimitParams {
+	plan, err := p.plan(c.Request.Context(), key, time.Now())
+	if err != nil {
+		_ = c.Error(err)
+	}
+	if tier, ok := p.config.Tiers[plan]; ok && err == nil {
+		return tier
+	}
+	return p.config.Tiers[p.config.Default]
+}
+
+func (p *plans) plan(ctx context.Context, key string, now time.Time) (plan string, err error) {
+	p.mutex.Lock()
+	if cached, ok := p.cache[key]; ok && now.Before(cached.expires) {
+		p.mutex.Unlock()
+		return cached.plan, nil
+	}
+	if call, ok := p.calls[key]; ok {
+		p.mutex.Unlock()
+		select {
+		case <-call.done:
+			return call.plan, call.err
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	call := &planCall{done: make(chan struct{})}
+	p.calls[key] = call
+	p.mutex.Unlock()
+
+	defer func() {
+		// A panicking Resolver fails the lookup like an error does, so the
+		// key falls back to the default tier instead of crashing.
+		if recovered := recover(); recovered != nil {
+			call.plan, call.err = "", fmt.Errorf("limiter: plan lookup panicked: %v", recovered)
+		}
+		p.mutex.Lock()
+		delete(p.calls, key)
+		if call.err == nil && !call.stale && p.config.CacheTTL > 0 {
+			p.cache[key] = cachedPlan{plan: call.plan, expires: now.Add(p.config.CacheTTL)}
+		}
+		p.mutex.Unlock()
+		close(call.done)
+		plan, err = call.plan, call.err
+	}()
+	lookup, cancel := context.WithTimeout(context.Background(), planLookupTimeout)
+	defer cancel()
+	call.plan, call.err = p.config.Resolver.Plan(lookup, key)
+	return call.plan, call.err
+}
+
+// invalidate forgets the cached plan of key, including one that a lookup
+// in progress would cache.
+func (p *plans) invalidate(key string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	delete(p.cache, key)
+	if call, ok := p.calls[key]; ok {
+		call.stale = true
+	}
+}
+
+func (p *plans) cleanup(now time.Time) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for key, cached := range p.cache {
+		if !now.Before(cached.expires) {
+			delete(p.cache, key)
+		}
+	}
+}
+
+// InvalidatePlan makes the next request of key look its plan up again
+// instead of using the cached one, e.g. right after the customer upgraded.
+// key is the key as KeyFunc returns it.
+func (rl *RateLimiter) InvalidatePlan(key string) {
+	if rl.plans == nil {
+		return
+	}
+	rl.plans.invalidate(key)
+	rl.dynamic.remove(rl.storeKey(key))
+}
+
+// ListenPlanInvalidations calls InvalidatePlan for every key received
+// from keys until ctx is done or keys is closed, so that plan changes
+// published by another service reach every instance.
+func (rl *RateLimiter) ListenPlanInvalidations(ctx context.Context, keys <-chan string) {
+	for {
+		select {
+		case key, ok := <-keys:
+			if !ok {
+				return
+			}
+			rl.InvalidatePlan(key)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
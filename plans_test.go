@@ -0,0 +1,281 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterPlans(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	// 模拟订阅信息
+	subscriptions := map[string]string{"alice": "pro", "bob": "free"}
+	config := RateLimitConfig{
+		MaxTokens:          10,
+		RefillRate:         10,
+		RefillInterval:     time.Minute,
+		KeyFunc:            ByHeader("X-API-Key"),
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Plans: &PlanConfig{
+			Resolver: PlanFunc(func(ctx context.Context, key string) (string, error) {
+				if key == "broken" {
+					return "", errors.New("lookup failed")
+				}
+				return subscriptions[key], nil
+			}),
+			Tiers: map[string]RateLimitParams{
+				"free": {MaxTokens: 1, RefillRate: 1, RefillInterval: time.Minute},
+				"pro":  {MaxTokens: 3, RefillRate: 3, RefillInterval: time.Minute},
+			},
+			Default: "free",
+		},
+	}
+
+	limiterMiddleware, err := NewRateLimiter(config)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func(apiKey string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		req.Header.Set("X-API-Key", apiKey)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 每个等级使用自己的限额
+	assert.Equal(t, "3", request("alice").Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", request("bob").Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, http.StatusTooManyRequests, request("bob").Code)
+
+	// 未知计划和查询失败使用默认等级
+	assert.Equal(t, "1", request("carol").Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", request("broken").Header().Get("X-RateLimit-Limit"))
+
+	// 无效的配置会被拒绝
+	tests := []struct {
+		name   string
+		modify func(*RateLimitConfig)
+	}{
+		{"没有解析器", func(c *RateLimitConfig) { c.Plans = &PlanConfig{Tiers: config.Plans.Tiers} }},
+		{"没有等级", func(c *RateLimitConfig) { c.Plans = &PlanConfig{Resolver: config.Plans.Resolver} }},
+		{"未知的默认等级", func(c *RateLimitConfig) {
+			c.Plans = &PlanConfig{Resolver: config.Plans.Resolver, Tiers: config.Plans.Tiers, Default: "gold"}
+		}},
+		{"同时设置 LimitFunc", func(c *RateLimitConfig) {
+			c.LimitFunc = func(*gin.Context, string) RateLimitParams { return RateLimitParams{} }
+		}},
+	}
+	for _, tt := range tests {
+		invalid := config
+		tt.modify(&invalid)
+		assert.Error(t, invalid.Validate(), tt.name)
+	}
+}
+
+func TestPlansCache(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	p := newPlans(PlanConfig{
+		Resolver: PlanFunc(func(ctx context.Context, key string) (string, error) {
+			calls.Add(1)
+			<-release
+			return "pro", nil
+		}),
+		Tiers:    map[string]RateLimitParams{"pro": {MaxTokens: 1, RefillRate: 1, RefillInterval: time.Minute}},
+		CacheTTL: time.Minute,
+	})
+
+	// 并发查询同一个键只调用一次解析器
+	now := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			plan, err := p.plan(context.Background(), "alice", now)
+			assert.NoError(t, err)
+			assert.Equal(t, "pro", plan)
+		}()
+	}
+	for {
+		p.mutex.Lock()
+		_, started := p.calls["alice"]
+		p.mutex.Unlock()
+		if started {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), calls.Load())
+
+	// 缓存有效期内不再查询
+	_, _ = p.plan(context.Background(), "alice", now.Add(30*time.Second))
+	assert.Equal(t, int32(1), calls.Load())
+
+	// 过期后重新查询
+	_, _ = p.plan(context.Background(), "alice", now.Add(2*time.Minute))
+	assert.Equal(t, int32(2), calls.Load())
+
+	// 清理过期的缓存
+	p.cleanup(now.Add(10 * time.Minute))
+	assert.Empty(t, p.cache)
+}
+
+func TestPlansSharedLookup(t *testing.T) {
+	release := make(chan struct{})
+	p := newPlans(PlanConfig{
+		Resolver: PlanFunc(func(ctx context.Context, key string) (string, error) {
+			_, ok := ctx.Deadline()
+			assert.True(t, ok)
+			<-release
+			return "pro", ctx.Err()
+		}),
+		Tiers: map[string]RateLimitParams{"pro": {MaxTokens: 1, RefillRate: 1, RefillInterval: time.Minute}},
+	})
+
+	// 发起查询的请求取消后，等待同一查询的请求仍然得到结果
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { _, _ = p.plan(ctx, "alice", time.Now()) }()
+	for {
+		p.mutex.Lock()
+		_, started := p.calls["alice"]
+		p.mutex.Unlock()
+		if started {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	done := make(chan string)
+	go func() {
+		plan, err := p.plan(context.Background(), "alice", time.Now())
+		assert.NoError(t, err)
+		done <- plan
+	}()
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	assert.Equal(t, "pro", <-done)
+
+	// CacheTTL 为 0 时不缓存
+	assert.Empty(t, p.cache)
+}
+
+func TestPlansPanickingResolver(t *testing.T) {
+	release := make(chan struct{})
+	p := newPlans(PlanConfig{
+		Resolver: PlanFunc(func(ctx context.Context, key string) (string, error) {
+			<-release
+			panic("resolver bug")
+		}),
+		Tiers:   map[string]RateLimitParams{"free": {MaxTokens: 1, RefillRate: 1, RefillInterval: time.Minute}},
+		Default: "free",
+	})
+
+	// 解析器 panic 时查询失败，等待同一查询的请求也得到错误
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := p.plan(context.Background(), "alice", time.Now())
+			errs <- err
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	assert.Error(t, <-errs)
+	assert.Error(t, <-errs)
+	assert.Empty(t, p.calls)
+}
+
+func TestRateLimiterInvalidatePlan(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	var mutex sync.Mutex
+	subscriptions := map[string]string{"alice": "free"}
+	limiter, err := New(RateLimitConfig{
+		MaxTokens:          10,
+		RefillRate:         10,
+		RefillInterval:     time.Minute,
+		KeyFunc:            ByHeader("X-API-Key"),
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Plans: &PlanConfig{
+			Resolver: PlanFunc(func(ctx context.Context, key string) (string, error) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				return subscriptions[key], nil
+			}),
+			Tiers: map[string]RateLimitParams{
+				"free": {MaxTokens: 1, RefillRate: 1, RefillInterval: time.Minute},
+				"pro":  {MaxTokens: 3, RefillRate: 3, RefillInterval: time.Minute},
+			},
+			CacheTTL: time.Hour,
+		},
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiter.RateLimitMiddleware())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	limit := func() string {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		req.Header.Set("X-API-Key", "alice")
+		router.ServeHTTP(w, req)
+		return w.Header().Get("X-RateLimit-Limit")
+	}
+	upgrade := func(plan string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		subscriptions["alice"] = plan
+	}
+
+	// 升级后缓存的计划仍然有效
+	assert.Equal(t, "1", limit())
+	upgrade("pro")
+	assert.Equal(t, "1", limit())
+
+	// 失效后立即使用新计划
+	limiter.InvalidatePlan("alice")
+	assert.Equal(t, "3", limit())
+
+	// 通过通道接收失效通知
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	keys := make(chan string)
+	done := make(chan struct{})
+	go func() {
+		limiter.ListenPlanInvalidations(ctx, keys)
+		close(done)
+	}()
+	upgrade("free")
+	keys <- "alice"
+	close(keys)
+	<-done
+	assert.Equal(t, "1", limit())
+}
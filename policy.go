@@ -0,0 +1,63 @@
+package limiter
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PolicySet applies a different limiter configuration to each class of
+// request, e.g. stricter limits for clients on hosting networks. The
+// selector names the class of a request; classes without a policy use the
+// policy named "", and are not limited if there is none.
+type PolicySet struct {
+	selector func(*gin.Context) string
+	limiters map[string]*RateLimiter
+}
+
+func NewPolicySet(selector func(*gin.Context) string, policies map[string]RateLimitConfig) (*PolicySet, error) {
+	if selector == nil {
+		return nil, errors.New("selector must not be nil")
+	}
+	p := &PolicySet{
+		selector: selector,
+		limiters: make(map[string]*RateLimiter, len(policies)),
+	}
+	for class, config := range policies {
+		limiter, err := New(config)
+		if err != nil {
+			return nil, errors.New("policies[" + class + "]." + err.Error())
+		}
+		p.limiters[class] = limiter
+	}
+	return p, nil
+}
+
+// Limiter returns the limiter of a class, or nil if it has no policy.
+func (p *PolicySet) Limiter(class string) *RateLimiter {
+	return p.limiters[class]
+}
+
+func (p *PolicySet) CleanupExpiredBuckets() {
+	for _, limiter := range p.limiters {
+		limiter.CleanupExpiredBuckets()
+	}
+}
+
+func (p *PolicySet) RateLimitMiddleware() gin.HandlerFunc {
+	middlewares := make(map[string]gin.HandlerFunc, len(p.limiters))
+	for class, limiter := range p.limiters {
+		middlewares[class] = limiter.RateLimitMiddleware()
+	}
+	return func(c *gin.Context) {
+		middleware, ok := middlewares[p.selector(c)]
+		if !ok {
+			middleware, ok = middlewares[""]
+		}
+		if !ok {
+			c.Next()
+			return
+		}
+		middleware(c)
+	}
+}
@@ -0,0 +1,66 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicySet(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	policy := func(maxTokens int) RateLimitConfig {
+		return RateLimitConfig{
+			MaxTokens:          maxTokens,
+			RefillRate:         1,
+			RefillInterval:     time.Minute,
+			KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+			BurstMultiplier:    1,
+			ExpirationDuration: time.Minute * 5,
+		}
+	}
+
+	policies, err := NewPolicySet(ByHeader("X-Class"), map[string]RateLimitConfig{
+		"strict": policy(1),
+		"":       policy(2),
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(policies.RateLimitMiddleware())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func(class string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		req.Header.Set("X-Class", class)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 每个类别使用各自的配置
+	assert.Equal(t, http.StatusOK, request("strict"))
+	assert.Equal(t, http.StatusTooManyRequests, request("strict"))
+
+	// 未知类别使用默认配置
+	assert.Equal(t, http.StatusOK, request("unknown"))
+	assert.Equal(t, http.StatusOK, request(""))
+	assert.Equal(t, http.StatusTooManyRequests, request("other"))
+
+	assert.NotNil(t, policies.Limiter("strict"))
+	assert.Nil(t, policies.Limiter("missing"))
+
+	// 无效的配置会被拒绝
+	_, err = NewPolicySet(ByHeader("X-Class"), map[string]RateLimitConfig{"bad": {}})
+	assert.Error(t, err)
+	_, err = NewPolicySet(nil, nil)
+	assert.Error(t, err)
+}
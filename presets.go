@@ -0,0 +1,56 @@
+package limiter
+
+import "time"
+
+// PresetPublicAPI allows each client IP a steady 1 request per second
+// with bursts of up to 60, a reasonable default for a public JSON API.
+func PresetPublicAPI() RateLimitConfig {
+	return RateLimitConfig{
+		MaxTokens:          60,
+		RefillRate:         1,
+		RefillInterval:     time.Second,
+		BurstMultiplier:    1,
+		ExpirationDuration: 10 * time.Minute,
+	}
+}
+
+// PresetLogin allows each client IP 5 attempts and then 1 more per
+// minute, for login, signup and password reset endpoints. Failed
+// attempts, answered with 401 or 403, cost a second token.
+func PresetLogin() RateLimitConfig {
+	return RateLimitConfig{
+		MaxTokens:          5,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Hour,
+		AuthFailurePenalty: 1,
+	}
+}
+
+// PresetWebhookReceiver absorbs the bursts in which providers deliver
+// webhooks: 100 at once, or 200 after a quiet spell, and 50 per second
+// sustained per sender. Deliveries that fail with a server error are not
+// counted, since the provider will retry them.
+func PresetWebhookReceiver() RateLimitConfig {
+	return RateLimitConfig{
+		MaxTokens:          100,
+		RefillRate:         50,
+		RefillInterval:     time.Second,
+		BurstMultiplier:    2,
+		ExpirationDuration: 5 * time.Minute,
+		RefundServerErrors: true,
+	}
+}
+
+// PresetStrict allows each client IP 10 requests per minute with no
+// burst beyond that, for expensive endpoints such as exports or search.
+func PresetStrict() RateLimitConfig {
+	return RateLimitConfig{
+		MaxTokens:          10,
+		RefillRate:         1,
+		RefillInterval:     6 * time.Second,
+		BurstMultiplier:    1,
+		ExpirationDuration: 10 * time.Minute,
+	}
+}
@@ -0,0 +1,54 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresets(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		config RateLimitConfig
+		burst  int
+	}{
+		{"PublicAPI", PresetPublicAPI(), 60},
+		{"Login", PresetLogin(), 5},
+		{"WebhookReceiver", PresetWebhookReceiver(), 100},
+		{"Strict", PresetStrict(), 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 预设配置可以直接使用
+			limiterMiddleware, err := NewRateLimiter(tt.config)
+			assert.NoError(t, err)
+
+			router := gin.New()
+			router.Use(limiterMiddleware)
+			router.GET("/", func(c *gin.Context) {
+				c.String(http.StatusOK, "Hello, world!")
+			})
+
+			// 初始突发量用完后拒绝请求
+			for i := 0; i < tt.burst; i++ {
+				w := httptest.NewRecorder()
+				req, _ := http.NewRequest("GET", "/", nil)
+				req.RemoteAddr = "192.168.1.1:1234"
+				router.ServeHTTP(w, req)
+				assert.Equal(t, http.StatusOK, w.Code)
+			}
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/", nil)
+			req.RemoteAddr = "192.168.1.1:1234"
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package limiter
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PriorityClass is how one class of traffic is treated. Classes with a
+// higher Priority survive overload longer: LoadShedding rejects the
+// lowest priority first. Limit, unless zero, replaces the main bucket
+// parameters for the requests of the class.
+type PriorityClass struct {
+	Priority int
+	Limit    RateLimitParams
+}
+
+// PriorityConfig sorts requests into classes such as gold, silver and
+// bronze. Classify names the class of a request; requests of a class
+// that is not in Classes get priority 0 and the main config.
+type PriorityConfig struct {
+	Classify func(*gin.Context) string
+	Classes  map[string]PriorityClass
+}
+
+func (p *PriorityConfig) Validate() error {
+	if p.Classify == nil {
+		return errors.New("Priorities.Classify must not be nil")
+	}
+	if len(p.Classes) == 0 {
+		return errors.New("Priorities.Classes must not be empty")
+	}
+	for name, class := range p.Classes {
+		if class.Limit == (RateLimitParams{}) {
+			continue
+		}
+		if err := class.Limit.Validate(); err != nil {
+			return errors.New("Priorities.Classes[" + name + "].Limit." + err.Error())
+		}
+	}
+	return nil
+}
+
+// limited reports whether any class has a Limit of its own.
+func (p *PriorityConfig) limited() bool {
+	for _, class := range p.Classes {
+		if class.Limit != (RateLimitParams{}) {
+			return true
+		}
+	}
+	return false
+}
+
+// priorityKey is the gin.Context key of the PriorityClass of a request.
+const priorityKey = "limiter.priority"
+
+type priorities struct {
+	config PriorityConfig
+	ranks  map[int]int
+}
+
+func newPriorities(config PriorityConfig) *priorities {
+	levels := []int{0}
+	for _, class := range config.Classes {
+		levels = append(levels, class.Priority)
+	}
+	sort.Ints(levels)
+
+	ranks := make(map[int]int, len(levels))
+	for _, priority := range levels {
+		if _, ok := ranks[priority]; !ok {
+			ranks[priority] = len(ranks)
+		}
+	}
+	return &priorities{config: config, ranks: ranks}
+}
+
+// class returns the class of c, classifying it only once per request.
+func (p *priorities) class(c *gin.Context) PriorityClass {
+	if value, ok := c.Get(priorityKey); ok {
+		return value.(PriorityClass)
+	}
+	class := p.config.Classes[p.config.Classify(c)]
+	c.Set(priorityKey, class)
+	return class
+}
+
+// priority returns the class of c together with the rank of its priority
+// among all priority levels, 0 being the lowest.
+func (rl *RateLimiter) priority(c *gin.Context) (class PriorityClass, rank, ranks int) {
+	if rl.priorities == nil {
+		return class, 0, 1
+	}
+	class = rl.priorities.class(c)
+	return class, rl.priorities.ranks[class.Priority], len(rl.priorities.ranks)
+}
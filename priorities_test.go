@@ -0,0 +1,84 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterPriorities(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	config := RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            ByHeader("X-User"),
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Priorities: &PriorityConfig{
+			Classify: ByHeader("X-Tier"),
+			Classes: map[string]PriorityClass{
+				"gold":   {Priority: 2, Limit: RateLimitParams{MaxTokens: 3, RefillRate: 3, RefillInterval: time.Minute}},
+				"silver": {Priority: 1},
+			},
+		},
+	}
+
+	limiter, err := New(config)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiter.RateLimitMiddleware())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func(user, tier string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		req.Header.Set("X-User", user)
+		req.Header.Set("X-Tier", tier)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 每个等级使用自己的限额
+	assert.Equal(t, "3", request("alice", "gold").Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", request("bob", "silver").Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", request("carol", "").Header().Get("X-RateLimit-Limit"))
+
+	// 过载时先拒绝低优先级的流量
+	limiter.shedder = newLoadShedder(LoadSheddingConfig{CPUThreshold: 0.5, SampleInterval: time.Hour})
+	limiter.shedder.sample = func(now time.Time) (float64, uint64) { return 0.75, 0 }
+	assert.Equal(t, http.StatusServiceUnavailable, request("dave", "").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, request("erin", "").Code)
+	assert.Equal(t, http.StatusOK, request("alice", "gold").Code)
+
+	// 无效的配置会被拒绝
+	config.Priorities = &PriorityConfig{Classify: ByHeader("X-Tier")}
+	assert.Error(t, config.Validate())
+	config.Priorities = &PriorityConfig{
+		Classify: ByHeader("X-Tier"),
+		Classes:  map[string]PriorityClass{"gold": {Limit: RateLimitParams{MaxTokens: 1}}},
+	}
+	assert.Error(t, config.Validate())
+}
+
+func TestLoadShedder_ShedRank(t *testing.T) {
+	shedder := newLoadShedder(LoadSheddingConfig{CPUThreshold: 0.5, SampleInterval: time.Hour})
+	shedder.sample = func(now time.Time) (float64, uint64) { return 0.75, 0 }
+	now := time.Now()
+
+	// 一半负载需要削减时，三个等级中最低的全部拒绝，最高的不受影响
+	for i := 0; i < 100; i++ {
+		assert.True(t, shedder.shedRank(now, 0, 3))
+		assert.False(t, shedder.shedRank(now, 2, 3))
+	}
+}
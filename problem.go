@@ -0,0 +1,60 @@
+package limiter
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// denialKey is the gin.Context key under which the middleware leaves the
+// details of a rejection for the built-in handlers.
+const denialKey = "limiter.denial"
+
+type denial struct {
+	status  int
+	info    LimitInfo
+	message string
+	body    []byte
+	html    bool
+}
+
+func getDenial(c *gin.Context) denial {
+	if value, ok := c.Get(denialKey); ok {
+		return value.(denial)
+	}
+	return denial{status: http.StatusTooManyRequests}
+}
+
+type problemDetails struct {
+	Type       string `json:"type"`
+	Title      string `json:"title"`
+	Status     int    `json:"status"`
+	Detail     string `json:"detail"`
+	RetryAfter int    `json:"retry-after,omitempty"`
+}
+
+// ProblemDetailsHandler rejects the request with an RFC 7807
+// application/problem+json document. Use it as LimitExceededHandler.
+func ProblemDetailsHandler(c *gin.Context) {
+	d := getDenial(c)
+	problem := problemDetails{
+		Type:   "about:blank",
+		Title:  http.StatusText(d.status),
+		Status: d.status,
+		Detail: "Rate limit exceeded.",
+	}
+	if d.info.RetryAfter > 0 {
+		problem.RetryAfter = maxInt(ceilDiv(d.info.RetryAfter, time.Second), 1)
+		problem.Detail = "Rate limit exceeded, retry in " + strconv.Itoa(problem.RetryAfter) + " seconds."
+	}
+	if d.message != "" {
+		problem.Detail = d.message
+	}
+
+	body, _ := json.Marshal(problem)
+	c.Data(problem.Status, "application/problem+json", body)
+	c.Abort()
+}
@@ -0,0 +1,96 @@
+package limiter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProblemDetailsHandler(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+		MaxTokens:            1,
+		RefillRate:           1,
+		RefillInterval:       time.Second * 30,
+		KeyFunc:              func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:      1,
+		LimitExceededHandler: ProblemDetailsHandler,
+		ExpirationDuration:   time.Minute * 5,
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+
+	// 响应体包含 RFC 7807 规定的字段以及重试时间
+	var problem map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, "about:blank", problem["type"])
+	assert.Equal(t, "Too Many Requests", problem["title"])
+	assert.Equal(t, float64(429), problem["status"])
+	assert.Equal(t, float64(30), problem["retry-after"])
+	assert.Equal(t, "Rate limit exceeded, retry in 30 seconds.", problem["detail"])
+}
+
+func TestRejectionStatusCode(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	for _, handler := range []gin.HandlerFunc{nil, ProblemDetailsHandler} {
+		limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+			MaxTokens:            1,
+			RefillRate:           1,
+			RefillInterval:       time.Minute,
+			KeyFunc:              func(c *gin.Context) string { return c.ClientIP() },
+			BurstMultiplier:      1,
+			LimitExceededHandler: handler,
+			ExpirationDuration:   time.Minute * 5,
+			StatusCode:           http.StatusServiceUnavailable,
+		})
+		assert.NoError(t, err)
+
+		router := gin.New()
+		router.Use(limiterMiddleware)
+		router.GET("/", func(c *gin.Context) {
+			c.String(http.StatusOK, "Hello, world!")
+		})
+
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		// 内置处理函数都使用配置的状态码
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	}
+
+	_, err := NewRateLimiter(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		StatusCode:         http.StatusOK,
+	})
+	assert.Error(t, err)
+}
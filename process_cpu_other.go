@@ -0,0 +1,11 @@
+//go:build !unix && !windows
+
+package limiter
+
+import "time"
+
+// processCPUTime is not available on this platform; load shedding then
+// relies on memory alone.
+func processCPUTime() (time.Duration, bool) {
+	return 0, false
+}
@@ -0,0 +1,118 @@
+package limiter
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/bits"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProofOfWork is a ChallengeProvider that makes limited clients spend CPU
+// instead of solving a CAPTCHA. The challenge is sent in the
+// X-PoW-Challenge header; the client must find a solution such that
+// SHA-256(challenge + solution) starts with Difficulty zero bits and send
+// both back in X-PoW-Challenge and X-PoW-Solution. Every 1 added to
+// Difficulty doubles the expected work. Challenges are signed with Secret,
+// so no state is kept until they are solved, and each can be redeemed
+// once within TTL.
+type ProofOfWork struct {
+	secret     []byte
+	difficulty int
+	ttl        time.Duration
+	redeemed   map[string]time.Time
+	mutex      sync.Mutex
+}
+
+func NewProofOfWork(secret []byte, difficulty int, ttl time.Duration) *ProofOfWork {
+	return &ProofOfWork{
+		secret:     secret,
+		difficulty: difficulty,
+		ttl:        ttl,
+		redeemed:   make(map[string]time.Time),
+	}
+}
+
+func (p *ProofOfWork) Challenge(c *gin.Context, info LimitInfo) {
+	nonce := make([]byte, 16)
+	_, _ = rand.Read(nonce)
+	payload := strconv.FormatInt(time.Now().Add(p.ttl).Unix(), 10) + "." + hex.EncodeToString(nonce)
+	challenge := payload + "." + p.sign(payload)
+
+	c.Header("X-PoW-Challenge", challenge)
+	c.Header("X-PoW-Difficulty", strconv.Itoa(p.difficulty))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"challenge":  challenge,
+		"difficulty": p.difficulty,
+	})
+}
+
+func (p *ProofOfWork) Verify(c *gin.Context) (bool, error) {
+	challenge := c.GetHeader("X-PoW-Challenge")
+	solution := c.GetHeader("X-PoW-Solution")
+	if challenge == "" || solution == "" {
+		return false, nil
+	}
+
+	dot := strings.LastIndexByte(challenge, '.')
+	if dot < 0 || !hmac.Equal([]byte(challenge[dot+1:]), []byte(p.sign(challenge[:dot]))) {
+		return false, nil
+	}
+	expiry, err := strconv.ParseInt(challenge[:strings.IndexByte(challenge, '.')], 10, 64)
+	now := time.Now()
+	if err != nil || now.Unix() > expiry {
+		return false, nil
+	}
+	if leadingZeros(sha256.Sum256([]byte(challenge+solution))) < p.difficulty {
+		return false, nil
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if _, ok := p.redeemed[challenge]; ok {
+		return false, nil
+	}
+	for redeemed, expires := range p.redeemed {
+		if now.After(expires) {
+			delete(p.redeemed, redeemed)
+		}
+	}
+	p.redeemed[challenge] = time.Unix(expiry, 0)
+	return true, nil
+}
+
+func (p *ProofOfWork) sign(payload string) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SolveProofOfWork finds a solution to a challenge issued by ProofOfWork,
+// for Go clients and tests.
+func SolveProofOfWork(challenge string, difficulty int) string {
+	for i := 0; ; i++ {
+		solution := strconv.Itoa(i)
+		if leadingZeros(sha256.Sum256([]byte(challenge+solution))) >= difficulty {
+			return solution
+		}
+	}
+}
+
+func leadingZeros(sum [sha256.Size]byte) int {
+	n := 0
+	for _, b := range sum {
+		n += bits.LeadingZeros8(b)
+		if b != 0 {
+			break
+		}
+	}
+	return n
+}
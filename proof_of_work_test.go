@@ -0,0 +1,82 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProofOfWork(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Challenge:          NewProofOfWork([]byte("secret"), 8, time.Minute),
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	// 超限的客户端收到工作量证明挑战
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "8", w.Header().Get("X-PoW-Difficulty"))
+	challenge := w.Header().Get("X-PoW-Challenge")
+	assert.NotEmpty(t, challenge)
+
+	// 错误的解答或被篡改的挑战无效
+	req.Header.Set("X-PoW-Challenge", challenge+"0")
+	req.Header.Set("X-PoW-Solution", SolveProofOfWork(challenge+"0", 8))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	// 携带有效解答的请求绕过限流
+	req.Header.Set("X-PoW-Challenge", challenge)
+	req.Header.Set("X-PoW-Solution", SolveProofOfWork(challenge, 8))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 同一个挑战只能使用一次
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEqual(t, challenge, w.Header().Get("X-PoW-Challenge"))
+}
+
+func TestProofOfWorkExpired(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	pow := NewProofOfWork([]byte("secret"), 4, -time.Minute)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("GET", "/", nil)
+	pow.Challenge(c, LimitInfo{})
+	challenge := c.Writer.Header().Get("X-PoW-Challenge")
+
+	c.Request.Header.Set("X-PoW-Challenge", challenge)
+	c.Request.Header.Set("X-PoW-Solution", SolveProofOfWork(challenge, 4))
+	ok, err := pow.Verify(c)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
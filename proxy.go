@@ -0,0 +1,90 @@
+package limiter
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ByTrustedProxyIP keys requests by the real client address behind the
+// given proxies, which may be CIDRs or single addresses. X-Forwarded-For
+// is read from right to left and the first hop that is not a trusted proxy
+// is the client; X-Real-IP is used if there is no X-Forwarded-For. Headers
+// are ignored unless the direct peer is trusted, so clients cannot spoof
+// them to get a fresh bucket. IPv6 clients are aggregated to IPv6Prefix.
+func ByTrustedProxyIP(proxies []string) (func(*gin.Context) string, error) {
+	resolve, err := newProxyResolver(proxies)
+	if err != nil {
+		return nil, err
+	}
+	return func(c *gin.Context) string {
+		return ipPrefix(resolve(c.Request), 32, IPv6Prefix)
+	}, nil
+}
+
+// newProxyResolver returns the client address of a request as
+// ByTrustedProxyIP resolves it, before any aggregation.
+func newProxyResolver(proxies []string) (func(*http.Request) string, error) {
+	trusted := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		prefix, ok := parsePrefix(proxy)
+		if !ok {
+			return nil, errors.New("invalid trusted proxy " + proxy)
+		}
+		trusted = append(trusted, prefix)
+	}
+
+	isTrusted := func(addr netip.Addr) bool {
+		for _, prefix := range trusted {
+			if prefix.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(req *http.Request) string {
+		host := peerAddr(req)
+		peer, err := netip.ParseAddr(host)
+		if err != nil || !isTrusted(peer.Unmap()) {
+			return host
+		}
+
+		client := peer.Unmap()
+		// Proxies may append their own header line rather than extend the
+		// existing one, so the last line holds the nearest hops.
+		if forwarded := strings.Join(req.Header.Values("X-Forwarded-For"), ","); forwarded != "" {
+			hops := strings.Split(forwarded, ",")
+			for i := len(hops) - 1; i >= 0; i-- {
+				hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+				if err != nil {
+					// Anything left of a malformed hop is untrustworthy.
+					break
+				}
+				client = hop.Unmap()
+				if !isTrusted(client) {
+					break
+				}
+			}
+			return client.String()
+		}
+		if realIP, err := netip.ParseAddr(strings.TrimSpace(req.Header.Get("X-Real-IP"))); err == nil {
+			return realIP.Unmap().String()
+		}
+		return client.String()
+	}, nil
+}
+
+// peerAddr is the address of the direct peer of req, which no header can
+// change.
+func peerAddr(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
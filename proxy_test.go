@@ -0,0 +1,60 @@
+package limiter
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestByTrustedProxyIP(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	keyFunc, err := ByTrustedProxyIP([]string{"10.0.0.0/8", "192.168.1.1", "fd00::/8"})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		want       string
+	}{
+		{"direct client", "203.0.113.7:1234", "", "", "203.0.113.7"},
+		// 不可信的对端不能伪造请求头
+		{"spoofed header", "203.0.113.7:1234", "1.2.3.4", "5.6.7.8", "203.0.113.7"},
+		{"single proxy", "192.168.1.1:1234", "203.0.113.7", "", "203.0.113.7"},
+		// 从右向左跳过可信代理
+		{"proxy chain", "10.0.0.1:1234", "1.2.3.4, 203.0.113.7, 10.1.2.3", "", "203.0.113.7"},
+		{"all trusted", "10.0.0.1:1234", "10.0.0.2, 10.0.0.3", "", "10.0.0.2"},
+		// 多个请求头按顺序拼接，最后一行是最近的代理
+		{"multiple headers", "10.0.0.1:1234", "203.0.113.7\n1.2.3.4, 10.1.2.3", "", "1.2.3.4"},
+		{"malformed hop", "10.0.0.1:1234", "1.2.3.4, garbage, 10.0.0.3", "", "10.0.0.3"},
+		{"real ip", "10.0.0.1:1234", "", "203.0.113.7", "203.0.113.7"},
+		{"no headers", "10.0.0.1:1234", "", "", "10.0.0.1"},
+		// IPv6 客户端聚合为 /64
+		{"ipv6 proxy", "[fd00::1]:1234", "2001:db8::1", "", "2001:db8::/64"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newKeyContext(func(req *http.Request) {
+				req.RemoteAddr = tt.remoteAddr
+				if tt.forwarded != "" {
+					for _, line := range strings.Split(tt.forwarded, "\n") {
+						req.Header.Add("X-Forwarded-For", line)
+					}
+				}
+				if tt.realIP != "" {
+					req.Header.Set("X-Real-IP", tt.realIP)
+				}
+			})
+			assert.Equal(t, tt.want, keyFunc(c))
+		})
+	}
+
+	_, err = ByTrustedProxyIP([]string{"10.0.0.0/33"})
+	assert.EqualError(t, err, "invalid trusted proxy 10.0.0.0/33")
+}
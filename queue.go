@@ -0,0 +1,150 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errQueueFull = errors.New("limiter: wait queue is full")
+
+// QueueRule is the Rule reported in LimitInfo when a request is turned
+// away because the wait queue of its key is full.
+const QueueRule = "queue"
+
+// waitQueue lines up the requests of a key so that tokens are handed out
+// by priority and then in arrival order. Each waiter owns a channel that
+// is closed once it is at the front of its queue.
+type waitQueue struct {
+	max   int
+	keys  map[string][]waiter
+	mutex sync.Mutex
+}
+
+type waiter struct {
+	turn     chan struct{}
+	priority int
+}
+
+func newWaitQueue(max int) *waitQueue {
+	return &waitQueue{
+		max:  max,
+		keys: make(map[string][]waiter),
+	}
+}
+
+// enter queues a request of the given priority behind those of the same
+// or a higher priority. The front of the queue keeps its turn even if a
+// request of a higher priority arrives.
+func (q *waitQueue) enter(key string, priority int) (chan struct{}, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	waiters := q.keys[key]
+	if len(waiters) >= q.max {
+		return nil, false
+	}
+	turn := make(chan struct{})
+	if len(waiters) == 0 {
+		close(turn)
+	}
+	i := len(waiters)
+	for i > 1 && waiters[i-1].priority < priority {
+		i--
+	}
+	waiters = append(waiters, waiter{})
+	copy(waiters[i+1:], waiters[i:])
+	waiters[i] = waiter{turn: turn, priority: priority}
+	q.keys[key] = waiters
+	return turn, true
+}
+
+func (q *waitQueue) leave(key string, turn chan struct{}) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	waiters := q.keys[key]
+	for i, w := range waiters {
+		if w.turn != turn {
+			continue
+		}
+		waiters = append(waiters[:i], waiters[i+1:]...)
+		if i == 0 && len(waiters) > 0 {
+			close(waiters[0].turn)
+		}
+		break
+	}
+	if len(waiters) == 0 {
+		delete(q.keys, key)
+	} else {
+		q.keys[key] = waiters
+	}
+}
+
+// takeQueued waits for its turn in the queue of key and then for a token,
+// for at most Timeout, which Validate defaults when MaxQueue is set. It
+// returns errQueueFull if the queue has no room left. With FairQueuing the
+// GlobalLimit is shared out between the keys by weight.
+func (rl *RateLimiter) takeQueued(ctx context.Context, key string, n int, scopes []string, priority, weight int) (bool, rejection, error) {
+	turn, ok := rl.queue.enter(key, priority)
+	if !ok {
+		return false, rejection{retryAfter: rl.queueRetryAfter()}, errQueueFull
+	}
+	defer rl.queue.leave(key, turn)
+
+	start := time.Now()
+	var expired <-chan time.Time
+	if rl.config.Timeout > 0 {
+		timer := time.NewTimer(rl.config.Timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case <-turn:
+	case <-expired:
+		return false, rejection{retryAfter: rl.queueRetryAfter()}, nil
+	case <-ctx.Done():
+		return false, rejection{}, nil
+	}
+
+	var r rejection
+	if rl.fair != nil && rl.fair.busy() {
+		// Other keys are already waiting for the GlobalLimit; get in line.
+		r = rl.global.reject(GlobalRule, n, time.Now())
+	} else {
+		allowed, next, err := rl.takeScoped(ctx, key, n, scopes)
+		if err != nil || allowed {
+			return allowed, next, err
+		}
+		r = next
+	}
+
+	timeout := rl.config.Timeout
+	if timeout > 0 {
+		if timeout -= time.Since(start); timeout <= 0 {
+			return false, r, nil
+		}
+	}
+	if rl.fair != nil && r.rule == GlobalRule {
+		return rl.waitFair(ctx, key, n, scopes, weight, timeout, r)
+	}
+	return rl.wait(ctx, key, n, scopes, timeout, r)
+}
+
+// queueWeight is the share of the GlobalLimit the key of c gets under
+// FairQueuing, relative to the other keys.
+func (rl *RateLimiter) queueWeight(c *gin.Context) int {
+	if rl.config.QueueWeight == nil {
+		return 1
+	}
+	return maxInt(rl.config.QueueWeight(c), 1)
+}
+
+// queueRetryAfter estimates how long a full queue needs to drain.
+func (rl *RateLimiter) queueRetryAfter() time.Duration {
+	l := rl.limits()
+	return time.Duration(rl.config.MaxQueue) * l.refillInterval / time.Duration(l.refillRate)
+}
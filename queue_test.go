@@ -0,0 +1,133 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitQueueOrder(t *testing.T) {
+	q := newWaitQueue(2)
+
+	first, ok := q.enter("a", 0)
+	assert.True(t, ok)
+	second, ok := q.enter("a", 0)
+	assert.True(t, ok)
+	_, ok = q.enter("a", 0)
+	assert.False(t, ok)
+
+	// 队首立即轮到，后面的需要等待
+	assert.NotNil(t, first)
+	select {
+	case <-first:
+	default:
+		t.Fatal("first waiter should be at the front")
+	}
+	select {
+	case <-second:
+		t.Fatal("second waiter should still wait")
+	default:
+	}
+
+	q.leave("a", first)
+	select {
+	case <-second:
+	default:
+		t.Fatal("second waiter should be at the front")
+	}
+	q.leave("a", second)
+	assert.Empty(t, q.keys)
+}
+
+func TestWaitQueuePriority(t *testing.T) {
+	q := newWaitQueue(4)
+
+	front, _ := q.enter("a", 0)
+	low, _ := q.enter("a", 0)
+	high, _ := q.enter("a", 2)
+	medium, _ := q.enter("a", 1)
+
+	// 高优先级排在低优先级之前，但不抢占队首
+	var order []chan struct{}
+	for _, w := range q.keys["a"] {
+		order = append(order, w.turn)
+	}
+	assert.Equal(t, []chan struct{}{front, high, medium, low}, order)
+
+	q.leave("a", front)
+	select {
+	case <-high:
+	default:
+		t.Fatal("high priority waiter should be at the front")
+	}
+}
+
+func TestQueueMiddleware(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Millisecond * 100,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		MaxQueue:           2,
+		LimitExceededFunc: func(c *gin.Context, info LimitInfo) {
+			c.String(http.StatusServiceUnavailable, info.Rule)
+		},
+	})
+	assert.NoError(t, err)
+
+	var order []string
+	var mutex sync.Mutex
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/:id", func(c *gin.Context) {
+		mutex.Lock()
+		order = append(order, c.Param("id"))
+		mutex.Unlock()
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	// 未设置 Timeout 时默认为排空满队列的时间加一个间隔
+	config := RateLimitConfig{MaxTokens: 1, RefillRate: 1, RefillInterval: time.Millisecond * 100, MaxQueue: 2}
+	assert.NoError(t, config.Validate())
+	assert.Equal(t, time.Millisecond*300, config.Timeout)
+
+	serve := func(id string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/"+id, nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, serve("0").Code)
+
+	// 超出限额的请求按到达顺序排队等待令牌
+	var wg sync.WaitGroup
+	for _, id := range []string{"1", "2"} {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			assert.Equal(t, http.StatusOK, serve(id).Code)
+		}(id)
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	// 队列已满时立即拒绝，并交给 LimitExceededFunc 处理
+	w := serve("3")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, QueueRule, w.Body.String())
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	wg.Wait()
+	assert.Equal(t, []string{"0", "1", "2"}, order)
+}
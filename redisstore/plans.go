@@ -0,0 +1,37 @@
+package redisstore
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// PlanInvalidations relays the messages published on a Redis channel as
+// keys for ListenPlanInvalidations, until ctx is done:
+//
+//	go rl.ListenPlanInvalidations(ctx, redisstore.PlanInvalidations(ctx, client, "plan-changes"))
+func PlanInvalidations(ctx context.Context, client redis.UniversalClient, channel string) <-chan string {
+	pubsub := client.Subscribe(ctx, channel)
+	keys := make(chan string)
+	go func() {
+		defer close(keys)
+		defer pubsub.Close()
+		messages := pubsub.Channel()
+		for {
+			select {
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				select {
+				case keys <- msg.Payload:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return keys
+}
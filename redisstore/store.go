@@ -0,0 +1,68 @@
+// Package redisstore keeps the buckets of a limiter in Redis, so that
+// several instances enforce one shared limit.
+package redisstore
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	limiter "github.com/colommar/gin-ratelimiter"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ limiter.Store = (*Store)(nil)
+
+// compareAndSwapScript keeps the bucket value and its revision in one hash
+// so the revision check and the write happen atomically.
+var compareAndSwapScript = redis.NewScript(`
+local rev = tonumber(redis.call('HGET', KEYS[1], 'r') or '0')
+if rev ~= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'v', ARGV[2], 'r', rev + 1)
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return 1
+`)
+
+// Store is a limiter.Store that keeps buckets in Redis. Combine it with
+// SyncInterval to cache buckets locally and only reconcile with Redis
+// periodically.
+type Store struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+func New(client redis.UniversalClient, prefix string) *Store {
+	return &Store{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+func (s *Store) Get(ctx context.Context, key string) ([]byte, int64, error) {
+	fields, err := s.client.HMGet(ctx, s.prefix+key, "v", "r").Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	value, ok := fields[0].(string)
+	if !ok {
+		return nil, 0, nil
+	}
+	revision, _ := fields[1].(string)
+	rev, err := strconv.ParseInt(revision, 10, 64)
+	if err != nil {
+		return nil, 0, err
+	}
+	return []byte(value), rev, nil
+}
+
+func (s *Store) CompareAndSwap(ctx context.Context, key string, revision int64, value []byte, ttl time.Duration) (bool, error) {
+	ok, err := compareAndSwapScript.Run(ctx, s.client, []string{s.prefix + key},
+		revision, value, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return ok == 1, nil
+}
@@ -0,0 +1,124 @@
+package redisstore
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	limiter "github.com/colommar/gin-ratelimiter"
+)
+
+func newTestStore(t *testing.T) (*miniredis.Miniredis, *Store) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	return server, New(client, "limits/")
+}
+
+func TestStoreCompareAndSwap(t *testing.T) {
+	server, store := newTestStore(t)
+	ctx := context.Background()
+
+	// 不存在的键返回空值和修订号 0
+	value, revision, err := store.Get(ctx, "user")
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+	assert.Equal(t, int64(0), revision)
+
+	// 修订号 0 表示新建，脚本写入值、修订号和过期时间
+	ok, err := store.CompareAndSwap(ctx, "user", 0, []byte("a"), time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "a", server.HGet("limits/user", "v"))
+	assert.Equal(t, "1", server.HGet("limits/user", "r"))
+	assert.Equal(t, time.Minute, server.TTL("limits/user"))
+
+	value, revision, err = store.Get(ctx, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("a"), value)
+	assert.Equal(t, int64(1), revision)
+
+	// 过期的修订号写入失败，值保持不变
+	ok, err = store.CompareAndSwap(ctx, "user", 0, []byte("b"), time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "a", server.HGet("limits/user", "v"))
+
+	// 当前修订号写入成功并递增修订号
+	ok, err = store.CompareAndSwap(ctx, "user", 1, []byte("c"), time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	value, revision, _ = store.Get(ctx, "user")
+	assert.Equal(t, []byte("c"), value)
+	assert.Equal(t, int64(2), revision)
+
+	// 过期后键消失，重新从修订号 0 开始
+	server.FastForward(time.Minute)
+	assert.False(t, server.Exists("limits/user"))
+	value, revision, err = store.Get(ctx, "user")
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+	assert.Equal(t, int64(0), revision)
+}
+
+func TestStoreErrors(t *testing.T) {
+	server, store := newTestStore(t)
+	server.SetError("unavailable")
+	ctx := context.Background()
+
+	_, _, err := store.Get(ctx, "user")
+	assert.Error(t, err)
+
+	ok, err := store.CompareAndSwap(ctx, "user", 0, []byte("a"), time.Minute)
+	assert.Error(t, err)
+	assert.False(t, ok)
+}
+
+func TestStoreSharedLimiters(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	_, store := newTestStore(t)
+	config := limiter.RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Second,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Store:              store,
+	}
+
+	// 两个中间件实例共享同一个 Redis Store
+	first, err := limiter.NewRateLimiter(config)
+	assert.NoError(t, err)
+	second, err := limiter.NewRateLimiter(config)
+	assert.NoError(t, err)
+
+	newRouter := func(middleware gin.HandlerFunc) *gin.Engine {
+		router := gin.New()
+		router.Use(middleware)
+		router.GET("/", func(c *gin.Context) {
+			c.String(http.StatusOK, "Hello, world!")
+		})
+		return router
+	}
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+
+	w := httptest.NewRecorder()
+	newRouter(first).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 第二个实例看到令牌已被消耗
+	w = httptest.NewRecorder()
+	newRouter(second).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
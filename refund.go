@@ -0,0 +1,85 @@
+package limiter
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// counts reports whether an admitted request keeps the tokens it took once
+// its response is known. Server errors are our fault, not the client's;
+// CountStatusClasses lists the classes that count, e.g. 2 for 2xx.
+func (rl *RateLimiter) counts(c *gin.Context) bool {
+	status := c.Writer.Status()
+	if rl.config.RefundServerErrors && status >= http.StatusInternalServerError {
+		return false
+	}
+	if len(rl.config.CountStatusClasses) > 0 && !containsInt(rl.config.CountStatusClasses, status/100) {
+		return false
+	}
+	return rl.config.CountResponse == nil || rl.config.CountResponse(c)
+}
+
+// refund gives back the n tokens the middleware took for key at every
+// stage that charged them. Like Reservation.Cancel it cannot return
+// tokens taken by an Algorithm, including GCRA in a Store.
+func (rl *RateLimiter) refund(ctx context.Context, key string, n int, scopes []string) error {
+	if scopes != nil {
+		rl.hierarchy.refund(scopes, n)
+	}
+	if rl.rules != nil {
+		rl.rules.refund(key, n)
+	}
+	if rl.global != nil {
+		rl.global.refund(n)
+	}
+
+	if rl.algorithm != nil {
+		return nil
+	}
+	if rl.config.Store != nil && rl.config.SyncInterval == 0 {
+		return rl.refundToStore(ctx, key, n)
+	}
+
+	bucket := rl.getBucket(key)
+	bucket.mutex.Lock()
+	defer bucket.mutex.Unlock()
+
+	bucket.tokens = minInt(bucket.tokens+n, bucket.maxTokens)
+	// Tokens spent locally but not synced yet must not be charged twice.
+	bucket.pending = maxInt(bucket.pending-n, 0)
+	return nil
+}
+
+func (rl *RateLimiter) refundToStore(ctx context.Context, key string, n int) error {
+	for attempt := 0; attempt < maxStoreAttempts; attempt++ {
+		value, revision, err := rl.config.Store.Get(ctx, key)
+		if err != nil || value == nil {
+			// A missing key has expired and is full anyway.
+			return err
+		}
+
+		now := time.Now()
+		bucket := rl.newBucket(key, now)
+		bucket.decode(value)
+		bucket.refill(now)
+		bucket.tokens = minInt(bucket.tokens+n, bucket.maxTokens)
+
+		ok, err := rl.config.Store.CompareAndSwap(ctx, key, revision, bucket.encode(), rl.config.ExpirationDuration)
+		if err != nil || ok {
+			return err
+		}
+	}
+	return ErrStoreContention
+}
+
+func containsInt(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountResponse(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	for _, store := range []Store{nil, newMapStore()} {
+		limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+			MaxTokens:          1,
+			RefillRate:         1,
+			RefillInterval:     time.Minute,
+			KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+			BurstMultiplier:    1,
+			ExpirationDuration: time.Minute * 5,
+			Store:              store,
+			GlobalLimit:        &Limit{MaxTokens: 2, RefillRate: 1, RefillInterval: time.Minute},
+			CountResponse: func(c *gin.Context) bool {
+				return c.Writer.Status() != http.StatusNotModified
+			},
+		})
+		assert.NoError(t, err)
+
+		router := gin.New()
+		router.Use(limiterMiddleware)
+		router.GET("/", func(c *gin.Context) {
+			c.String(http.StatusOK, "Hello, world!")
+		})
+		router.GET("/cached", func(c *gin.Context) {
+			c.Status(http.StatusNotModified)
+		})
+
+		// 304 响应不消耗令牌
+		req, _ := http.NewRequest("GET", "/cached", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		for i := 0; i < 3; i++ {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusNotModified, w.Code)
+		}
+
+		// 其他响应照常计数
+		req, _ = http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		for _, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, want, w.Code)
+		}
+	}
+}
+
+func TestRefundServerErrors(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		RefundServerErrors: true,
+	})
+	assert.NoError(t, err)
+
+	failing := true
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		if failing {
+			c.String(http.StatusServiceUnavailable, "down")
+			return
+		}
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	// 服务端错误退还令牌，重试不会耗尽配额
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	}
+
+	// 恢复后的请求照常计数
+	failing = false
+	for _, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, want, w.Code)
+	}
+}
+
+func TestCountStatusClasses(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+		MaxTokens:          2,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		CountStatusClasses: []int{2, 4},
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/:status", func(c *gin.Context) {
+		status, _ := strconv.Atoi(c.Param("status"))
+		c.Status(status)
+	})
+
+	// 只有 2xx 和 4xx 响应消耗令牌
+	for _, tt := range []struct {
+		path string
+		want int
+	}{
+		{"/302", http.StatusFound},
+		{"/500", http.StatusInternalServerError},
+		{"/200", http.StatusOK},
+		{"/302", http.StatusFound},
+		{"/404", http.StatusNotFound},
+		{"/200", http.StatusTooManyRequests},
+	} {
+		req, _ := http.NewRequest("GET", tt.path, nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tt.want, w.Code, tt.path)
+	}
+
+	config := RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		CountStatusClasses: []int{200},
+	}
+	assert.EqualError(t, config.Validate(), "CountStatusClasses must be between 1 and 5")
+
+	// 其他算法无法退还令牌，拒绝这些选项
+	config.CountStatusClasses = nil
+	config.RefundServerErrors = true
+	config.Algorithm = SlidingWindowLog
+	assert.EqualError(t, config.Validate(), "CountResponse, RefundServerErrors and CountStatusClasses are only supported by the token bucket algorithm")
+}
@@ -0,0 +1,67 @@
+package limiter
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Registry holds named limiters, so unrelated routes can share one budget
+// such as "expensive-search" and handlers can look a limiter up by name
+// to call Allow themselves.
+type Registry struct {
+	limiters map[string]*RateLimiter
+	mutex    sync.RWMutex
+}
+
+func NewRegistry() *Registry {
+	return &Registry{limiters: make(map[string]*RateLimiter)}
+}
+
+// Register creates the limiter called name from config, replacing any
+// earlier one.
+func (r *Registry) Register(name string, config RateLimitConfig) (*RateLimiter, error) {
+	limiter, err := New(config)
+	if err != nil {
+		return nil, errors.New(name + ": " + err.Error())
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.limiters[name] = limiter
+	return limiter, nil
+}
+
+// Get returns the limiter called name, or nil if there is none.
+func (r *Registry) Get(name string) *RateLimiter {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.limiters[name]
+}
+
+func (r *Registry) CleanupExpiredBuckets() {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	for _, limiter := range r.limiters {
+		limiter.CleanupExpiredBuckets()
+	}
+}
+
+// RateLimitMiddleware limits requests with the limiter called name, which
+// is looked up per request so it may be registered or replaced later.
+// Every route using it draws from the same budget. While no such limiter
+// exists, requests are rejected with 503 Service Unavailable and the error
+// is added to the context, so a misspelled name cannot switch limiting off.
+func (r *Registry) RateLimitMiddleware(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limiter := r.Get(name)
+		if limiter == nil {
+			_ = c.Error(errors.New("limiter: no limiter named " + name))
+			c.AbortWithStatus(http.StatusServiceUnavailable)
+			return
+		}
+		limiter.RateLimitMiddleware()(c)
+	}
+}
@@ -0,0 +1,67 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	registry := NewRegistry()
+	search, err := registry.Register("expensive-search", RateLimitConfig{
+		MaxTokens:          2,
+		RefillRate:         2,
+		RefillInterval:     time.Minute,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+	})
+	assert.NoError(t, err)
+	assert.Same(t, search, registry.Get("expensive-search"))
+	assert.Nil(t, registry.Get("missing"))
+
+	_, err = registry.Register("bad", RateLimitConfig{})
+	assert.Error(t, err)
+
+	router := gin.New()
+	handler := func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	}
+	router.GET("/search", registry.RateLimitMiddleware("expensive-search"), handler)
+	router.GET("/export", registry.RateLimitMiddleware("expensive-search"), handler)
+	router.GET("/other", registry.RateLimitMiddleware("missing"), handler)
+
+	request := func(path string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 不同的路由共享同一额度
+	assert.Equal(t, http.StatusOK, request("/search"))
+	assert.Equal(t, http.StatusOK, request("/export"))
+	assert.Equal(t, http.StatusTooManyRequests, request("/search"))
+
+	// 处理函数可以按名称手动调用 Allow
+	assert.False(t, registry.Get("expensive-search").Allow("192.168.1.1"))
+
+	// 未注册的名称拒绝请求，注册后生效
+	assert.Equal(t, http.StatusServiceUnavailable, request("/other"))
+	_, err = registry.Register("missing", RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, request("/other"))
+}
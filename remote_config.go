@@ -0,0 +1,69 @@
+package limiter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+)
+
+// limitUpdate is the part of a LoadConfig limiter that UpdateConfig can
+// change at runtime.
+type limitUpdate struct {
+	fileLimit
+	BurstMultiplier int                  `json:"burst_multiplier"`
+	Overrides       map[string]fileLimit `json:"overrides"`
+}
+
+func parseLimitUpdate(data []byte) (RateLimitConfig, error) {
+	var update limitUpdate
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&update); err != nil {
+		return RateLimitConfig{}, err
+	}
+
+	limit, err := update.limit()
+	if err != nil {
+		return RateLimitConfig{}, err
+	}
+	config := RateLimitConfig{
+		MaxTokens:       limit.MaxTokens,
+		RefillRate:      limit.RefillRate,
+		RefillInterval:  limit.RefillInterval,
+		BurstMultiplier: update.BurstMultiplier,
+	}
+	if config.Overrides, err = fileOverrides(update.Overrides); err != nil {
+		return RateLimitConfig{}, err
+	}
+	return config, nil
+}
+
+// WatchConfig applies every update received from updates with
+// UpdateConfig until ctx is done or updates is closed, so that limits
+// changed in a central store reach every instance within seconds. An
+// update is a JSON object with the limit fields of LoadConfig and
+// replaces the limits as a whole, including any Schedule or Cron:
+//
+//	{"max_tokens": 50, "refill_rate": 50, "refill_interval": "1m", "burst_multiplier": 1}
+//
+// Invalid updates leave the limits as they are and are passed to onError
+// unless it is nil.
+func (rl *RateLimiter) WatchConfig(ctx context.Context, updates <-chan []byte, onError func(error)) {
+	for {
+		select {
+		case data, ok := <-updates:
+			if !ok {
+				return
+			}
+			config, err := parseLimitUpdate(data)
+			if err == nil {
+				err = rl.UpdateConfig(config)
+			}
+			if err != nil && onError != nil {
+				onError(err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterWatchConfig(t *testing.T) {
+	limiter, err := New(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+	})
+	assert.NoError(t, err)
+
+	updates := make(chan []byte)
+	var errs []error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		limiter.WatchConfig(context.Background(), updates, func(err error) {
+			errs = append(errs, err)
+		})
+	}()
+
+	assert.True(t, limiter.Allow("a"))
+	assert.False(t, limiter.Allow("a"))
+
+	// 远程推送的新限额立即生效，已有的桶保留状态
+	updates <- []byte(`{"max_tokens": 3, "refill_rate": 1, "refill_interval": "1m", "burst_multiplier": 1, "overrides": {"vip": {"max_tokens": 10, "refill_rate": 10, "refill_interval": "1m"}}}`)
+	// 无效的更新被报告，限额保持不变
+	updates <- []byte(`{"max_tokens": 0, "refill_rate": 1, "refill_interval": "1m", "burst_multiplier": 1}`)
+	updates <- []byte(`{"max_tokens": 1, "namespace": "api"}`)
+	close(updates)
+	<-done
+
+	assert.Len(t, errs, 2)
+	assert.False(t, limiter.Allow("a"))
+	for i := 0; i < 3; i++ {
+		assert.True(t, limiter.Allow("b"))
+	}
+	assert.False(t, limiter.Allow("b"))
+	for i := 0; i < 10; i++ {
+		assert.True(t, limiter.Allow("vip"))
+	}
+
+	// ctx 结束后停止监听
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	limiter.WatchConfig(ctx, make(chan []byte), nil)
+}
@@ -0,0 +1,115 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterReputation(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	lookups := 0
+	scores := map[string]float64{"203.0.113.1": 0.5, "203.0.113.2": 0.05, "203.0.113.4": 0.15}
+	config := RateLimitConfig{
+		MaxTokens:          4,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Reputation: &ReputationConfig{
+			Provider: ReputationFunc(func(ctx context.Context, ip string) (float64, error) {
+				lookups++
+				if ip == "203.0.113.3" {
+					return 0, errors.New("lookup failed")
+				}
+				if score, ok := scores[ip]; ok {
+					return score, nil
+				}
+				return 1, nil
+			}),
+			BlockBelow: 0.1,
+			CacheTTL:   time.Minute,
+		},
+	}
+
+	limiterMiddleware, err := NewRateLimiter(config)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func(ip string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 信誉良好的客户端拥有完整额度
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, http.StatusOK, request("192.168.1.1"))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, request("192.168.1.1"))
+
+	// 信誉减半的客户端额度减半
+	assert.Equal(t, http.StatusOK, request("203.0.113.1"))
+	assert.Equal(t, http.StatusOK, request("203.0.113.1"))
+	assert.Equal(t, http.StatusTooManyRequests, request("203.0.113.1"))
+
+	// 缩放后的开销不超过桶容量，低信誉客户端仍能在桶装满后发出一个请求
+	assert.Equal(t, http.StatusOK, request("203.0.113.4"))
+	assert.Equal(t, http.StatusTooManyRequests, request("203.0.113.4"))
+
+	// 低于阈值的客户端被直接拒绝，并像普通拒绝一样带上限流头
+	assert.Equal(t, http.StatusForbidden, request("203.0.113.2"))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "203.0.113.2:1234"
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.NotEmpty(t, w.Header().Get("X-RateLimit-Limit"))
+
+	// 查询失败时按信誉良好处理
+	assert.Equal(t, http.StatusOK, request("203.0.113.3"))
+
+	// 查询结果按 IP 缓存
+	lookups = 0
+	request("203.0.113.2")
+	request("203.0.113.1")
+	assert.Equal(t, 0, lookups)
+
+	// DryRun 模式下信誉拒绝只被记录
+	config.DryRun = true
+	limiter, err := New(config)
+	assert.NoError(t, err)
+	router = gin.New()
+	router.Use(limiter.RateLimitMiddleware())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+	assert.Equal(t, http.StatusOK, request("203.0.113.2"))
+	assert.Equal(t, uint64(1), limiter.Stats().DryRunDenied)
+}
+
+func TestValidateReputation(t *testing.T) {
+	provider := ReputationFunc(func(context.Context, string) (float64, error) { return 1, nil })
+
+	assert.NoError(t, (&ReputationConfig{Provider: provider, BlockBelow: 0.2}).Validate())
+	assert.Error(t, (&ReputationConfig{}).Validate())
+	assert.Error(t, (&ReputationConfig{Provider: provider, BlockBelow: 2}).Validate())
+	assert.Error(t, (&ReputationConfig{Provider: provider, CacheTTL: -time.Second}).Validate())
+}
@@ -0,0 +1,190 @@
+package limiter
+
+import (
+	"context"
+	"time"
+)
+
+// Reservation holds tokens taken ahead of time, modeled on the one in
+// golang.org/x/time/rate. The caller must wait Delay before acting, or
+// Cancel the reservation to give the tokens back.
+type Reservation struct {
+	ok        bool
+	timeToAct time.Time
+	refund    func()
+}
+
+// OK reports whether the limiter can provide the requested tokens at all.
+// It is false if n is not positive or exceeds the burst of any limit, or
+// if a Store or an Algorithm other than the token bucket denied the
+// request.
+func (r *Reservation) OK() bool {
+	return r.ok
+}
+
+func (r *Reservation) Delay() time.Duration {
+	return r.DelayFrom(time.Now())
+}
+
+func (r *Reservation) DelayFrom(now time.Time) time.Duration {
+	if !r.ok {
+		return 0
+	}
+	if delay := r.timeToAct.Sub(now); delay > 0 {
+		return delay
+	}
+	return 0
+}
+
+// Cancel returns the reserved tokens if the reservation has not come due
+// yet. Tokens taken from a Store or an Algorithm are not returned.
+func (r *Reservation) Cancel() {
+	if !r.ok || r.refund == nil || !time.Now().Before(r.timeToAct) {
+		return
+	}
+	r.refund()
+	r.refund = nil
+}
+
+func (rl *RateLimiter) Reserve(key string) *Reservation {
+	return rl.ReserveN(key, 1)
+}
+
+// ReserveN takes n tokens for key even if they are not available yet and
+// reports how long the caller has to wait for them. Unlike the middleware
+// the in-memory buckets may run into debt, which later requests pay off.
+func (rl *RateLimiter) ReserveN(key string, n int) *Reservation {
+	key = rl.storeKey(key)
+	if !rl.canReserve(key, n) {
+		rl.denied.Add(1)
+		return &Reservation{}
+	}
+
+	now := time.Now()
+	var delay time.Duration
+	if rl.global != nil {
+		rl.global.mutex.Lock()
+		delay = rl.global.reserve(n, now)
+		rl.global.mutex.Unlock()
+	}
+	if rl.rules != nil {
+		if d := rl.rules.reserve(key, n, now); d > delay {
+			delay = d
+		}
+	}
+	refundLimits := func() {
+		if rl.rules != nil {
+			rl.rules.refund(key, n)
+		}
+		if rl.global != nil {
+			rl.global.refund(n)
+		}
+	}
+
+	if rl.config.Store != nil || rl.algorithm != nil {
+		var allowed bool
+		var err error
+		if rl.config.Store != nil {
+			allowed, _, err = rl.takeKey(context.Background(), key, n, true)
+		} else {
+			var d time.Duration
+			if allowed, d = rl.algorithm.Take(key, n, now); d > delay {
+				delay = d
+			}
+		}
+		if err == nil && !allowed {
+			refundLimits()
+			rl.denied.Add(1)
+			return &Reservation{}
+		}
+		rl.allowed.Add(1)
+		return &Reservation{ok: true, timeToAct: now.Add(delay), refund: refundLimits}
+	}
+
+	bucket := rl.getBucket(key)
+	bucket.mutex.Lock()
+	rl.configure(bucket, key)
+	if d := bucket.reserve(n, now); d > delay {
+		delay = d
+	}
+	bucket.mutex.Unlock()
+
+	rl.allowed.Add(1)
+	return &Reservation{
+		ok:        true,
+		timeToAct: now.Add(delay),
+		refund: func() {
+			bucket.refund(n)
+			refundLimits()
+		},
+	}
+}
+
+// canReserve reports whether n tokens fit into every bucket of key at
+// once; otherwise no amount of waiting would make them available.
+func (rl *RateLimiter) canReserve(key string, n int) bool {
+	return n > 0 && n <= rl.capacity(key, nil)
+}
+
+// fits is canReserve for a request, which its Scopes limit as well.
+func (rl *RateLimiter) fits(key string, n int, scopes []string) bool {
+	return n > 0 && n <= rl.capacity(key, scopes)
+}
+
+// CapacityRule is the Rule reported in LimitInfo when DryRun admits a
+// request whose cost exceeds what its limits can ever hold.
+const CapacityRule = "capacity"
+
+// capacity is the largest cost that every limit of key can admit at once,
+// counting the Scopes only for a request that has them. An Algorithm that
+// is a Quoter admits at most the limit it reports, which for all but GCRA
+// leaves out BurstMultiplier.
+func (rl *RateLimiter) capacity(key string, scopes []string) int {
+	var max int
+	if limit, ok := rl.limitFor(key); ok {
+		max = limit.MaxTokens
+	} else if quoter, ok := rl.algorithm.(Quoter); ok {
+		max, _, _ = quoter.Quota(key, time.Now())
+	} else {
+		l := rl.limits()
+		max = l.maxTokens * l.burstMultiplier
+	}
+	if rl.global != nil {
+		max = minInt(max, rl.global.maxTokens)
+	}
+	for _, rule := range rl.config.Rules {
+		max = minInt(max, rule.MaxTokens)
+	}
+	if scopes != nil {
+		for _, scope := range rl.config.Scopes {
+			max = minInt(max, scope.MaxTokens)
+		}
+	}
+	return max
+}
+
+// reserve takes n tokens even if that puts the bucket into debt and
+// returns how long it takes until the debt is paid off. The caller must
+// hold the bucket's lock.
+func (b *tokenBucket) reserve(n int, now time.Time) time.Duration {
+	b.refill(now)
+	delay := b.retryAfter(n, now)
+	b.tokens -= n
+	return delay
+}
+
+func (s *stackedRules) reserve(key string, n int, now time.Time) time.Duration {
+	set := s.get(key, now)
+
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+
+	set.lastUsed = now
+	var delay time.Duration
+	for _, bucket := range set.buckets {
+		if d := bucket.reserve(n, now); d > delay {
+			delay = d
+		}
+	}
+	return delay
+}
@@ -0,0 +1,117 @@
+package limiter
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReserveDelay(t *testing.T) {
+	limiter, err := New(RateLimitConfig{
+		MaxTokens:          2,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+	})
+	assert.NoError(t, err)
+
+	now := time.Now()
+	r := limiter.ReserveN("job", 2)
+	assert.True(t, r.OK())
+	assert.Equal(t, time.Duration(0), r.Delay())
+
+	// 令牌不足时需要等待下一次补充
+	r = limiter.Reserve("job")
+	assert.True(t, r.OK())
+	assert.InDelta(t, float64(time.Minute), float64(r.DelayFrom(now)), float64(time.Second))
+
+	// 预留会排在之前的预留之后
+	r = limiter.Reserve("job")
+	assert.InDelta(t, float64(2*time.Minute), float64(r.DelayFrom(now)), float64(time.Second))
+	assert.False(t, limiter.Allow("job"))
+
+	// 超过桶容量的预留永远无法满足
+	assert.False(t, limiter.ReserveN("job", 3).OK())
+
+	// 非正数的 n 被拒绝，不会归还令牌
+	assert.False(t, limiter.ReserveN("fresh", -5).OK())
+	assert.False(t, limiter.ReserveN("fresh", 0).OK())
+	assert.True(t, limiter.ReserveN("fresh", 2).OK())
+	assert.False(t, limiter.Allow("fresh"))
+}
+
+func TestReserveCancel(t *testing.T) {
+	limiter, err := New(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		GlobalLimit:        &Limit{MaxTokens: 1, RefillRate: 1, RefillInterval: time.Minute},
+	})
+	assert.NoError(t, err)
+
+	assert.True(t, limiter.Allow("job"))
+	r := limiter.Reserve("job")
+	assert.True(t, r.OK())
+	assert.Greater(t, r.Delay(), time.Duration(0))
+
+	// 取消后令牌归还，下一次预留不再排在其后
+	r.Cancel()
+	r = limiter.Reserve("job")
+	assert.InDelta(t, float64(time.Minute), float64(r.Delay()), float64(time.Second))
+}
+
+func TestReserveAlgorithm(t *testing.T) {
+	limiter, err := New(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Algorithm:          FixedWindow,
+	})
+	assert.NoError(t, err)
+
+	assert.True(t, limiter.Reserve("job").OK())
+	assert.False(t, limiter.Reserve("job").OK())
+}
+
+func TestCapacityAlgorithm(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	// 只有令牌桶和 GCRA 的容量包含突发倍数
+	for algorithm, capacity := range map[string]int{
+		TokenBucket:          6,
+		GCRA:                 6,
+		SlidingWindowCounter: 3,
+		SlidingWindowLog:     3,
+		FixedWindow:          3,
+		LeakyBucket:          3,
+	} {
+		config := perMinute(3)
+		config.BurstMultiplier = 2
+		config.Algorithm = algorithm
+		cost := capacity
+		config.Cost = func(c *gin.Context) int { return cost }
+		limiter, err := New(config)
+		assert.NoError(t, err)
+		assert.Equal(t, capacity, limiter.capacity("192.168.1.1", nil), algorithm)
+
+		router := gin.New()
+		router.Use(limiter.RateLimitMiddleware())
+		router.GET("/", func(c *gin.Context) {
+			c.String(http.StatusOK, "Hello, world!")
+		})
+
+		// 开销等于容量时可以等待令牌，超过容量时返回 413 而不是 429
+		assert.NotEqual(t, http.StatusRequestEntityTooLarge, serve(router, "GET", "/", nil).Code, algorithm)
+		cost = capacity + 1
+		assert.Equal(t, http.StatusRequestEntityTooLarge, serve(router, "GET", "/", nil).Code, algorithm)
+	}
+}
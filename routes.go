@@ -0,0 +1,187 @@
+package limiter
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouteLimiter applies a separate configuration to each registered route,
+// so one middleware can give /login 5 requests per minute and /search
+// 100. Routes are matched against the route template from c.FullPath(),
+// e.g. "/users/:id", optionally preceded by a method such as "POST /login"
+// to limit methods of one path differently. Requests to other routes use
+// the route registered as "", and are not limited if there is none.
+// Limiters that share a Store need distinct Namespaces.
+type RouteLimiter struct {
+	routes   map[string]*RateLimiter
+	patterns []*routePattern
+	mutex    sync.RWMutex
+}
+
+type routePattern struct {
+	pattern string
+	method  string
+	re      *regexp.Regexp
+	limiter *RateLimiter
+}
+
+func NewRouteLimiter() *RouteLimiter {
+	return &RouteLimiter{routes: make(map[string]*RateLimiter)}
+}
+
+// Register sets the configuration of route, replacing any earlier one. It
+// may be called while the middleware is serving requests. A route with
+// "*" is a glob: "*" matches within one path segment and "**" across
+// segments, so "/api/v1/admin/**" covers every admin endpoint. Exact
+// routes take precedence over patterns, which are tried in the order
+// they were registered, and routes with a method over those without.
+func (r *RouteLimiter) Register(route string, config RateLimitConfig) error {
+	if method, path := splitMethod(route); strings.Contains(path, "*") {
+		return r.register(route, method, regexp.MustCompile(globPattern(path)), config)
+	}
+	limiter, err := New(config)
+	if err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.routes[route] = limiter
+	return nil
+}
+
+// RegisterRegexp sets the configuration of the routes matching expr, a
+// regular expression that is not anchored unless it says so. Like routes
+// it may be preceded by a method.
+func (r *RouteLimiter) RegisterRegexp(expr string, config RateLimitConfig) error {
+	method, path := splitMethod(expr)
+	re, err := regexp.Compile(path)
+	if err != nil {
+		return err
+	}
+	return r.register(expr, method, re, config)
+}
+
+// splitMethod splits "POST /login" into its method and path.
+func splitMethod(route string) (string, string) {
+	method, path, ok := strings.Cut(route, " ")
+	if !ok || method == "" || strings.ToUpper(method) != method {
+		return "", route
+	}
+	return method, path
+}
+
+func (r *RouteLimiter) register(pattern, method string, re *regexp.Regexp, config RateLimitConfig) error {
+	limiter, err := New(config)
+	if err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, p := range r.patterns {
+		if p.pattern == pattern {
+			p.limiter = limiter
+			return nil
+		}
+	}
+	r.patterns = append(r.patterns, &routePattern{pattern: pattern, method: method, re: re, limiter: limiter})
+	return nil
+}
+
+// globPattern translates a route glob into an anchored regular expression.
+func globPattern(glob string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			b.WriteString("(/.*)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			b.WriteString("[^/]*")
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// Limiter returns the limiter registered for route, which may also be a
+// pattern, or nil if there is none.
+func (r *RouteLimiter) Limiter(route string) *RateLimiter {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if limiter, ok := r.routes[route]; ok {
+		return limiter
+	}
+	for _, p := range r.patterns {
+		if p.pattern == route {
+			return p.limiter
+		}
+	}
+	return nil
+}
+
+func (r *RouteLimiter) CleanupExpiredBuckets() {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	for _, limiter := range r.routes {
+		limiter.CleanupExpiredBuckets()
+	}
+	for _, p := range r.patterns {
+		p.limiter.CleanupExpiredBuckets()
+	}
+}
+
+func (r *RouteLimiter) lookup(c *gin.Context) *RateLimiter {
+	route := c.FullPath()
+	method := c.Request.Method
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if limiter, ok := r.routes[method+" "+route]; ok && route != "" {
+		return limiter
+	}
+	if limiter, ok := r.routes[route]; ok {
+		return limiter
+	}
+	if route != "" {
+		if limiter := r.match(method, route); limiter != nil {
+			return limiter
+		}
+		if limiter := r.match("", route); limiter != nil {
+			return limiter
+		}
+	}
+	return r.routes[""]
+}
+
+// match returns the limiter of the first pattern for method that matches
+// route; the caller holds the lock.
+func (r *RouteLimiter) match(method, route string) *RateLimiter {
+	for _, p := range r.patterns {
+		if p.method == method && p.re.MatchString(route) {
+			return p.limiter
+		}
+	}
+	return nil
+}
+
+func (r *RouteLimiter) RateLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limiter := r.lookup(c)
+		if limiter == nil {
+			c.Next()
+			return
+		}
+		limiter.RateLimitMiddleware()(c)
+	}
+}
@@ -0,0 +1,139 @@
+package limiter
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteLimiter(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	routes := NewRouteLimiter()
+	assert.NoError(t, routes.Register("/login", perMinute(1)))
+	assert.NoError(t, routes.Register("/users/:id", perMinute(2)))
+	assert.Error(t, routes.Register("/bad", RateLimitConfig{}))
+
+	router := gin.New()
+	router.Use(routes.RateLimitMiddleware())
+	for _, path := range []string{"/login", "/users/:id", "/search"} {
+		router.GET(path, func(c *gin.Context) {
+			c.String(http.StatusOK, "Hello, world!")
+		})
+	}
+
+	// 每个路由使用各自的配置
+	assert.Equal(t, http.StatusOK, serve(router, "GET", "/login", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(router, "GET", "/login", nil).Code)
+
+	// 按路由模板匹配，不同参数共享同一配置
+	assert.Equal(t, http.StatusOK, serve(router, "GET", "/users/1", nil).Code)
+	assert.Equal(t, http.StatusOK, serve(router, "GET", "/users/2", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(router, "GET", "/users/3", nil).Code)
+
+	// 未注册的路由不限流
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, serve(router, "GET", "/search", nil).Code)
+	}
+
+	// 运行时注册的默认配置用于其他路由
+	assert.NoError(t, routes.Register("", perMinute(1)))
+	assert.Equal(t, http.StatusOK, serve(router, "GET", "/search", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(router, "GET", "/search", nil).Code)
+
+	assert.NotNil(t, routes.Limiter("/login"))
+	assert.Nil(t, routes.Limiter("/missing"))
+}
+
+func TestGlobPattern(t *testing.T) {
+	tests := []struct {
+		glob  string
+		route string
+		match bool
+	}{
+		{"/api/v1/admin/**", "/api/v1/admin", true},
+		{"/api/v1/admin/**", "/api/v1/admin/users/:id", true},
+		{"/api/v1/admin/**", "/api/v1/administrators", false},
+		{"/api/*/users", "/api/v2/users", true},
+		{"/api/*/users", "/api/v2/beta/users", false},
+		{"/files/**/raw", "/files/a/b/raw", true},
+		{"/static/*.css", "/static/site.css", true},
+		{"/static/*.css", "/static/sitexcss", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.match, regexp.MustCompile(globPattern(tt.glob)).MatchString(tt.route), tt.glob+" "+tt.route)
+	}
+}
+
+func TestRouteLimiterPatterns(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	routes := NewRouteLimiter()
+	assert.NoError(t, routes.Register("/api/v1/admin/stats", perMinute(3)))
+	assert.NoError(t, routes.Register("/api/v1/admin/**", perMinute(1)))
+	assert.NoError(t, routes.RegisterRegexp(`^/api/v\d+/reports/`, perMinute(2)))
+	assert.Error(t, routes.RegisterRegexp(`(`, perMinute(2)))
+
+	router := gin.New()
+	router.Use(routes.RateLimitMiddleware())
+	for _, path := range []string{"/api/v1/admin/users", "/api/v1/admin/stats", "/api/v2/reports/:id"} {
+		router.GET(path, func(c *gin.Context) {
+			c.String(http.StatusOK, "Hello, world!")
+		})
+	}
+
+	// 通配符匹配所有管理接口
+	assert.Equal(t, http.StatusOK, serve(router, "GET", "/api/v1/admin/users", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(router, "GET", "/api/v1/admin/users", nil).Code)
+
+	// 精确路由优先于通配符
+	assert.Equal(t, http.StatusOK, serve(router, "GET", "/api/v1/admin/stats", nil).Code)
+	assert.Equal(t, http.StatusOK, serve(router, "GET", "/api/v1/admin/stats", nil).Code)
+
+	// 正则表达式匹配路由模板
+	assert.Equal(t, http.StatusOK, serve(router, "GET", "/api/v2/reports/1", nil).Code)
+	assert.Equal(t, http.StatusOK, serve(router, "GET", "/api/v2/reports/2", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(router, "GET", "/api/v2/reports/3", nil).Code)
+
+	assert.NotNil(t, routes.Limiter("/api/v1/admin/**"))
+}
+
+func TestRouteLimiterMethods(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	routes := NewRouteLimiter()
+	assert.NoError(t, routes.Register("/items", perMinute(3)))
+	assert.NoError(t, routes.Register("POST /items", perMinute(1)))
+	assert.NoError(t, routes.Register("DELETE /items/**", perMinute(1)))
+
+	router := gin.New()
+	router.Use(routes.RateLimitMiddleware())
+	handler := func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	}
+	router.GET("/items", handler)
+	router.POST("/items", handler)
+	router.GET("/items/:id", handler)
+	router.DELETE("/items/:id", handler)
+
+	// 同一路径的不同方法使用不同的限额
+	assert.Equal(t, http.StatusOK, serve(router, "POST", "/items", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(router, "POST", "/items", nil).Code)
+	assert.Equal(t, http.StatusOK, serve(router, "GET", "/items", nil).Code)
+	assert.Equal(t, http.StatusOK, serve(router, "GET", "/items", nil).Code)
+
+	// 带方法的通配符只匹配该方法
+	assert.Equal(t, http.StatusOK, serve(router, "DELETE", "/items/1", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(router, "DELETE", "/items/2", nil).Code)
+	assert.Equal(t, http.StatusOK, serve(router, "GET", "/items/1", nil).Code)
+	assert.Equal(t, http.StatusOK, serve(router, "GET", "/items/1", nil).Code)
+
+	assert.NotNil(t, routes.Limiter("POST /items"))
+}
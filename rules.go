@@ -0,0 +1,147 @@
+package limiter
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Rule is an additional limit enforced for every key on top of the main
+// bucket, e.g. 1,000 per hour next to 10 per second. StatusCode and
+// LimitExceededHandler override the limiter-wide settings for requests
+// this rule denies, so different violations can be treated differently.
+type Rule struct {
+	Name                 string
+	StatusCode           int
+	LimitExceededHandler gin.HandlerFunc
+	Limit
+}
+
+func (r Rule) Validate() error {
+	if err := r.Limit.Validate(); err != nil {
+		return err
+	}
+	if r.StatusCode != 0 && (r.StatusCode < 400 || r.StatusCode > 599) {
+		return errors.New("StatusCode must be a 4xx or 5xx status")
+	}
+	return nil
+}
+
+type ruleSet struct {
+	buckets  []*tokenBucket
+	lastUsed time.Time
+	mutex    sync.Mutex
+}
+
+// stackedRules evaluates all rules of a key under one lock, so tokens are
+// only taken when every rule admits the request.
+type stackedRules struct {
+	rules []Rule
+	// idle is how long a key must be unused before every bucket would
+	// be full again; dropping it earlier would reset long windows.
+	idle  time.Duration
+	sets  map[string]*ruleSet
+	mutex sync.Mutex
+}
+
+func newStackedRules(rules []Rule) *stackedRules {
+	s := &stackedRules{
+		rules: rules,
+		sets:  make(map[string]*ruleSet),
+	}
+	for _, rule := range rules {
+		refills := (rule.MaxTokens + rule.RefillRate - 1) / rule.RefillRate
+		if idle := time.Duration(refills) * rule.RefillInterval; idle > s.idle {
+			s.idle = idle
+		}
+	}
+	return s
+}
+
+func (s *stackedRules) get(key string, now time.Time) *ruleSet {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	set, exists := s.sets[key]
+	if !exists {
+		set = &ruleSet{buckets: make([]*tokenBucket, len(s.rules))}
+		for i, rule := range s.rules {
+			set.buckets[i] = newLimitBucket(rule.Limit, now)
+		}
+		s.sets[key] = set
+	}
+	return set
+}
+
+func (s *stackedRules) take(key string, n int, now time.Time) bool {
+	set := s.get(key, now)
+
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+
+	set.lastUsed = now
+	for _, bucket := range set.buckets {
+		bucket.refill(now)
+		if bucket.tokens < n {
+			return false
+		}
+	}
+	for _, bucket := range set.buckets {
+		bucket.tokens -= n
+	}
+	return true
+}
+
+// reject reports the rule of key that takes longest to admit n tokens.
+func (s *stackedRules) reject(key string, n int, now time.Time) rejection {
+	set := s.get(key, now)
+
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+
+	var r rejection
+	for i, bucket := range set.buckets {
+		if candidate := bucket.rejection(s.rules[i].Name, n, now); candidate.retryAfter >= r.retryAfter {
+			candidate.status = s.rules[i].StatusCode
+			candidate.handler = s.rules[i].LimitExceededHandler
+			r = candidate
+		}
+	}
+	return r
+}
+
+func (s *stackedRules) refund(key string, n int) {
+	set := s.get(key, time.Now())
+
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+
+	for _, bucket := range set.buckets {
+		bucket.tokens = minInt(bucket.tokens+n, bucket.maxTokens)
+	}
+}
+
+func (s *stackedRules) reset(key string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.sets, key)
+}
+
+func (s *stackedRules) cleanup(now time.Time, expiration time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.idle > expiration {
+		expiration = s.idle
+	}
+	for key, set := range s.sets {
+		set.mutex.Lock()
+		if now.Sub(set.lastUsed) > expiration {
+			delete(s.sets, key)
+		}
+		set.mutex.Unlock()
+	}
+}
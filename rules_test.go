@@ -0,0 +1,136 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStackedRules_AllMustPass(t *testing.T) {
+	rules := newStackedRules([]Rule{
+		{Name: "second", Limit: Limit{MaxTokens: 2, RefillRate: 2, RefillInterval: time.Second}},
+		{Name: "hour", Limit: Limit{MaxTokens: 3, RefillRate: 3, RefillInterval: time.Hour}},
+	})
+	now := time.Now()
+
+	assert.True(t, rules.take("key", 1, now))
+	assert.True(t, rules.take("key", 1, now))
+	// 每秒规则耗尽
+	assert.False(t, rules.take("key", 1, now))
+
+	// 一秒后每秒规则恢复，但每小时规则只剩一个令牌
+	assert.True(t, rules.take("key", 1, now.Add(time.Second)))
+	assert.False(t, rules.take("key", 1, now.Add(time.Second)))
+	assert.Equal(t, 1, rules.sets["key"].buckets[0].tokens, "rejected request must not consume tokens")
+
+	// 长周期规则使空闲的键保留足够长的时间
+	rules.cleanup(now.Add(time.Minute*10), time.Minute*5)
+	assert.Contains(t, rules.sets, "key")
+	rules.cleanup(now.Add(time.Hour*2), time.Minute*5)
+	assert.NotContains(t, rules.sets, "key")
+}
+
+func TestRateLimiterRulesRefundedOnKeyDenial(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	config := RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Rules: []Rule{
+			{Name: "day", Limit: Limit{MaxTokens: 10, RefillRate: 10, RefillInterval: time.Hour * 24}},
+		},
+	}
+
+	limiter := &RateLimiter{
+		buckets: make(map[string]*tokenBucket),
+		config:  config,
+		rules:   newStackedRules(config.Rules),
+	}
+
+	router := gin.New()
+	router.Use(limiter.RateLimitMiddleware())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+	for _, want := range []int{http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, want, w.Code)
+	}
+
+	// 被主令牌桶拒绝的请求退还了每日规则的令牌
+	assert.Equal(t, 9, limiter.rules.sets["192.168.1.1"].buckets[0].tokens)
+}
+
+func TestRuleRejectionBehavior(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	abuse := Rule{Name: "abuse", StatusCode: http.StatusServiceUnavailable, LimitExceededHandler: func(c *gin.Context) {
+		info, _ := FromContext(c)
+		c.Header("X-Banned", info.Rule)
+		c.AbortWithStatus(getDenial(c).status)
+	}}
+
+	tests := []struct {
+		name   string
+		burst  int
+		abuse  int
+		status int
+		banned string
+	}{
+		// 突发规则使用全局的拒绝方式
+		{name: "burst", burst: 1, abuse: 5, status: http.StatusTooManyRequests},
+		// 滥用规则使用自己的状态码和处理函数
+		{name: "abuse", burst: 5, abuse: 1, status: http.StatusServiceUnavailable, banned: "abuse"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			abuse.Limit = Limit{MaxTokens: tt.abuse, RefillRate: tt.abuse, RefillInterval: time.Hour}
+			limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+				MaxTokens:          10,
+				RefillRate:         10,
+				RefillInterval:     time.Minute,
+				KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+				BurstMultiplier:    1,
+				ExpirationDuration: time.Minute * 5,
+				Rules: []Rule{
+					{Name: "burst", Limit: Limit{MaxTokens: tt.burst, RefillRate: tt.burst, RefillInterval: time.Minute}},
+					abuse,
+				},
+			})
+			assert.NoError(t, err)
+
+			router := gin.New()
+			router.Use(limiterMiddleware)
+			router.GET("/", func(c *gin.Context) {
+				c.String(http.StatusOK, "Hello, world!")
+			})
+
+			req, _ := http.NewRequest("GET", "/", nil)
+			req.RemoteAddr = "192.168.1.1:1234"
+			router.ServeHTTP(httptest.NewRecorder(), req)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.banned, w.Header().Get("X-Banned"))
+		})
+	}
+}
+
+func TestValidateRuleStatusCode(t *testing.T) {
+	rule := Rule{Name: "abuse", StatusCode: http.StatusOK, Limit: Limit{MaxTokens: 1, RefillRate: 1, RefillInterval: time.Hour}}
+	assert.EqualError(t, rule.Validate(), "StatusCode must be a 4xx or 5xx status")
+}
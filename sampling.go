@@ -0,0 +1,20 @@
+package limiter
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// sampled reports whether the requests of key are limited under
+// SampleRate. The choice depends only on the key, so a client is either
+// limited or not for as long as the rate stays the same, and raising the
+// rate only adds keys to those limited. Without a SampleRate every key is
+// limited, and with 0 none is.
+func (rl *RateLimiter) sampled(key string) bool {
+	if rl.config.SampleRate == nil || *rl.config.SampleRate >= 1 {
+		return true
+	}
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return float64(h.Sum64())/math.MaxUint64 < *rl.config.SampleRate
+}
@@ -0,0 +1,69 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterSampleRate(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	config := RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            ByHeader("X-User"),
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+	}
+	rate := 0.25
+	config.SampleRate = &rate
+
+	limiterMiddleware, err := NewRateLimiter(config)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func(user string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		req.Header.Set("X-User", user)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 只有部分键受到限制，且同一个键的结果稳定
+	limited := 0
+	for i := 0; i < 1000; i++ {
+		user := "user" + strconv.Itoa(i)
+		request(user)
+		second := request(user)
+		assert.Equal(t, second, request(user))
+		if second == http.StatusTooManyRequests {
+			limited++
+		}
+	}
+	assert.InDelta(t, 250, limited, 60)
+
+	// 比例为 0 时不限制任何键
+	rate = 0
+	limiter, err := New(config)
+	assert.NoError(t, err)
+	assert.False(t, limiter.sampled("user1"))
+
+	// 无效的比例会被拒绝
+	rate = 1.5
+	assert.Error(t, config.Validate())
+}
@@ -0,0 +1,110 @@
+package limiter
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// ScheduleWindow replaces the main limit during a daily window, e.g. a
+// tighter limit during business hours. Start and End are clock times such
+// as "09:00" in Location, or UTC if it is nil; a window that ends before
+// it starts runs past midnight, and one that ends when it starts lasts all
+// day. Days restricts the window to the weekdays it starts on.
+type ScheduleWindow struct {
+	Days     []time.Weekday
+	Start    string
+	End      string
+	Location *time.Location
+	Limit    RateLimitParams
+}
+
+func (w *ScheduleWindow) Validate() error {
+	if _, err := parseClock(w.Start); err != nil {
+		return errors.New("Start " + err.Error())
+	}
+	if _, err := parseClock(w.End); err != nil {
+		return errors.New("End " + err.Error())
+	}
+	if err := w.Limit.Validate(); err != nil {
+		return errors.New("Limit." + err.Error())
+	}
+	return nil
+}
+
+func validateSchedule(r *RateLimitConfig) error {
+	if len(r.Schedule) > 0 && r.Algorithm != "" && r.Algorithm != TokenBucket {
+		return errors.New("Schedule is only supported by the token bucket algorithm")
+	}
+	for i := range r.Schedule {
+		if err := r.Schedule[i].Validate(); err != nil {
+			return errors.New("Schedule[" + strconv.Itoa(i) + "]." + err.Error())
+		}
+	}
+	return nil
+}
+
+// parseClock returns the minutes since midnight of a "15:04" clock time.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, errors.New("must be a clock time such as 09:00")
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+type scheduleWindow struct {
+	days     map[time.Weekday]bool
+	start    int
+	end      int
+	location *time.Location
+	limit    Limit
+}
+
+type schedule []scheduleWindow
+
+func newSchedule(windows []ScheduleWindow) schedule {
+	s := make(schedule, 0, len(windows))
+	for _, w := range windows {
+		window := scheduleWindow{location: w.Location, limit: w.Limit}
+		window.start, _ = parseClock(w.Start)
+		window.end, _ = parseClock(w.End)
+		if window.location == nil {
+			window.location = time.UTC
+		}
+		if len(w.Days) > 0 {
+			window.days = make(map[time.Weekday]bool, len(w.Days))
+			for _, day := range w.Days {
+				window.days[day] = true
+			}
+		}
+		s = append(s, window)
+	}
+	return s
+}
+
+// limit returns the limit of the first window that is open at now.
+func (s schedule) limit(now time.Time) (Limit, bool) {
+	for _, w := range s {
+		if w.open(now) {
+			return w.limit, true
+		}
+	}
+	return Limit{}, false
+}
+
+func (w *scheduleWindow) open(now time.Time) bool {
+	local := now.In(w.location)
+	minute := local.Hour()*60 + local.Minute()
+	today := w.days == nil || w.days[local.Weekday()]
+	switch {
+	case w.start == w.end:
+		return today
+	case w.start < w.end:
+		return today && minute >= w.start && minute < w.end
+	}
+	// The window runs past midnight; after midnight it belongs to the
+	// day it started on.
+	yesterday := w.days == nil || w.days[local.AddDate(0, 0, -1).Weekday()]
+	return (today && minute >= w.start) || (yesterday && minute < w.end)
+}
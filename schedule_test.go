@@ -0,0 +1,90 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleLimit(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*60*60)
+	business := Limit{MaxTokens: 10, RefillRate: 10, RefillInterval: time.Minute}
+	night := Limit{MaxTokens: 100, RefillRate: 100, RefillInterval: time.Minute}
+	s := newSchedule([]ScheduleWindow{
+		{
+			Days:     []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+			Start:    "09:00",
+			End:      "18:00",
+			Location: shanghai,
+			Limit:    business,
+		},
+		{Days: []time.Weekday{time.Friday}, Start: "22:00", End: "06:00", Location: shanghai, Limit: night},
+	})
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected Limit
+		open     bool
+	}{
+		{"工作时间", time.Date(2026, 10, 16, 10, 0, 0, 0, shanghai), business, true},
+		{"按时区换算", time.Date(2026, 10, 16, 2, 0, 0, 0, time.UTC), business, true},
+		{"下班之后", time.Date(2026, 10, 16, 18, 0, 0, 0, shanghai), Limit{}, false},
+		{"周末", time.Date(2026, 10, 17, 10, 0, 0, 0, shanghai), Limit{}, false},
+		{"跨越午夜的窗口", time.Date(2026, 10, 16, 23, 0, 0, 0, shanghai), night, true},
+		{"午夜之后属于开始的那天", time.Date(2026, 10, 17, 5, 0, 0, 0, shanghai), night, true},
+		{"其他日子的夜间", time.Date(2026, 10, 15, 23, 0, 0, 0, shanghai), Limit{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, open := s.limit(tt.now)
+			assert.Equal(t, tt.open, open)
+			assert.Equal(t, tt.expected, limit)
+		})
+	}
+}
+
+func TestRateLimiterSchedule(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	config := RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Schedule: []ScheduleWindow{
+			// 全天开放的窗口
+			{Start: "00:00", End: "00:00", Limit: RateLimitParams{MaxTokens: 3, RefillRate: 3, RefillInterval: time.Minute}},
+		},
+	}
+
+	limiterMiddleware, err := NewRateLimiter(config)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	// 当前窗口的限额生效
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "3", w.Header().Get("X-RateLimit-Limit"))
+
+	// 无效的窗口会被拒绝
+	config.Schedule = []ScheduleWindow{{Start: "9am", End: "18:00", Limit: RateLimitParams{MaxTokens: 1, RefillRate: 1, RefillInterval: time.Minute}}}
+	assert.EqualError(t, config.Validate(), "Schedule[0].Start must be a clock time such as 09:00")
+	config.Schedule = []ScheduleWindow{{Start: "09:00", End: "18:00"}}
+	assert.Error(t, config.Validate())
+}
@@ -0,0 +1,15 @@
+package limiter
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setServerTiming reports how long the limiter took to decide, store round
+// trips and any waiting included, next to other Server-Timing entries.
+func setServerTiming(c *gin.Context, d time.Duration) {
+	ms := strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+	c.Writer.Header().Add("Server-Timing", "ratelimit;dur="+ms)
+}
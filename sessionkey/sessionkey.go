@@ -0,0 +1,51 @@
+// Package sessionkey keys limiter requests by gin-contrib/sessions
+// sessions, so logged-in users keep their own bucket behind
+// carrier-grade NAT.
+package sessionkey
+
+import (
+	"fmt"
+
+	limiter "github.com/colommar/gin-ratelimiter"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// ByID keys requests by the ID of the session. The sessions middleware
+// must run before the limiter. Requests without a session, and stores
+// that do not assign IDs such as the cookie store, are keyed like
+// limiter.ByClientIP instead; use ByValue for those.
+func ByID() func(*gin.Context) string {
+	clientIP := limiter.ByClientIP()
+	return func(c *gin.Context) string {
+		if session := currentSession(c); session != nil && session.ID() != "" {
+			return session.ID()
+		}
+		return clientIP(c)
+	}
+}
+
+// ByValue keys requests by a value stored in the session, such as the
+// user ID saved at login, or like limiter.ByClientIP if it is not set.
+func ByValue(name string) func(*gin.Context) string {
+	clientIP := limiter.ByClientIP()
+	return func(c *gin.Context) string {
+		if session := currentSession(c); session != nil {
+			if value := session.Get(name); value != nil {
+				if key := fmt.Sprint(value); key != "" {
+					return key
+				}
+			}
+		}
+		return clientIP(c)
+	}
+}
+
+// currentSession returns the session of c, or nil if the sessions
+// middleware did not run.
+func currentSession(c *gin.Context) sessions.Session {
+	if _, ok := c.Get(sessions.DefaultKey); !ok {
+		return nil
+	}
+	return sessions.Default(c)
+}
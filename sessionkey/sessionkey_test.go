@@ -0,0 +1,53 @@
+package sessionkey
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type testSession struct {
+	sessions.Session
+	id     string
+	values map[any]any
+}
+
+func (s testSession) ID() string {
+	return s.id
+}
+
+func (s testSession) Get(key any) any {
+	return s.values[key]
+}
+
+func TestSessionKeys(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	newContext := func() *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request, _ = http.NewRequest("GET", "/", nil)
+		c.Request.RemoteAddr = "192.168.1.1:1234"
+		return c
+	}
+	withSession := func(session sessions.Session) *gin.Context {
+		c := newContext()
+		c.Set(sessions.DefaultKey, session)
+		return c
+	}
+	session := testSession{id: "abc123", values: map[any]any{"user_id": 42}}
+
+	// 按会话 ID 生成键
+	assert.Equal(t, "abc123", ByID()(withSession(session)))
+	assert.Equal(t, "42", ByValue("user_id")(withSession(session)))
+
+	// 没有会话、会话 ID 为空或值缺失时回退到客户端 IP
+	assert.Equal(t, "192.168.1.1", ByID()(newContext()))
+	assert.Equal(t, "192.168.1.1", ByID()(withSession(testSession{})))
+	assert.Equal(t, "192.168.1.1", ByValue("user_id")(newContext()))
+	assert.Equal(t, "192.168.1.1", ByValue("missing")(withSession(session)))
+}
@@ -0,0 +1,38 @@
+package limiter
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SafeMethods are the methods RFC 9110 defines as safe, for use as
+// ExemptMethods.
+var SafeMethods = []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace}
+
+// skipped reports whether c bypasses the limiter before any key is
+// extracted: its method is one of ExemptMethods, its path is one of
+// SkipPaths or starts with one that ends in "*", or Skipper says so.
+func (rl *RateLimiter) skipped(c *gin.Context) bool {
+	if rl.config.Skipper != nil && rl.config.Skipper(c) {
+		return true
+	}
+	for _, method := range rl.config.ExemptMethods {
+		if strings.EqualFold(c.Request.Method, method) {
+			return true
+		}
+	}
+
+	path := c.Request.URL.Path
+	for _, skip := range rl.config.SkipPaths {
+		if prefix, ok := strings.CutSuffix(skip, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == skip {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterSkipPaths(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	keys := 0
+	config := RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { keys++; return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		SkipPaths:          []string{"/health", "/favicon.ico", "/metrics/*"},
+	}
+
+	limiterMiddleware, err := NewRateLimiter(config)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	for _, path := range []string{"/health", "/healthz", "/favicon.ico", "/metrics/prometheus", "/api"} {
+		router.GET(path, func(c *gin.Context) {
+			c.String(http.StatusOK, "Hello, world!")
+		})
+	}
+
+	request := func(path string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 跳过的路径不限流，也不提取键
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, request("/health"))
+		assert.Equal(t, http.StatusOK, request("/favicon.ico"))
+		assert.Equal(t, http.StatusOK, request("/metrics/prometheus"))
+	}
+	assert.Equal(t, 0, keys)
+
+	// 精确路径不匹配前缀相同的其他路径
+	assert.Equal(t, http.StatusOK, request("/healthz"))
+	assert.Equal(t, http.StatusTooManyRequests, request("/api"))
+}
+
+func TestRateLimiterExemptMethods(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	config := RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		ExemptMethods:      []string{http.MethodOptions, http.MethodHead},
+	}
+
+	limiterMiddleware, err := NewRateLimiter(config)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	handler := func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	}
+	router.GET("/", handler)
+	router.HEAD("/", handler)
+	router.OPTIONS("/", handler)
+
+	request := func(method string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, "/", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 预检请求不消耗令牌
+	assert.Equal(t, http.StatusNoContent, request(http.MethodOptions))
+	assert.Equal(t, http.StatusNoContent, request(http.MethodHead))
+	assert.Equal(t, http.StatusNoContent, request(http.MethodGet))
+	assert.Equal(t, http.StatusTooManyRequests, request(http.MethodGet))
+
+	// 被限流后预检请求仍然成功
+	assert.Equal(t, http.StatusNoContent, request(http.MethodOptions))
+}
+
+func TestRateLimiterSkipper(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	config := RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Skipper: func(c *gin.Context) bool {
+			return c.GetHeader("X-Internal") == "true"
+		},
+	}
+
+	limiterMiddleware, err := NewRateLimiter(config)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func(internal string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		req.Header.Set("X-Internal", internal)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Skipper 返回 true 的请求跳过限流
+	assert.Equal(t, http.StatusOK, request("false"))
+	assert.Equal(t, http.StatusTooManyRequests, request("false"))
+	assert.Equal(t, http.StatusOK, request("true"))
+	assert.Equal(t, http.StatusOK, request("true"))
+}
@@ -0,0 +1,105 @@
+package limiter
+
+import (
+	"sync"
+	"time"
+)
+
+// windowLog is a ring buffer holding the timestamps of the requests
+// admitted during the last window, oldest first.
+type windowLog struct {
+	timestamps []time.Time
+	head       int
+	size       int
+}
+
+// slidingWindowLog remembers every admitted request, so "N per window"
+// holds exactly at any instant. Memory grows with the limit, which makes
+// it a fit for low-rate endpoints that need precise semantics.
+type slidingWindowLog struct {
+	limit  int
+	window time.Duration
+	logs   map[string]*windowLog
+	mutex  sync.Mutex
+}
+
+func newSlidingWindowLog(limit int, window time.Duration) *slidingWindowLog {
+	return &slidingWindowLog{
+		limit:  limit,
+		window: window,
+		logs:   make(map[string]*windowLog),
+	}
+}
+
+func (s *slidingWindowLog) Take(key string, n int, now time.Time) (bool, time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	log, exists := s.logs[key]
+	if !exists {
+		log = &windowLog{timestamps: make([]time.Time, s.limit)}
+		s.logs[key] = log
+	}
+
+	for log.size > 0 && now.Sub(log.timestamps[log.head]) >= s.window {
+		log.head = (log.head + 1) % s.limit
+		log.size--
+	}
+
+	if n > s.limit {
+		return false, s.window
+	}
+	if log.size+n > s.limit {
+		// The request fits once enough of the oldest entries have expired.
+		oldest := log.timestamps[(log.head+log.size+n-s.limit-1)%s.limit]
+		return false, oldest.Add(s.window).Sub(now)
+	}
+
+	for i := 0; i < n; i++ {
+		log.timestamps[(log.head+log.size)%s.limit] = now
+		log.size++
+	}
+	return true, 0
+}
+
+func (s *slidingWindowLog) Quota(key string, now time.Time) (int, int, time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	log, exists := s.logs[key]
+	if !exists {
+		return s.limit, s.limit, 0
+	}
+
+	used := 0
+	var newest time.Time
+	for i := 0; i < log.size; i++ {
+		timestamp := log.timestamps[(log.head+i)%s.limit]
+		if now.Sub(timestamp) < s.window {
+			used++
+			newest = timestamp
+		}
+	}
+	if used == 0 {
+		return s.limit, s.limit, 0
+	}
+	return s.limit, s.limit - used, newest.Add(s.window).Sub(now)
+}
+
+func (s *slidingWindowLog) Reset(key string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.logs, key)
+}
+
+func (s *slidingWindowLog) Cleanup(now time.Time, expiration time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for key, log := range s.logs {
+		if log.size == 0 || now.Sub(log.timestamps[(log.head+log.size-1)%s.limit]) > expiration {
+			delete(s.logs, key)
+		}
+	}
+}
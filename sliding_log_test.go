@@ -0,0 +1,44 @@
+package limiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlidingWindowLog_ExactWindow(t *testing.T) {
+	log := newSlidingWindowLog(3, time.Minute*10)
+	start := time.Now()
+
+	// 在不同时间点放行三个请求
+	for i := 0; i < 3; i++ {
+		allowed, _ := log.Take("key", 1, start.Add(time.Minute*time.Duration(i)))
+		assert.True(t, allowed)
+	}
+
+	// 第四个请求需要等待最早的请求滑出窗口
+	allowed, retryAfter := log.Take("key", 1, start.Add(time.Minute*5))
+	assert.False(t, allowed)
+	assert.Equal(t, time.Minute*5, retryAfter)
+
+	// 最早的请求过期后立即可以放行
+	allowed, _ = log.Take("key", 1, start.Add(time.Minute*10))
+	assert.True(t, allowed)
+
+	// 一次请求两个令牌时需要等待两个最早的记录过期
+	allowed, retryAfter = log.Take("key", 2, start.Add(time.Minute*10))
+	assert.False(t, allowed)
+	assert.Equal(t, time.Minute*2, retryAfter)
+}
+
+func TestSlidingWindowLog_Cleanup(t *testing.T) {
+	log := newSlidingWindowLog(2, time.Second)
+	now := time.Now()
+	log.Take("key", 1, now)
+
+	log.Cleanup(now.Add(time.Minute), time.Second*5)
+
+	_, exists := log.logs["key"]
+	assert.False(t, exists, "Expected log to be cleaned up")
+}
@@ -0,0 +1,122 @@
+package limiter
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+type windowCount struct {
+	start    time.Time
+	current  int
+	previous int
+}
+
+// slidingWindowCounter approximates a sliding window from two adjacent
+// fixed windows, weighting the previous one by how much of it still
+// overlaps the sliding window. This avoids the boundary bursts of fixed
+// windows while keeping two counters per key.
+type slidingWindowCounter struct {
+	limit   int
+	window  time.Duration
+	windows map[string]*windowCount
+	mutex   sync.Mutex
+}
+
+func newSlidingWindowCounter(limit int, window time.Duration) *slidingWindowCounter {
+	return &slidingWindowCounter{
+		limit:   limit,
+		window:  window,
+		windows: make(map[string]*windowCount),
+	}
+}
+
+func (s *slidingWindowCounter) Take(key string, n int, now time.Time) (bool, time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	w, exists := s.windows[key]
+	if !exists {
+		w = &windowCount{start: now.Truncate(s.window)}
+		s.windows[key] = w
+	}
+
+	elapsed := now.Sub(w.start)
+	if elapsed >= 2*s.window {
+		w.start = now.Truncate(s.window)
+		w.previous = 0
+		w.current = 0
+	} else if elapsed >= s.window {
+		w.start = w.start.Add(s.window)
+		w.previous = w.current
+		w.current = 0
+	}
+
+	elapsed = now.Sub(w.start)
+	weight := 1 - float64(elapsed)/float64(s.window)
+	estimated := float64(w.previous)*weight + float64(w.current)
+
+	if estimated+float64(n) <= float64(s.limit) {
+		w.current += n
+		return true, 0
+	}
+
+	// Wait until the previous window's weight has decayed enough, or until
+	// the current window rolls over if that can never happen.
+	free := s.limit - w.current - n
+	if w.previous > 0 && free >= 0 {
+		target := 1 - float64(free)/float64(w.previous)
+		return false, time.Duration(target*float64(s.window)) - elapsed
+	}
+	return false, s.window - elapsed
+}
+
+func (s *slidingWindowCounter) Quota(key string, now time.Time) (int, int, time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	w, exists := s.windows[key]
+	if !exists {
+		return s.limit, s.limit, 0
+	}
+
+	elapsed := now.Sub(w.start)
+	previous, current := w.previous, w.current
+	if elapsed >= 2*s.window {
+		return s.limit, s.limit, 0
+	} else if elapsed >= s.window {
+		elapsed -= s.window
+		previous, current = current, 0
+	}
+
+	// The estimate only drops to zero once the current window has also
+	// slid out completely.
+	weight := 1 - float64(elapsed)/float64(s.window)
+	used := int(math.Ceil(float64(previous)*weight)) + current
+	reset := 2*s.window - elapsed
+	if current == 0 {
+		reset = s.window - elapsed
+	}
+	if used == 0 {
+		reset = 0
+	}
+	return s.limit, maxInt(s.limit-used, 0), reset
+}
+
+func (s *slidingWindowCounter) Reset(key string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.windows, key)
+}
+
+func (s *slidingWindowCounter) Cleanup(now time.Time, expiration time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for key, w := range s.windows {
+		if now.Sub(w.start) > expiration {
+			delete(s.windows, key)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlidingWindowCounter_Interpolation(t *testing.T) {
+	counter := newSlidingWindowCounter(10, time.Minute)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// 在第一个窗口内用完全部额度
+	for i := 0; i < 10; i++ {
+		allowed, _ := counter.Take("key", 1, start.Add(time.Second))
+		assert.True(t, allowed)
+	}
+	allowed, retryAfter := counter.Take("key", 1, start.Add(time.Second))
+	assert.False(t, allowed)
+	assert.Equal(t, time.Second*59, retryAfter)
+
+	// 进入下一个窗口 15 秒时，上一窗口仍占 75% 权重，估算值为 7.5
+	next := start.Add(time.Minute + time.Second*15)
+	for i := 0; i < 2; i++ {
+		allowed, _ = counter.Take("key", 1, next)
+		assert.True(t, allowed)
+	}
+	allowed, retryAfter = counter.Take("key", 1, next)
+	assert.False(t, allowed)
+	assert.Equal(t, time.Second*3, retryAfter)
+
+	// 超过两个窗口后计数完全重置
+	allowed, _ = counter.Take("key", 10, start.Add(time.Minute*3))
+	assert.True(t, allowed)
+}
+
+func TestSlidingWindowCounter_Cleanup(t *testing.T) {
+	counter := newSlidingWindowCounter(1, time.Second)
+	now := time.Now()
+	counter.Take("key", 1, now)
+
+	counter.Cleanup(now.Add(time.Minute), time.Second*5)
+
+	_, exists := counter.windows["key"]
+	assert.False(t, exists, "Expected window to be cleaned up")
+}
+
+func TestRateLimiterSlidingWindowAlgorithm(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	config := RateLimitConfig{
+		MaxTokens:          2,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Algorithm:          SlidingWindowCounter,
+	}
+
+	limiterMiddleware, err := NewRateLimiter(config)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+
+	for _, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, want, w.Code)
+	}
+}
+
+func TestValidateAlgorithm(t *testing.T) {
+	config := RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Second,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Algorithm:          "unknown",
+	}
+	assert.Error(t, config.Validate())
+
+	config.Algorithm = SlidingWindowCounter
+	config.Store = newMapStore()
+	assert.Error(t, config.Validate())
+}
@@ -0,0 +1,83 @@
+package limiter
+
+import (
+	"context"
+	"time"
+)
+
+// Stats is a snapshot of a limiter's activity. Keys only counts the
+// in-memory token buckets; state kept by a Store or an Algorithm is not
+// included. In DryRun mode every request counts as allowed and
+// DryRunDenied counts those that would have been rejected.
+type Stats struct {
+	Keys         int
+	Allowed      uint64
+	Denied       uint64
+	DryRunDenied uint64
+}
+
+// Resetter is implemented by algorithms that can forget the state of a
+// single key.
+type Resetter interface {
+	Reset(key string)
+}
+
+func (rl *RateLimiter) Stats() Stats {
+	rl.mutex.RLock()
+	keys := len(rl.buckets)
+	rl.mutex.RUnlock()
+
+	return Stats{
+		Keys:         keys,
+		Allowed:      rl.allowed.Load(),
+		Denied:       rl.denied.Load(),
+		DryRunDenied: rl.dryRunDenied.Load(),
+	}
+}
+
+// Config returns the config in effect, including the limits applied by
+// UpdateConfig since the limiter was created.
+func (rl *RateLimiter) Config() RateLimitConfig {
+	return rl.limits().config
+}
+
+// Reset gives key a full bucket again, both in memory and in the Store.
+func (rl *RateLimiter) Reset(ctx context.Context, key string) error {
+	return rl.reset(ctx, rl.storeKey(key))
+}
+
+func (rl *RateLimiter) reset(ctx context.Context, key string) error {
+	rl.mutex.Lock()
+	delete(rl.buckets, key)
+	rl.mutex.Unlock()
+
+	if resetter, ok := rl.algorithm.(Resetter); ok {
+		resetter.Reset(key)
+	}
+	if rl.rules != nil {
+		rl.rules.reset(key)
+	}
+
+	if rl.config.Store == nil {
+		return nil
+	}
+	for attempt := 0; attempt < maxStoreAttempts; attempt++ {
+		_, revision, err := rl.config.Store.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		value := rl.newBucket(key, time.Now()).encode()
+		if _, ok := rl.algorithm.(*gcra); ok {
+			// An empty TAT means the key has no history.
+			value = nil
+		}
+		ok, err := rl.config.Store.CompareAndSwap(ctx, key, revision, value, rl.config.ExpirationDuration)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+	}
+	return ErrStoreContention
+}
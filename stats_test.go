@@ -0,0 +1,74 @@
+package limiter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterStatsAndReset(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	limiter, err := New(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Store:              newMapStore(),
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiter.RateLimitMiddleware())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+	for _, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, want, w.Code)
+	}
+
+	stats := limiter.Stats()
+	assert.Equal(t, uint64(1), stats.Allowed)
+	assert.Equal(t, uint64(1), stats.Denied)
+
+	// 重置后该键重新获得完整的令牌桶
+	assert.NoError(t, limiter.Reset(context.Background(), "192.168.1.1"))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestResetAlgorithmState(t *testing.T) {
+	limiter, err := New(RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Algorithm:          FixedWindow,
+	})
+	assert.NoError(t, err)
+
+	now := time.Now()
+	allowed, _ := limiter.algorithm.Take("key", 1, now)
+	assert.True(t, allowed)
+	allowed, _ = limiter.algorithm.Take("key", 1, now)
+	assert.False(t, allowed)
+
+	assert.NoError(t, limiter.Reset(context.Background(), "key"))
+	allowed, _ = limiter.algorithm.Take("key", 1, now)
+	assert.True(t, allowed)
+}
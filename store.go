@@ -0,0 +1,73 @@
+package limiter
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// Store keeps bucket state outside the process so that several instances
+// can enforce one shared limit. Implementations only need to provide
+// optimistic concurrency; the refill arithmetic stays in the limiter.
+type Store interface {
+	// Get returns the value stored under key together with its revision.
+	// A missing key is reported as a nil value and revision 0.
+	Get(ctx context.Context, key string) (value []byte, revision int64, err error)
+	// CompareAndSwap writes value only if key still has the given revision
+	// (0 meaning "does not exist") and expires it after ttl.
+	CompareAndSwap(ctx context.Context, key string, revision int64, value []byte, ttl time.Duration) (bool, error)
+}
+
+const maxStoreAttempts = 10
+
+var ErrStoreContention = errors.New("limiter: too many concurrent updates to the same key")
+
+func (rl *RateLimiter) takeFromStore(ctx context.Context, key string, n int) (bool, rejection, error) {
+	for attempt := 0; attempt < maxStoreAttempts; attempt++ {
+		value, revision, err := rl.config.Store.Get(ctx, key)
+		if err != nil {
+			return false, rejection{}, err
+		}
+
+		now := time.Now()
+		bucket := rl.newBucket(key, now)
+		if value != nil {
+			bucket.decode(value)
+		}
+		bucket.refill(now)
+
+		if bucket.tokens < n {
+			r := rejection{retryAfter: bucket.retryAfter(n, now), quoted: true}
+			r.usage.limit, r.usage.remaining, r.usage.reset = bucket.quota(now)
+			return false, r, nil
+		}
+		bucket.tokens -= n
+
+		ok, err := rl.config.Store.CompareAndSwap(ctx, key, revision, bucket.encode(), rl.config.ExpirationDuration)
+		if err != nil {
+			return false, rejection{}, err
+		}
+		if ok {
+			r := rejection{quoted: true}
+			r.usage.limit, r.usage.remaining, r.usage.reset = bucket.quota(now)
+			return true, r, nil
+		}
+	}
+	return false, rejection{}, ErrStoreContention
+}
+
+func (b *tokenBucket) encode() []byte {
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint64(buf[0:8], uint64(b.tokens))
+	binary.BigEndian.PutUint64(buf[8:16], uint64(b.lastRefill.UnixNano()))
+	return buf
+}
+
+func (b *tokenBucket) decode(buf []byte) {
+	if len(buf) != 16 {
+		return
+	}
+	b.tokens = int(binary.BigEndian.Uint64(buf[0:8]))
+	b.lastRefill = time.Unix(0, int64(binary.BigEndian.Uint64(buf[8:16])))
+}
@@ -0,0 +1,119 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// mapStore 是用于测试的内存 Store 实现
+type mapStore struct {
+	mutex     sync.Mutex
+	values    map[string][]byte
+	revisions map[string]int64
+	err       error
+	gets      int
+}
+
+func newMapStore() *mapStore {
+	return &mapStore{
+		values:    make(map[string][]byte),
+		revisions: make(map[string]int64),
+	}
+}
+
+func (s *mapStore) Get(ctx context.Context, key string) ([]byte, int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.gets++
+	if s.err != nil {
+		return nil, 0, s.err
+	}
+	return s.values[key], s.revisions[key], nil
+}
+
+func (s *mapStore) CompareAndSwap(ctx context.Context, key string, revision int64, value []byte, ttl time.Duration) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.err != nil {
+		return false, s.err
+	}
+	if s.revisions[key] != revision {
+		return false, nil
+	}
+	s.values[key] = value
+	s.revisions[key] = revision + 1
+	return true, nil
+}
+
+func TestRateLimiterWithStore(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	store := newMapStore()
+	config := RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Second,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Store:              store,
+	}
+
+	// 两个中间件实例共享同一个 Store，模拟多实例部署
+	first, err := NewRateLimiter(config)
+	assert.NoError(t, err)
+	second, err := NewRateLimiter(config)
+	assert.NoError(t, err)
+
+	newRouter := func(middleware gin.HandlerFunc) *gin.Engine {
+		router := gin.New()
+		router.Use(middleware)
+		router.GET("/", func(c *gin.Context) {
+			c.String(http.StatusOK, "Hello, world!")
+		})
+		return router
+	}
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+
+	// 第一个实例消耗唯一的令牌
+	w := httptest.NewRecorder()
+	newRouter(first).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 响应头使用取令牌时读到的用量，不会再读一次 Store
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, 1, store.gets)
+
+	// 第二个实例应该看到令牌已被消耗
+	w = httptest.NewRecorder()
+	newRouter(second).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	// 存储出错时放行请求
+	store.err = errors.New("unavailable")
+	w = httptest.NewRecorder()
+	newRouter(second).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestTokenBucketEncodeDecode(t *testing.T) {
+	now := time.Unix(0, time.Now().UnixNano())
+	bucket := &tokenBucket{tokens: 7, lastRefill: now}
+
+	decoded := &tokenBucket{}
+	decoded.decode(bucket.encode())
+
+	assert.Equal(t, 7, decoded.tokens)
+	assert.True(t, now.Equal(decoded.lastRefill))
+}
@@ -0,0 +1,55 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// TarpitConfig slows clients down instead of answering them right away.
+// Once a key has used Threshold of its limit, admitted requests are delayed
+// by a share of MaxDelay that grows linearly until the limit is reached;
+// denied requests wait the full MaxDelay before they are rejected. Every
+// delayed request keeps its goroutine and connection busy meanwhile.
+type TarpitConfig struct {
+	Threshold float64
+	MaxDelay  time.Duration
+}
+
+func (t *TarpitConfig) Validate() error {
+	if t.Threshold < 0 || t.Threshold >= 1 {
+		return errors.New("Tarpit.Threshold must be between 0 and 1")
+	}
+	if t.MaxDelay <= 0 {
+		return errors.New("Tarpit.MaxDelay must be greater than 0")
+	}
+	return nil
+}
+
+// delay is how long to hold a request given the usage of its key.
+func (t *TarpitConfig) delay(u usage, known, allowed bool) time.Duration {
+	if !allowed {
+		return t.MaxDelay
+	}
+	if !known || u.limit <= 0 {
+		return 0
+	}
+	start := t.Threshold * float64(u.limit)
+	used := float64(u.limit - u.remaining)
+	if used <= start {
+		return 0
+	}
+	return time.Duration(float64(t.MaxDelay) * (used - start) / (float64(u.limit) - start))
+}
+
+// sleep waits for d and reports false if ctx ends first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,81 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTarpitDelay(t *testing.T) {
+	tarpit := &TarpitConfig{Threshold: 0.5, MaxDelay: time.Second}
+
+	// 未达到阈值时不延迟
+	assert.Equal(t, time.Duration(0), tarpit.delay(usage{limit: 10, remaining: 5}, true, true))
+	// 超过阈值后延迟线性增长
+	assert.Equal(t, time.Second*2/5, tarpit.delay(usage{limit: 10, remaining: 3}, true, true))
+	assert.Equal(t, time.Second, tarpit.delay(usage{limit: 10, remaining: 0}, true, true))
+	// 被拒绝的请求总是等待最长延迟
+	assert.Equal(t, time.Second, tarpit.delay(usage{}, false, false))
+	assert.Equal(t, time.Duration(0), tarpit.delay(usage{}, false, true))
+}
+
+func TestTarpitMiddleware(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+		MaxTokens:          2,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Tarpit:             &TarpitConfig{Threshold: 0.5, MaxDelay: time.Millisecond * 100},
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+
+	// 第一个请求未达到阈值，立即返回
+	start := time.Now()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Less(t, time.Since(start), time.Millisecond*50)
+
+	// 用尽限额的请求和被拒绝的请求都被拖慢
+	for _, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		start = time.Now()
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, want, w.Code)
+		assert.GreaterOrEqual(t, time.Since(start), time.Millisecond*100)
+	}
+}
+
+func TestValidateTarpit(t *testing.T) {
+	config := RateLimitConfig{
+		MaxTokens:          1,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		Tarpit:             &TarpitConfig{Threshold: 1, MaxDelay: time.Second},
+	}
+	assert.EqualError(t, config.Validate(), "Tarpit.Threshold must be between 0 and 1")
+
+	config.Tarpit = &TarpitConfig{Threshold: 0.5}
+	assert.EqualError(t, config.Validate(), "Tarpit.MaxDelay must be greater than 0")
+}
@@ -0,0 +1,132 @@
+package limiter
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TenantLimiter gives the tenants of a multi-tenant service that SetTenant
+// configured their own limiter, with separate buckets, quota and Stats, so
+// they can be inspected and reset independently. The tenant of a request
+// is found by TenantFunc. All other tenants share one limiter with the
+// default config, in which each tenant still has its own buckets, so
+// inventing tenant names creates no limiters. With a Store, each
+// configured tenant's keys are namespaced by its name.
+type TenantLimiter struct {
+	tenantFunc func(*gin.Context) string
+	defaults   *RateLimiter
+	tenants    map[string]*RateLimiter
+	mutex      sync.RWMutex
+}
+
+func NewTenantLimiter(tenantFunc func(*gin.Context) string, config RateLimitConfig) (*TenantLimiter, error) {
+	if tenantFunc == nil {
+		return nil, errors.New("tenantFunc must not be nil")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	keyFunc, fallback := config.KeyFunc, config.FallbackKeyFunc
+	config.KeyFunc = func(c *gin.Context) string {
+		key := keyFunc(c)
+		if key == "" {
+			key = fallback(c)
+		}
+		return tenantNamespace(tenantFunc(c)) + ":" + key
+	}
+	defaults, err := New(config)
+	if err != nil {
+		return nil, err
+	}
+	return &TenantLimiter{
+		tenantFunc: tenantFunc,
+		defaults:   defaults,
+		tenants:    make(map[string]*RateLimiter),
+	}, nil
+}
+
+// tenantNamespace escapes name so that it cannot run into the key.
+func tenantNamespace(name string) string {
+	return strings.ReplaceAll(sanitizeKey(name), ":", "%3A")
+}
+
+// tenantConfig namespaces config by name if it uses a Store.
+func (t *TenantLimiter) tenantConfig(name string, config RateLimitConfig) RateLimitConfig {
+	if config.Store != nil {
+		namespace := tenantNamespace(name)
+		if config.Namespace != "" {
+			namespace = config.Namespace + "/" + namespace
+		}
+		config.Namespace = namespace
+	}
+	return config
+}
+
+// SetTenant gives a tenant its own configuration, e.g. a larger quota for
+// a paid plan, replacing its limiter and thereby its buckets.
+func (t *TenantLimiter) SetTenant(name string, config RateLimitConfig) error {
+	limiter, err := New(t.tenantConfig(name, config))
+	if err != nil {
+		return errors.New("tenant " + name + ": " + err.Error())
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.tenants[name] = limiter
+	return nil
+}
+
+// Tenant returns the limiter of a tenant configured by SetTenant, or else
+// the limiter that all other tenants share.
+func (t *TenantLimiter) Tenant(name string) *RateLimiter {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	if limiter, ok := t.tenants[name]; ok {
+		return limiter
+	}
+	return t.defaults
+}
+
+// Tenants lists the tenants configured by SetTenant.
+func (t *TenantLimiter) Tenants() []string {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	names := make([]string, 0, len(t.tenants))
+	for name := range t.tenants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RemoveTenant drops a tenant's own limiter and in-memory buckets, after
+// which it shares the default limiter again. Buckets kept in a Store are
+// not reset: they have the same keys in the default limiter, which
+// carries on from them until they expire.
+func (t *TenantLimiter) RemoveTenant(name string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.tenants, name)
+}
+
+// CleanupExpiredBuckets cleans up the default limiter and every tenant's
+// own limiter.
+func (t *TenantLimiter) CleanupExpiredBuckets() {
+	t.defaults.CleanupExpiredBuckets()
+
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	for _, limiter := range t.tenants {
+		limiter.CleanupExpiredBuckets()
+	}
+}
+
+func (t *TenantLimiter) RateLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t.Tenant(t.tenantFunc(c)).RateLimitMiddleware()(c)
+	}
+}
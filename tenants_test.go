@@ -0,0 +1,63 @@
+package limiter
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantLimiter(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	store := newMapStore()
+	defaults, enterprise := perMinute(1), perMinute(3)
+	defaults.Store, enterprise.Store = store, store
+
+	tenants, err := NewTenantLimiter(ByHeader("X-Tenant"), defaults)
+	assert.NoError(t, err)
+	assert.NoError(t, tenants.SetTenant("enterprise", enterprise))
+	assert.Error(t, tenants.SetTenant("bad", RateLimitConfig{}))
+
+	router := gin.New()
+	router.Use(tenants.RateLimitMiddleware())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func(tenant string) int {
+		return serve(router, "GET", "/", http.Header{"X-Tenant": {tenant}}).Code
+	}
+
+	// 同一客户端在不同租户下拥有独立的令牌桶
+	assert.Equal(t, http.StatusOK, request("acme"))
+	assert.Equal(t, http.StatusTooManyRequests, request("acme"))
+	assert.Equal(t, http.StatusOK, request("globex"))
+
+	// 单独配置的租户使用自己的额度
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, request("enterprise"))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, request("enterprise"))
+
+	// 未配置的租户共用默认限流器，不会为每个租户名创建限流器
+	assert.Equal(t, []string{"enterprise"}, tenants.Tenants())
+	assert.Same(t, tenants.Tenant("acme"), tenants.Tenant("globex"))
+	assert.Equal(t, uint64(1), tenants.Tenant("acme").Stats().Denied)
+	assert.Equal(t, uint64(1), tenants.Tenant("enterprise").Stats().Denied)
+	assert.Contains(t, store.values, "acme:192.168.1.1")
+	assert.Contains(t, store.values, "enterprise:192.168.1.1")
+
+	// 可以单独重置某个租户的键
+	assert.NoError(t, tenants.Tenant("acme").Reset(context.Background(), "acme:192.168.1.1"))
+	assert.Equal(t, http.StatusOK, request("acme"))
+
+	// 移除后租户回到默认限流器，存储中的令牌桶并不会被重置
+	tenants.RemoveTenant("enterprise")
+	assert.Equal(t, []string{}, tenants.Tenants())
+	assert.Same(t, tenants.Tenant("acme"), tenants.Tenant("enterprise"))
+	assert.Equal(t, http.StatusTooManyRequests, request("enterprise"))
+}
@@ -0,0 +1,26 @@
+package limiter
+
+import "time"
+
+// UnmatchedRule is the Rule reported in LimitInfo when the UnmatchedRoute
+// limit denied a request.
+const UnmatchedRule = "unmatched"
+
+func newUnmatched(limit Limit) *stackedRules {
+	return newStackedRules([]Rule{{Name: UnmatchedRule, Limit: limit}})
+}
+
+// takeUnmatched charges a request that matched no route to its key's
+// UnmatchedRoute bucket instead of the main limits, so scanners probing
+// random paths neither drain the budget of real endpoints nor get more
+// than the stricter 404 allowance. Admitted requests report the usage of
+// that bucket as well.
+func (rl *RateLimiter) takeUnmatched(key string) (bool, rejection) {
+	now := time.Now()
+	allowed := rl.unmatched.take(key, 1, now)
+	r := rl.unmatched.reject(key, 1, now)
+	if allowed {
+		return true, rejection{usage: r.usage, quoted: true}
+	}
+	return false, r
+}
@@ -0,0 +1,68 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterUnmatchedRoute(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	config := RateLimitConfig{
+		MaxTokens:          2,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		UnmatchedRoute:     &Limit{MaxTokens: 1, RefillRate: 1, RefillInterval: time.Minute},
+	}
+
+	limiterMiddleware, err := NewRateLimiter(config)
+	assert.NoError(t, err)
+
+	var rule string
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Next()
+		info, _ := FromContext(c)
+		rule = info.Rule
+	})
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 未匹配路由的请求使用更严格的独立令牌桶，放行时也带有该桶的响应头
+	w := request("/wp-admin")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+	w = request("/.env")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.Equal(t, UnmatchedRule, rule)
+
+	// 扫描不会消耗正常接口的额度
+	assert.Equal(t, http.StatusOK, request("/").Code)
+	assert.Equal(t, http.StatusOK, request("/").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request("/").Code)
+
+	// 无效的限额会被拒绝
+	config.UnmatchedRoute = &Limit{}
+	assert.Error(t, config.Validate())
+}
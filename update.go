@@ -0,0 +1,87 @@
+package limiter
+
+import (
+	"errors"
+	"time"
+)
+
+// limits are the parts of the config that UpdateConfig may change while
+// the limiter is serving requests.
+type limits struct {
+	maxTokens       int
+	refillRate      int
+	refillInterval  time.Duration
+	burstMultiplier int
+	overrides       map[string]Limit
+	schedule        schedule
+	cron            *cronScheduler
+	// config is the config the limits were built from, for Config.
+	config RateLimitConfig
+}
+
+func (rl *RateLimiter) newLimits(config RateLimitConfig, now time.Time) *limits {
+	l := &limits{
+		maxTokens:       config.MaxTokens,
+		refillRate:      config.RefillRate,
+		refillInterval:  config.RefillInterval,
+		burstMultiplier: config.BurstMultiplier,
+		overrides:       make(map[string]Limit, len(config.Overrides)),
+		config:          config,
+	}
+	for key, limit := range config.Overrides {
+		l.overrides[rl.storeKey(key)] = limit
+	}
+	if len(config.Schedule) > 0 {
+		l.schedule = newSchedule(config.Schedule)
+	}
+	if len(config.Cron) > 0 {
+		l.cron = newCronScheduler(config.Cron, now)
+	}
+	return l
+}
+
+// limits returns the limits in effect. Limiters built without New have
+// none stored and use their config as is.
+func (rl *RateLimiter) limits() *limits {
+	if l := rl.current.Load(); l != nil {
+		return l
+	}
+	return &limits{
+		maxTokens:       rl.config.MaxTokens,
+		refillRate:      rl.config.RefillRate,
+		refillInterval:  rl.config.RefillInterval,
+		burstMultiplier: rl.config.BurstMultiplier,
+		config:          rl.config,
+	}
+}
+
+// UpdateConfig replaces the limits of a running limiter, e.g. to loosen
+// them during an incident without a restart. It applies MaxTokens,
+// RefillRate, RefillInterval, BurstMultiplier, Overrides, Schedule and
+// Cron from config and ignores its other fields. Existing buckets keep
+// their tokens and adopt the new limits on their next request. With
+// Adaptive set, the adaptive rate restarts from the new RefillRate,
+// clamped to Adaptive.MinRate and MaxRate.
+func (rl *RateLimiter) UpdateConfig(config RateLimitConfig) error {
+	if rl.algorithm != nil {
+		return errors.New("UpdateConfig is only supported by the token bucket algorithm")
+	}
+
+	next := rl.config
+	next.MaxTokens = config.MaxTokens
+	next.RefillRate = config.RefillRate
+	next.RefillInterval = config.RefillInterval
+	next.BurstMultiplier = config.BurstMultiplier
+	next.Overrides = config.Overrides
+	next.Schedule = config.Schedule
+	next.Cron = config.Cron
+	if err := next.Validate(); err != nil {
+		return err
+	}
+
+	rl.current.Store(rl.newLimits(next, time.Now()))
+	if rl.adaptive != nil {
+		rl.adaptive.rebase(next.RefillRate, next.RefillInterval, time.Now())
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterUpdateConfig(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	config := RateLimitConfig{
+		MaxTokens:          2,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            ByHeader("X-API-Key"),
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+	}
+
+	limiter, err := New(config)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiter.RateLimitMiddleware())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func(apiKey string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		req.Header.Set("X-API-Key", apiKey)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, request("a").Code)
+	assert.Equal(t, http.StatusOK, request("a").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request("a").Code)
+
+	// 放宽限额后已有的桶保留剩余令牌，只是上限变大
+	config.MaxTokens = 4
+	config.Overrides = map[string]Limit{
+		"partner": {MaxTokens: 10, RefillRate: 10, RefillInterval: time.Minute},
+	}
+	assert.NoError(t, limiter.UpdateConfig(config))
+	w := request("a")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "4", w.Header().Get("X-RateLimit-Limit"))
+
+	// 新的桶直接使用新的限额
+	w = request("b")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "4", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "3", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "10", request("partner").Header().Get("X-RateLimit-Limit"))
+
+	// 收紧限额时多余的令牌被截断
+	config.MaxTokens = 1
+	config.Overrides = nil
+	assert.NoError(t, limiter.UpdateConfig(config))
+	assert.Equal(t, http.StatusOK, request("b").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request("b").Code)
+
+	// 无效的配置被拒绝，原有限额不变
+	config.MaxTokens = 0
+	assert.Error(t, limiter.UpdateConfig(config))
+	assert.Equal(t, "1", request("c").Header().Get("X-RateLimit-Limit"))
+
+	// Config 返回当前生效的限额
+	assert.Equal(t, 1, limiter.Config().MaxTokens)
+	assert.Equal(t, 0, len(limiter.Config().Overrides))
+
+	// 其他算法不支持运行时更新
+	config.MaxTokens = 2
+	config.Algorithm = FixedWindow
+	other, err := New(config)
+	assert.NoError(t, err)
+	assert.Error(t, other.UpdateConfig(config))
+}
+
+func TestRateLimiterUpdateConfigAdaptive(t *testing.T) {
+	config := RateLimitConfig{
+		MaxTokens:          10,
+		RefillRate:         4,
+		RefillInterval:     time.Second,
+		KeyFunc:            ByClientIP(),
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute,
+		Adaptive: &AdaptiveConfig{
+			MinRate:        2,
+			MaxRate:        8,
+			IncreaseStep:   1,
+			DecreaseFactor: 0.5,
+		},
+	}
+	limiter, err := New(config)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, limiter.adaptive.current())
+
+	// 更新 RefillRate 后自适应速率从新值重新开始，并限制在 MinRate 和 MaxRate 之间
+	config.RefillRate = 6
+	assert.NoError(t, limiter.UpdateConfig(config))
+	assert.Equal(t, 6, limiter.adaptive.current())
+	config.RefillRate = 20
+	assert.NoError(t, limiter.UpdateConfig(config))
+	assert.Equal(t, 8, limiter.adaptive.current())
+	assert.Equal(t, 20, limiter.Config().RefillRate)
+}
@@ -0,0 +1,46 @@
+package limiter
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserAgentRule assigns Class to requests whose User-Agent matches
+// Pattern, a case-insensitive regular expression.
+type UserAgentRule struct {
+	Class   string
+	Pattern string
+}
+
+// DefaultUserAgentRules tell well-known crawlers, headless browsers and
+// scripted HTTP clients apart from other traffic.
+var DefaultUserAgentRules = []UserAgentRule{
+	{Class: "bot", Pattern: `googlebot|bingbot|duckduckbot|applebot|yandexbot|baiduspider`},
+	{Class: "headless", Pattern: `headlesschrome|phantomjs|puppeteer|playwright|selenium`},
+	{Class: "scraper", Pattern: `^$|curl|wget|python-requests|python-urllib|scrapy|go-http-client|libwww|httpclient`},
+}
+
+// UserAgentClass is a PolicySet selector that names the class of a request
+// by the first of rules whose pattern matches its User-Agent, so scrapers
+// can get stricter limits than browsers. Unmatched requests give "".
+func UserAgentClass(rules []UserAgentRule) (func(*gin.Context) string, error) {
+	patterns := make([]*regexp.Regexp, len(rules))
+	for i, rule := range rules {
+		pattern, err := regexp.Compile("(?i)" + rule.Pattern)
+		if err != nil {
+			return nil, errors.New("invalid User-Agent pattern for " + rule.Class + ": " + err.Error())
+		}
+		patterns[i] = pattern
+	}
+	return func(c *gin.Context) string {
+		userAgent := c.GetHeader("User-Agent")
+		for i, pattern := range patterns {
+			if pattern.MatchString(userAgent) {
+				return rules[i].Class
+			}
+		}
+		return ""
+	}, nil
+}
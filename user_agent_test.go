@@ -0,0 +1,38 @@
+package limiter
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserAgentClass(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	classes, err := UserAgentClass(DefaultUserAgentRules)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		userAgent string
+		class     string
+	}{
+		{"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", "bot"},
+		{"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/120.0.0.0 Safari/537.36", "headless"},
+		{"python-requests/2.31.0", "scraper"},
+		{"curl/8.4.0", "scraper"},
+		{"", "scraper"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36", ""},
+	}
+
+	for _, tt := range tests {
+		c := newKeyContext(func(req *http.Request) { req.Header.Set("User-Agent", tt.userAgent) })
+		assert.Equal(t, tt.class, classes(c), tt.userAgent)
+	}
+
+	// 无效的正则表达式会被拒绝
+	_, err = UserAgentClass([]UserAgentRule{{Class: "bad", Pattern: "("}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,117 @@
+package limiter
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const visitorKey = "limiter.visitor"
+
+// pendingVisitor is a cookie that ByVisitorCookie prepared for a request
+// and that is only sent once the limiter admits it.
+type pendingVisitor struct {
+	cookie  *http.Cookie
+	ip      string
+	issuer  *ipQuota
+	settled bool
+}
+
+// ipQuota counts events per client IP in fixed windows, such as the
+// visitor IDs issued to an IP per hour, so a single client cannot cause
+// them by the thousand.
+type ipQuota struct {
+	max    int
+	window time.Duration
+	start  time.Time
+	counts map[string]int
+	mutex  sync.Mutex
+}
+
+func newIPQuota(max int, window time.Duration) *ipQuota {
+	return &ipQuota{max: max, window: window, counts: make(map[string]int)}
+}
+
+func (q *ipQuota) allow(ip string, now time.Time) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if now.Sub(q.start) >= q.window {
+		q.start = now
+		q.counts = make(map[string]int)
+	}
+	if q.counts[ip] >= q.max {
+		return false
+	}
+	q.counts[ip]++
+	return true
+}
+
+// ByVisitorCookie keys anonymous visitors by a random ID kept in a signed,
+// HttpOnly cookie, so an office behind one NAT address is not throttled
+// as a single client. Visitors without a valid cookie are keyed like
+// ByClientIP until they return one, which they are only sent with an
+// admitted response and at most maxNew times per client IP and hour.
+// Clients that never store cookies therefore stay keyed by IP, and
+// neither dropping the cookie nor hoarding new ones escapes the limit.
+func ByVisitorCookie(name string, secret []byte, maxAge time.Duration, maxNew int) func(*gin.Context) string {
+	clientIP := ByClientIP()
+	issuer := newIPQuota(maxNew, time.Hour)
+	sign := func(id string) string {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(id))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	return func(c *gin.Context) string {
+		if value, err := c.Cookie(name); err == nil {
+			id, signature, ok := strings.Cut(value, ".")
+			if ok && id != "" && hmac.Equal([]byte(signature), []byte(sign(id))) {
+				return id
+			}
+		}
+		ip := clientIP(c)
+		if _, prepared := c.Get(visitorKey); !prepared {
+			nonce := make([]byte, 16)
+			_, _ = rand.Read(nonce)
+			id := hex.EncodeToString(nonce)
+			c.Set(visitorKey, &pendingVisitor{
+				cookie: &http.Cookie{
+					Name:     name,
+					Value:    id + "." + sign(id),
+					Path:     "/",
+					MaxAge:   int(maxAge / time.Second),
+					Secure:   c.Request.TLS != nil,
+					HttpOnly: true,
+					SameSite: http.SameSiteLaxMode,
+				},
+				ip:     ip,
+				issuer: issuer,
+			})
+		}
+		return ip
+	}
+}
+
+// issueVisitor sends the cookie ByVisitorCookie prepared for c, if any and
+// if the client IP has not used up its new IDs yet.
+func issueVisitor(c *gin.Context) {
+	value, ok := c.Get(visitorKey)
+	if !ok {
+		return
+	}
+	pending := value.(*pendingVisitor)
+	if pending.settled {
+		return
+	}
+	pending.settled = true
+	if pending.issuer.allow(pending.ip, time.Now()) {
+		http.SetCookie(c.Writer, pending.cookie)
+	}
+}
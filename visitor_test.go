@@ -0,0 +1,79 @@
+package limiter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestByVisitorCookie(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	limiter, err := New(RateLimitConfig{
+		MaxTokens:          2,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            ByVisitorCookie("visitor", []byte("secret"), time.Hour, 2),
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiter.RateLimitMiddleware())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	request := func(cookie *http.Cookie) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "192.168.1.1:1234"
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 首次访问按 IP 计数并下发签名 Cookie
+	w := request(nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	cookies := w.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.True(t, cookies[0].HttpOnly)
+	alice := cookies[0]
+
+	// 同一 NAT 后的另一个访客拿到自己的 Cookie
+	w = request(nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	bob := w.Result().Cookies()[0]
+	assert.NotEqual(t, alice.Value, bob.Value)
+
+	// 被拒绝的请求不会拿到新的 Cookie
+	w = request(nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Len(t, w.Result().Cookies(), 0)
+
+	// 持有 Cookie 的访客拥有独立的令牌桶
+	assert.Equal(t, http.StatusOK, request(alice).Code)
+	assert.Equal(t, http.StatusOK, request(bob).Code)
+	assert.Equal(t, http.StatusOK, request(alice).Code)
+	assert.Equal(t, http.StatusTooManyRequests, request(alice).Code)
+
+	// 伪造的 Cookie 不被接受，按 IP 计数
+	w = request(&http.Cookie{Name: "visitor", Value: "forged.0000"})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	// 每个 IP 每小时只能拿到有限个新 Cookie
+	assert.NoError(t, limiter.Reset(context.Background(), "192.168.1.1"))
+	w = request(nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, w.Result().Cookies(), 0)
+}
@@ -0,0 +1,43 @@
+package limiter
+
+import (
+	"context"
+	"time"
+)
+
+// wait keeps retrying a denied request until a token frees up or timeout
+// elapses; a timeout of 0 waits as long as ctx allows. Each attempt sleeps
+// for the retryAfter reported by the previous rejection r.
+func (rl *RateLimiter) wait(ctx context.Context, key string, n int, scopes []string, timeout time.Duration, r rejection) (bool, rejection, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		delay := r.retryAfter
+		if delay <= 0 {
+			l := rl.limits()
+			delay = l.refillInterval / time.Duration(l.refillRate)
+		}
+		if timeout > 0 {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				return false, r, nil
+			}
+			if delay > remaining {
+				delay = remaining
+			}
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return false, r, nil
+		}
+
+		allowed, next, err := rl.takeScoped(ctx, key, n, scopes)
+		if err != nil || allowed {
+			return allowed, next, err
+		}
+		r = next
+	}
+}
@@ -0,0 +1,29 @@
+package limiter
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// warn flags an admitted request whose key has used up WarningThreshold of
+// its limit, so clients can back off before they are rejected.
+func (rl *RateLimiter) warn(c *gin.Context, key string, u usage, now time.Time) {
+	used := u.limit - u.remaining
+	if u.limit <= 0 || float64(used) < rl.config.WarningThreshold*float64(u.limit) {
+		return
+	}
+
+	if !rl.config.DisableHeaders {
+		c.Header("X-RateLimit-Warning", strconv.Itoa(used*100/u.limit)+"% of rate limit used")
+	}
+	if rl.config.OnWarning != nil {
+		rl.config.OnWarning(c, LimitInfo{
+			Key:       key,
+			Limit:     u.limit,
+			Remaining: u.remaining,
+			Reset:     now.Add(u.reset),
+		})
+	}
+}
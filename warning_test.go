@@ -0,0 +1,56 @@
+package limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarningThreshold(t *testing.T) {
+	// 设置 Gin 测试模式
+	gin.SetMode(gin.TestMode)
+
+	var warnings []LimitInfo
+	limiterMiddleware, err := NewRateLimiter(RateLimitConfig{
+		MaxTokens:          5,
+		RefillRate:         1,
+		RefillInterval:     time.Minute,
+		KeyFunc:            func(c *gin.Context) string { return c.ClientIP() },
+		BurstMultiplier:    1,
+		ExpirationDuration: time.Minute * 5,
+		WarningThreshold:   0.8,
+		OnWarning: func(c *gin.Context, info LimitInfo) {
+			warnings = append(warnings, info)
+		},
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(limiterMiddleware)
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "Hello, world!")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.168.1.1:1234"
+
+	// 前三个请求低于阈值，不发出警告
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Empty(t, w.Header().Get("X-RateLimit-Warning"))
+	}
+	assert.Empty(t, warnings)
+
+	// 达到 80% 后仍然放行，但附带警告
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "80% of rate limit used", w.Header().Get("X-RateLimit-Warning"))
+	assert.Len(t, warnings, 1)
+	assert.Equal(t, 1, warnings[0].Remaining)
+}
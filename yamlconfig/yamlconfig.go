@@ -0,0 +1,81 @@
+// Package yamlconfig reads limiter config files written in YAML. Importing
+// it makes limiter.LoadConfig read .yaml and .yml files:
+//
+//	import _ "github.com/colommar/gin-ratelimiter/yamlconfig"
+//
+// The fields are those of limiter.ParseConfig:
+//
+//	limiters:
+//	  search:
+//	    key: header:X-API-Key
+//	    max_tokens: 100
+//	    refill_rate: 100
+//	    refill_interval: 1m
+//	    burst_multiplier: 1
+//	    expiration: 10m
+//	    exempt_networks: [10.0.0.0/8]
+//	    overrides:
+//	      partner: {max_tokens: 1000, refill_rate: 1000, refill_interval: 1m}
+//	    rules:
+//	      - {name: hourly, max_tokens: 1000, refill_rate: 1000, refill_interval: 1h}
+//	routes:
+//	  POST /login: {max_tokens: 5, refill_rate: 5, refill_interval: 1m, burst_multiplier: 1, expiration: 10m}
+package yamlconfig
+
+import (
+	"encoding/json"
+	"fmt"
+
+	limiter "github.com/colommar/gin-ratelimiter"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	limiter.RegisterConfigFormat(".yaml", ToJSON)
+	limiter.RegisterConfigFormat(".yml", ToJSON)
+}
+
+// Parse decodes named limiters and per-route limits from YAML and
+// validates them.
+func Parse(data []byte) (*limiter.FileConfig, error) {
+	data, err := ToJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return limiter.ParseConfig(data)
+}
+
+// ToJSON converts a YAML document into the JSON that limiter.ParseConfig
+// reads, so both formats accept exactly the same fields. It is the
+// limiter.ConfigFormat registered for .yaml and .yml.
+func ToJSON(data []byte) ([]byte, error) {
+	var document any
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonValue(document))
+}
+
+// jsonValue turns mappings with non-string keys, such as overrides for
+// numeric account IDs, into maps that encoding/json can marshal.
+func jsonValue(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, item := range value {
+			value[key] = jsonValue(item)
+		}
+		return value
+	case map[any]any:
+		converted := make(map[string]any, len(value))
+		for key, item := range value {
+			converted[fmt.Sprint(key)] = jsonValue(item)
+		}
+		return converted
+	case []any:
+		for i, item := range value {
+			value[i] = jsonValue(item)
+		}
+		return value
+	}
+	return value
+}
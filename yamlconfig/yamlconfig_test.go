@@ -0,0 +1,57 @@
+package yamlconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	limiter "github.com/colommar/gin-ratelimiter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	// YAML 文件描述命名限流器和按路由的限额
+	path := filepath.Join(dir, "limits.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(`
+limiters:
+  search:
+    key: header:X-API-Key
+    max_tokens: 100
+    refill_rate: 100
+    refill_interval: 1m
+    burst_multiplier: 1
+    expiration: 10m
+    exempt_networks: [10.0.0.0/8]
+    overrides:
+      partner: {max_tokens: 1000, refill_rate: 1000, refill_interval: 1m}
+    rules:
+      - {name: hourly, max_tokens: 1000, refill_rate: 1000, refill_interval: 1h}
+routes:
+  POST /login:
+    max_tokens: 5
+    refill_rate: 5
+    refill_interval: 1m
+    burst_multiplier: 1
+    expiration: 10m
+`), 0o600))
+
+	// 导入本包后 LoadConfig 按扩展名读取 YAML
+	config, err := limiter.LoadConfig(path)
+	assert.NoError(t, err)
+	search := config.Limiters["search"]
+	assert.Equal(t, 100, search.MaxTokens)
+	assert.Equal(t, time.Minute, search.RefillInterval)
+	assert.Equal(t, 10*time.Minute, search.ExpirationDuration)
+	assert.Equal(t, []string{"10.0.0.0/8"}, search.ExemptNetworks)
+	assert.Equal(t, limiter.Limit{MaxTokens: 1000, RefillRate: 1000, RefillInterval: time.Minute}, search.Overrides["partner"])
+	assert.Equal(t, []limiter.Rule{{Name: "hourly", Limit: limiter.Limit{MaxTokens: 1000, RefillRate: 1000, RefillInterval: time.Hour}}}, search.Rules)
+	assert.NotNil(t, search.KeyFunc)
+	assert.Equal(t, 5, config.Routes["POST /login"].MaxTokens)
+
+	// 未知的字段会报错
+	_, err = Parse([]byte("limiters:\n  api:\n    max_token: 1\n"))
+	assert.Error(t, err)
+}